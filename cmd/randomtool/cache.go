@@ -0,0 +1,223 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "cmp"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+    "maps"
+    "math/rand/v2"
+    "os"
+    "path/filepath"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// sumCache remembers file checksums keyed by absolute path and algorithm,
+// so that files whose size, modification time and inode are unchanged are
+// not read again. The cache file holds one JSON object per line.
+type sumCache struct {
+    path   string
+    sample float64 // fraction of hits that are re-hashed to catch stale entries
+
+    mu      sync.Mutex
+    entries map[cacheKey]cacheEntry
+    dirty   map[cacheKey]bool
+    hits    int
+    misses  int
+    stale   int
+}
+
+type cacheKey struct {
+    path      string
+    algorithm string
+}
+
+type cacheEntry struct {
+    Path      string `json:"path"`
+    Algorithm string `json:"algorithm"`
+    Size      int64  `json:"size"`
+    ModTime   int64  `json:"mtime"`
+    Inode     uint64 `json:"inode,omitempty"`
+    Checksum  string `json:"checksum"`
+}
+
+func (e cacheEntry) key() cacheKey { return cacheKey{e.Path, e.Algorithm} }
+
+// racyWindow is how recently a file may have been modified and still be
+// cached. A write landing in the same timestamp tick as the hash would
+// otherwise leave a stale entry that looks current.
+const racyWindow = 2 * time.Second
+
+// openCache loads the cache at path. A missing file is an empty cache;
+// lines that cannot be parsed are dropped.
+func openCache(path string, sample float64) (*sumCache, error) {
+    c := &sumCache{path: path, sample: sample, dirty: map[cacheKey]bool{}}
+    entries, err := readCache(path)
+    if err != nil {
+        return nil, err
+    }
+    c.entries = entries
+    return c, nil
+}
+
+func readCache(path string) (map[cacheKey]cacheEntry, error) {
+    entries := map[cacheKey]cacheEntry{}
+    f, err := os.Open(path)
+    if errors.Is(err, fs.ErrNotExist) {
+        return entries, nil
+    }
+    if err != nil {
+        return nil, &checksum.InputError{Path: path, Err: err}
+    }
+    defer f.Close()
+    sc := bufio.NewScanner(f)
+    sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+    for sc.Scan() {
+        var e cacheEntry
+        if json.Unmarshal(sc.Bytes(), &e) == nil && e.Path != "" {
+            entries[e.key()] = e
+        }
+    }
+    if err := sc.Err(); err != nil {
+        return nil, &checksum.InputError{Path: path, Err: fmt.Errorf("reading cache: %w", err)}
+    }
+    return entries, nil
+}
+
+// fileChecksum is fileChecksum backed by the cache. Standard input, keyed
+// and salted configurations are never cached.
+func (c *sumCache) fileChecksum(cfg checksum.Config, path string, stdin io.Reader, opts checksum.ReadOptions) ([]byte, input, error) {
+    if c == nil || path == "-" || cfg.Key != nil || cfg.Salt != nil || cfg.Normalize != 0 {
+        return fileChecksum(cfg, path, stdin, opts)
+    }
+    abs, err := filepath.Abs(path)
+    if err != nil {
+        return fileChecksum(cfg, path, stdin, opts)
+    }
+    before, err := os.Stat(path)
+    if err != nil || !before.Mode().IsRegular() {
+        return fileChecksum(cfg, path, stdin, opts)
+    }
+    k := cacheKey{abs, cfg.Name()}
+    cur := entryFor(k, before)
+
+    c.mu.Lock()
+    e, ok := c.entries[k]
+    c.mu.Unlock()
+    if ok && sameFile(e, cur) {
+        if digest, err := hex.DecodeString(e.Checksum); err == nil && len(digest) == cfg.Size() && rand.Float64() >= c.sample {
+            c.mu.Lock()
+            c.hits++
+            c.mu.Unlock()
+            return digest, input{name: path, kind: "file", bytes: e.Size}, nil
+        }
+    }
+
+    digest, in, err := fileChecksum(cfg, path, stdin, opts)
+    if err != nil {
+        return digest, in, err
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    switch sum := hex.EncodeToString(digest); {
+    case !ok || !sameFile(e, cur):
+        c.misses++
+    case e.Checksum == sum:
+        c.hits++
+    default:
+        c.stale++
+    }
+    after, err := os.Stat(path)
+    if err == nil && sameFile(cur, entryFor(k, after)) && time.Since(after.ModTime()) >= racyWindow {
+        cur.Checksum = hex.EncodeToString(digest)
+        c.entries[k] = cur
+        c.dirty[k] = true
+    }
+    return digest, in, nil
+}
+
+func entryFor(k cacheKey, info fs.FileInfo) cacheEntry {
+    return cacheEntry{
+        Path:      k.path,
+        Algorithm: k.algorithm,
+        Size:      info.Size(),
+        ModTime:   info.ModTime().UnixNano(),
+        Inode:     inode(info),
+    }
+}
+
+func sameFile(a, b cacheEntry) bool {
+    return a.Size == b.Size && a.ModTime == b.ModTime && a.Inode == b.Inode
+}
+
+// save merges the entries this run computed into the cache file as it is
+// now, so concurrent invocations do not lose each other's work, and
+// atomically replaces it.
+func (c *sumCache) save() error {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if len(c.dirty) == 0 {
+        return nil
+    }
+    entries, err := readCache(c.path)
+    if err != nil {
+        return err
+    }
+    for k := range c.dirty {
+        entries[k] = c.entries[k]
+    }
+
+    keys := slices.SortedFunc(maps.Keys(entries), func(a, b cacheKey) int {
+        return cmp.Or(strings.Compare(a.path, b.path), strings.Compare(a.algorithm, b.algorithm))
+    })
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    for _, k := range keys {
+        enc.Encode(entries[k])
+    }
+    tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if _, err := tmp.Write(buf.Bytes()); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    if err := os.Rename(tmp.Name(), c.path); err != nil {
+        return err
+    }
+    clear(c.dirty)
+    return nil
+}
+
+// finish saves the cache and, when verbose, reports how it was used. A
+// cache that cannot be saved turns a successful status into exitIO.
+func (c *sumCache) finish(status int, verbose bool, stderr io.Writer) int {
+    if c == nil {
+        return status
+    }
+    if err := c.save(); err != nil {
+        fmt.Fprintf(stderr, "randomtool: cache: %v\n", err)
+        if status == exitOK {
+            status = exitIO
+        }
+    }
+    if verbose {
+        fmt.Fprintf(stderr, "randomtool: cache: %d hits, %d misses, %d stale\n", c.hits, c.misses, c.stale)
+    }
+    return status
+}
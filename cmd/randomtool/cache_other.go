@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "io/fs"
+
+// inode is unavailable here; size and modification time alone decide
+// whether a cache entry is current, and dedup cannot tell hard links.
+func inode(info fs.FileInfo) uint64 { return 0 }
+
+func device(info fs.FileInfo) uint64 { return 0 }
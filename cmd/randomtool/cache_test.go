@@ -0,0 +1,290 @@
+package main
+
+import (
+    "bufio"
+    "crypto/sha1"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// oldTime is far enough in the past that files stamped with it are outside
+// racyWindow and may be cached.
+var oldTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// writeOld writes content to path and backdates it to mtime.
+func writeOld(t *testing.T, path, content string, mtime time.Time) {
+    t.Helper()
+    if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.Chtimes(path, mtime, mtime); err != nil {
+        t.Fatal(err)
+    }
+}
+
+// cacheStats runs randomtool -v with args and returns its stdout and the
+// cache summary line.
+func cacheStats(t *testing.T, args ...string) (stdout, stats string) {
+    t.Helper()
+    r := run(t, "", append([]string{"-v"}, args...)...)
+    if r.code != 0 {
+        t.Fatalf("randomtool -v %q: %+v", args, r)
+    }
+    for _, line := range strings.Split(r.stderr, "\n") {
+        if s, ok := strings.CutPrefix(line, "randomtool: cache: "); ok {
+            return r.stdout, s
+        }
+    }
+    t.Fatalf("randomtool -v %q printed no cache summary: %q", args, r.stderr)
+    return "", ""
+}
+
+// rawSHA1 is the hex SHA-1 of content, as -file computes it.
+func rawSHA1(content string) string {
+    sum := sha1.Sum([]byte(content))
+    return hex.EncodeToString(sum[:])
+}
+
+func sumLine(content, path string) string {
+    return rawSHA1(content)[:12] + "  " + path + "\n"
+}
+
+func TestCacheHit(t *testing.T) {
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    a := filepath.Join(dir, "a")
+    writeOld(t, a, "hello\n", oldTime)
+
+    for _, want := range []string{"0 hits, 1 misses, 0 stale", "1 hits, 0 misses, 0 stale", "1 hits, 0 misses, 0 stale"} {
+        stdout, stats := cacheStats(t, "-file", "-cache", cache, a)
+        if stdout != sumLine("hello\n", a) || stats != want {
+            t.Errorf("stdout %q, cache %q; want %q", stdout, stats, want)
+        }
+    }
+    if _, stats := cacheStats(t, "-file", "-algo", "sha256", "-cache", cache, a); stats != "0 hits, 1 misses, 0 stale" {
+        t.Errorf("another algorithm: cache %q, want a miss", stats)
+    }
+
+    entries, err := readCache(cache)
+    if err != nil {
+        t.Fatal(err)
+    }
+    abs, _ := filepath.Abs(a)
+    e, ok := entries[cacheKey{abs, "sha1"}]
+    if len(entries) != 2 || !ok || e.Size != 6 || e.ModTime != oldTime.UnixNano() || e.Checksum != rawSHA1("hello\n") {
+        t.Errorf("cache holds %+v", entries)
+    }
+
+    // Files modified within racyWindow are hashed but not recorded.
+    b := writeFile(t, dir, "b", "fresh")
+    for range 2 {
+        if _, stats := cacheStats(t, "-file", "-cache", cache, b); stats != "0 hits, 1 misses, 0 stale" {
+            t.Errorf("a just-written file: cache %q, want a miss each time", stats)
+        }
+    }
+
+    manifest := writeFile(t, dir, "SUMS", run(t, "", "-manifest", a).stdout)
+    for _, want := range []string{"1 hits, 0 misses, 0 stale", "1 hits, 0 misses, 0 stale"} {
+        if stdout, stats := cacheStats(t, "-check", manifest, "-cache", cache); stdout != a+": OK\n" || stats != want {
+            t.Errorf("-check: stdout %q, cache %q; want %q", stdout, stats, want)
+        }
+    }
+}
+
+func TestCacheInvalidation(t *testing.T) {
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    a := filepath.Join(dir, "a")
+    writeOld(t, a, "hello\n", oldTime)
+    cacheStats(t, "-file", "-cache", cache, a)
+
+    for _, tc := range []struct {
+        name    string
+        content string
+        change  func(content string)
+    }{
+        {"size", "hello, world\n", func(c string) { writeOld(t, a, c, oldTime) }},
+        {"mtime", "HELLO, WORLD\n", func(c string) { writeOld(t, a, c, oldTime.Add(time.Second)) }},
+        {"inode", "hello, there\n", func(c string) {
+            tmp := filepath.Join(dir, "a.new")
+            writeOld(t, tmp, c, oldTime.Add(time.Second))
+            if err := os.Rename(tmp, a); err != nil {
+                t.Fatal(err)
+            }
+        }},
+    } {
+        tc.change(tc.content)
+        stdout, stats := cacheStats(t, "-file", "-cache", cache, a)
+        if want := sumLine(tc.content, a); stdout != want || stats != "0 hits, 1 misses, 0 stale" {
+            t.Errorf("%s changed: stdout %q, cache %q; want %q and a miss", tc.name, stdout, stats, want)
+        }
+        if _, stats := cacheStats(t, "-file", "-cache", cache, a); stats != "1 hits, 0 misses, 0 stale" {
+            t.Errorf("%s changed, second run: cache %q, want a hit", tc.name, stats)
+        }
+    }
+}
+
+// TestCacheVerify edits a file without changing its size, modification
+// time or inode, which the cache cannot see, and checks that -cache-verify 1
+// catches it.
+func TestCacheVerify(t *testing.T) {
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    a := filepath.Join(dir, "a")
+    writeOld(t, a, "hello\n", oldTime)
+    cacheStats(t, "-file", "-cache", cache, a)
+
+    f, err := os.OpenFile(a, os.O_WRONLY, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := f.WriteString("jello\n"); err != nil {
+        t.Fatal(err)
+    }
+    f.Close()
+    if err := os.Chtimes(a, oldTime, oldTime); err != nil {
+        t.Fatal(err)
+    }
+
+    if stdout, stats := cacheStats(t, "-file", "-cache", cache, a); stdout != sumLine("hello\n", a) || stats != "1 hits, 0 misses, 0 stale" {
+        t.Errorf("without -cache-verify: stdout %q, cache %q; want the cached sum", stdout, stats)
+    }
+    if stdout, stats := cacheStats(t, "-file", "-cache", cache, "-cache-verify", "1", a); stdout != sumLine("jello\n", a) || stats != "0 hits, 0 misses, 1 stale" {
+        t.Errorf("-cache-verify 1: stdout %q, cache %q; want the new sum and a stale entry", stdout, stats)
+    }
+    // The stale entry was replaced.
+    if stdout, stats := cacheStats(t, "-file", "-cache", cache, a); stdout != sumLine("jello\n", a) || stats != "1 hits, 0 misses, 0 stale" {
+        t.Errorf("after -cache-verify: stdout %q, cache %q", stdout, stats)
+    }
+}
+
+func TestCacheUsage(t *testing.T) {
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    a := writeFile(t, dir, "a", "hello\n")
+    for _, args := range [][]string{
+        {"-cache", cache, a},
+        {"-cache", cache, "-dir", dir},
+        {"-cache", cache, "-file", "-hmac-key", "secret", a},
+        {"-cache", cache, "-file", "-salt", "00112233", a},
+        {"-cache", cache, "-file", "-cache-verify", "-0.1", a},
+        {"-cache", cache, "-file", "-cache-verify", "1.5", a},
+        {"-cache", cache, "-file", "-normalize", "eol", a},
+    } {
+        r := run(t, "", args...)
+        r.want(t, 2, "")
+        if !strings.HasPrefix(r.stderr, "randomtool: -") {
+            t.Errorf("%q: stderr %q", args, r.stderr)
+        }
+    }
+    if _, err := os.Stat(cache); !os.IsNotExist(err) {
+        t.Errorf("a usage error created the cache: %v", err)
+    }
+
+    // An unreadable cache file is an I/O error, not an empty cache.
+    run(t, "", "-file", "-cache", dir, a).want(t, 3, "")
+}
+
+// TestCacheMerge interleaves two cache users the way concurrent
+// invocations would: each loads the cache before the other saves, and
+// neither loses the other's entries.
+func TestCacheMerge(t *testing.T) {
+    dir := t.TempDir()
+    path := filepath.Join(dir, "cache.jsonl")
+    a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+    writeOld(t, a, "a", oldTime)
+    writeOld(t, b, "b", oldTime)
+
+    c1, err := openCache(path, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    c2, err := openCache(path, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    cfg := checksum.Config{}
+    if _, _, err := c1.fileChecksum(cfg, a, nil, checksum.ReadOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    if _, _, err := c2.fileChecksum(cfg, b, nil, checksum.ReadOptions{}); err != nil {
+        t.Fatal(err)
+    }
+    if err := c2.save(); err != nil {
+        t.Fatal(err)
+    }
+    if err := c1.save(); err != nil {
+        t.Fatal(err)
+    }
+
+    entries, err := readCache(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, p := range []string{a, b} {
+        if _, ok := entries[cacheKey{p, cfg.Name()}]; !ok {
+            t.Errorf("the merged cache lost %s: %+v", p, entries)
+        }
+    }
+}
+
+// TestCacheConcurrent runs several invocations against one cache at once.
+// Each save replaces the file by rename, so the cache is never seen half
+// written and no temporary files are left behind.
+func TestCacheConcurrent(t *testing.T) {
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    const n = 8
+    var files []string
+    for i := range n {
+        p := filepath.Join(dir, fmt.Sprintf("f%d", i))
+        writeOld(t, p, strings.Repeat("x", i), oldTime)
+        files = append(files, p)
+    }
+
+    var wg sync.WaitGroup
+    results := make([]result, n)
+    for i := range n {
+        wg.Go(func() { results[i] = run(t, "", "-file", "-cache", cache, files[i], files[(i+1)%n]) })
+    }
+    wg.Wait()
+    for i, r := range results {
+        r.want(t, 0, sumLine(strings.Repeat("x", i), files[i])+sumLine(strings.Repeat("x", (i+1)%n), files[(i+1)%n]))
+    }
+
+    f, err := os.Open(cache)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer f.Close()
+    sc := bufio.NewScanner(f)
+    lines := 0
+    for sc.Scan() {
+        var e cacheEntry
+        if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.Path == "" {
+            t.Errorf("cache line %q: %v", sc.Text(), err)
+        }
+        lines++
+    }
+    if lines == 0 || lines > n {
+        t.Errorf("cache has %d entries for %d files", lines, n)
+    }
+    if leftovers, _ := filepath.Glob(cache + ".*"); len(leftovers) > 0 {
+        t.Errorf("temporary files left behind: %q", leftovers)
+    }
+
+    // Whatever was lost to a race, one more run fills in.
+    cacheStats(t, append([]string{"-file", "-cache", cache}, files...)...)
+    if _, stats := cacheStats(t, append([]string{"-file", "-cache", cache}, files...)...); stats != fmt.Sprintf("%d hits, 0 misses, 0 stale", n) {
+        t.Errorf("after the concurrent runs: cache %q", stats)
+    }
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package main
+
+import (
+    "io/fs"
+    "syscall"
+)
+
+func inode(info fs.FileInfo) uint64 {
+    if st, ok := info.Sys().(*syscall.Stat_t); ok {
+        return uint64(st.Ino)
+    }
+    return 0
+}
+
+func device(info fs.FileInfo) uint64 {
+    if st, ok := info.Sys().(*syscall.Stat_t); ok {
+        return uint64(st.Dev)
+    }
+    return 0
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "path/filepath"
+    "regexp"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// defaultNamePattern finds the first run of 8 to 64 hex digits in a file
+// name, such as the 3f9a1c2b4d5e of bundle-3f9a1c2b4d5e.tar.gz.
+const defaultNamePattern = `(?:^|[^0-9A-Fa-f])([0-9A-Fa-f]{8,64})(?:[^0-9A-Fa-f]|$)`
+
+// nameChecker holds what checkNames needs to check one file.
+type nameChecker struct {
+    cfg     checksum.Config
+    pattern *regexp.Regexp
+    length  int // the required ID length in hex digits, or 0 for any
+    cache   *sumCache
+    opts    checksum.ReadOptions
+    stdin   io.Reader
+}
+
+// checkNames hashes each file named by paths, or each file below them when
+// dirs is set, and compares the checksum with the ID that pattern's first
+// group finds in the file's base name. An ID is compared as a prefix of
+// the full hex digest, so its length picks the truncation. Results go to
+// stdout as MATCH, MISMATCH or NO-ID and a summary goes to stderr. The
+// exit status is exitMismatch if any file mismatched, and otherwise exitIO
+// if a file could not be read or had no ID, or there were no files.
+func checkNames(nc nameChecker, paths []string, dirs bool, treeOpts checksum.TreeOptions, quiet bool, stdout, stderr io.Writer) int {
+    var match, mismatch, noID, unreadable int
+    check := func(path string) {
+        id := nameID(nc.pattern, filepath.Base(path))
+        if id == "" || !validChecksum(id, textEncoding{enc: checksum.Hex}) || nc.length > 0 && len(id) != nc.length {
+            fmt.Fprintf(stdout, "%s: NO-ID\n", path)
+            noID++
+            return
+        }
+        digest, _, err := nc.cache.fileChecksum(nc.cfg, path, nc.stdin, nc.opts)
+        switch {
+        case err != nil:
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            fmt.Fprintf(stdout, "%s: FAILED open or read\n", path)
+            unreadable++
+        case checksum.EqualPrefix(id, checksum.Hex.Encode(digest)):
+            if !quiet {
+                fmt.Fprintf(stdout, "%s: MATCH\n", path)
+            }
+            match++
+        default:
+            fmt.Fprintf(stdout, "%s: MISMATCH\n", path)
+            mismatch++
+        }
+    }
+
+    for _, path := range paths {
+        if !dirs {
+            check(path)
+            continue
+        }
+        treeOpts.NoDigest = true
+        entries, err := nc.cfg.Tree(path, treeOpts)
+        if err != nil {
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            unreadable++
+            continue
+        }
+        for _, e := range entries {
+            if e.Type == checksum.File {
+                check(filepath.Join(path, filepath.FromSlash(e.Path)))
+            }
+        }
+    }
+
+    fmt.Fprintf(stderr, "randomtool: %d MATCH, %d MISMATCH, %d NO-ID, %d unreadable\n", match, mismatch, noID, unreadable)
+    switch {
+    case mismatch > 0:
+        return exitMismatch
+    case unreadable > 0 || noID > 0 || match == 0:
+        return exitIO
+    }
+    return exitOK
+}
+
+// nameID returns the text of pattern's first group in name, or "" if the
+// pattern does not match.
+func nameID(pattern *regexp.Regexp, name string) string {
+    m := pattern.FindStringSubmatch(name)
+    if m == nil {
+        return ""
+    }
+    return m[1]
+}
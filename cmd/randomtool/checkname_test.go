@@ -0,0 +1,89 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestCheckName(t *testing.T) {
+    dir := t.TempDir()
+    id := rawSHA1("bundle contents")
+    good := writeFile(t, dir, "bundle-"+id[:12]+".tar.gz", "bundle contents")
+    full := writeFile(t, dir, "full_"+id, "bundle contents")
+    upper := writeFile(t, dir, "BUNDLE-"+strings.ToUpper(id[:10]), "bundle contents")
+    bad := writeFile(t, dir, "bundle-"+id[:12]+".zip", "tampered contents")
+    noID := writeFile(t, dir, "bundle.tar.gz", "bundle contents")
+    short := writeFile(t, dir, "bundle-"+id[:7], "bundle contents")
+    missing := filepath.Join(dir, "gone-"+id[:12])
+
+    for _, tc := range []struct {
+        name   string
+        args   []string
+        code   int
+        stdout string
+        stats  string
+    }{
+        {"match", []string{good, full, upper}, 0, good + ": MATCH\n" + full + ": MATCH\n" + upper + ": MATCH\n", "3 MATCH, 0 MISMATCH, 0 NO-ID, 0 unreadable"},
+        {"quiet match", []string{"-quiet", good}, 0, "", "1 MATCH, 0 MISMATCH, 0 NO-ID, 0 unreadable"},
+        {"mismatch", []string{good, bad}, 1, good + ": MATCH\n" + bad + ": MISMATCH\n", "1 MATCH, 1 MISMATCH, 0 NO-ID, 0 unreadable"},
+        {"quiet mismatch", []string{"-quiet", good, bad}, 1, bad + ": MISMATCH\n", "1 MATCH, 1 MISMATCH, 0 NO-ID, 0 unreadable"},
+        {"no ID", []string{good, noID, short}, 3, good + ": MATCH\n" + noID + ": NO-ID\n" + short + ": NO-ID\n", "1 MATCH, 0 MISMATCH, 2 NO-ID, 0 unreadable"},
+        {"mismatch outranks no ID", []string{noID, bad}, 1, noID + ": NO-ID\n" + bad + ": MISMATCH\n", "0 MATCH, 1 MISMATCH, 1 NO-ID, 0 unreadable"},
+        {"unreadable", []string{good, missing}, 3, good + ": MATCH\n" + missing + ": FAILED open or read\n", "1 MATCH, 0 MISMATCH, 0 NO-ID, 1 unreadable"},
+        {"length", []string{"-length", "12", good, full}, 3, good + ": MATCH\n" + full + ": NO-ID\n", "1 MATCH, 0 MISMATCH, 1 NO-ID, 0 unreadable"},
+        {"pattern", []string{"-name-pattern", `^full_([0-9a-f]+)$`, full, good}, 3, full + ": MATCH\n" + good + ": NO-ID\n", "1 MATCH, 0 MISMATCH, 1 NO-ID, 0 unreadable"},
+    } {
+        r := run(t, "", append([]string{"-check-name"}, tc.args...)...)
+        r.want(t, tc.code, tc.stdout)
+        if !strings.HasSuffix(r.stderr, "randomtool: "+tc.stats+"\n") {
+            t.Errorf("%s: stderr %q, want the summary %q", tc.name, r.stderr, tc.stats)
+        }
+    }
+
+    sum := sha256.Sum256([]byte("bundle contents"))
+    wide := writeFile(t, dir, "app-"+hex.EncodeToString(sum[:8])+".bin", "bundle contents")
+    run(t, "", "-check-name", "-algo", "sha256", wide).want(t, 0, wide+": MATCH\n")
+    run(t, "", "-check-name", wide).want(t, 1, wide+": MISMATCH\n")
+}
+
+func TestCheckNameDir(t *testing.T) {
+    dir := t.TempDir()
+    id := rawSHA1("a")[:16]
+    writeFile(t, dir, "x/a-"+id+".dat", "a")
+    writeFile(t, dir, "x/b-"+rawSHA1("b")[:16]+".dat", "not b")
+    writeFile(t, dir, "x/sub/README", "no id here")
+
+    root := filepath.Join(dir, "x")
+    r := run(t, "", "-check-name", "-dir", root)
+    r.want(t, 1, filepath.Join(root, "a-"+id+".dat")+": MATCH\n"+
+        filepath.Join(root, "b-"+rawSHA1("b")[:16]+".dat")+": MISMATCH\n"+
+        filepath.Join(root, "sub", "README")+": NO-ID\n")
+    if !strings.HasSuffix(r.stderr, "randomtool: 1 MATCH, 1 MISMATCH, 1 NO-ID, 0 unreadable\n") {
+        t.Errorf("-check-name -dir: stderr %q", r.stderr)
+    }
+
+    // A directory with nothing to check is not a success.
+    empty := filepath.Join(dir, "empty")
+    writeFile(t, empty, "README", "no id here")
+    run(t, "", "-check-name", "-dir", empty).want(t, 3, filepath.Join(empty, "README")+": NO-ID\n")
+    run(t, "", "-check-name", "-dir", filepath.Join(dir, "missing")).want(t, 3, "")
+}
+
+func TestCheckNameUsage(t *testing.T) {
+    a := writeFile(t, t.TempDir(), "a-0123456789ab", "a")
+    for _, args := range [][]string{
+        {"-check-name"},
+        {"-check-name", "-name-pattern", `[0-9a-f]+`, a},
+        {"-check-name", "-name-pattern", `([0-9a-f]+)-([0-9a-f]+)`, a},
+        {"-check-name", "-name-pattern", `([0-9a-f`, a},
+        {"-check-name", "-file", a},
+        {"-check-name", "-check", a},
+        {"-check-name", "-json", a},
+        {"-check-name", "-encoding", "base64", a},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+    "bufio"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strconv"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// byteSize is a flag holding a size such as 4096, 64KiB or 4MiB. The KB,
+// MB and GB suffixes are decimal.
+type byteSize int64
+
+var byteSuffixes = []struct {
+    suffix string
+    scale  int64
+}{
+    {"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+    {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+    {"B", 1},
+}
+
+func (s *byteSize) String() string {
+    for _, sfx := range byteSuffixes[:3] {
+        if n := int64(*s); n >= sfx.scale && n%sfx.scale == 0 && n/sfx.scale < 1024 {
+            return strconv.FormatInt(n/sfx.scale, 10) + sfx.suffix
+        }
+    }
+    return strconv.FormatInt(int64(*s), 10)
+}
+
+func (s *byteSize) Set(v string) error {
+    scale := int64(1)
+    for _, sfx := range byteSuffixes {
+        if strings.HasSuffix(v, sfx.suffix) {
+            v, scale = strings.TrimSuffix(v, sfx.suffix), sfx.scale
+            break
+        }
+    }
+    n, err := strconv.ParseInt(v, 10, 64)
+    if err != nil || n < 0 || n > (1<<62)/scale {
+        return fmt.Errorf("not a size in bytes, such as 4096 or 4MiB")
+    }
+    *s = byteSize(n * scale)
+    return nil
+}
+
+// runChunks prints "offset length checksum" for every chunk of the file,
+// then "total length checksum" for the whole of it, with full hex digests.
+func runChunks(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    size := byteSize(4 << 20)
+    minSize, maxSize := byteSize(0), byteSize(0)
+    fs.Var(&size, "size", "chunk `size`, such as 4MiB, or with -cdc the average chunk size above -min")
+    cdc := fs.Bool("cdc", false, "place chunk boundaries by content, with a rolling hash, so insertions only change nearby chunks")
+    fs.Var(&minSize, "min", "with -cdc, the smallest chunk `size` (default a quarter of -size)")
+    fs.Var(&maxSize, "max", "with -cdc, the largest chunk `size` (default four times -size)")
+    algo := fs.String("algo", "sha256", "hash algorithm: "+strings.Join(checksum.AlgorithmNames(), ", "))
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() != 1 {
+        return usagef("chunks takes exactly one path, - for standard input")
+    }
+    alg, err := checksum.ParseAlgorithm(*algo)
+    if err != nil {
+        return usageError{err}
+    }
+    opts := checksum.ChunkOptions{Size: int64(size), CDC: *cdc, Min: int64(minSize), Max: int64(maxSize)}
+    if *cdc {
+        if !flagSet(fs, "min") {
+            opts.Min = max(1, opts.Size/4)
+        }
+        if !flagSet(fs, "max") {
+            opts.Max = opts.Size * 4
+        }
+        if opts.Size < 1 || opts.Min > opts.Max || opts.Size > opts.Max {
+            return usagef("-cdc needs a positive -size and -min <= -size <= -max")
+        }
+    } else if flagSet(fs, "min") || flagSet(fs, "max") {
+        return usagef("-min and -max only apply to -cdc")
+    } else if opts.Size < 1 {
+        return usagef("-size must be positive")
+    }
+    if limit := byteSize(checksum.MaxChunkSize); opts.Size > int64(limit) || opts.Max > int64(limit) {
+        return usagef("-size and -max are at most %s; with -cdc, -max defaults to four times -size", &limit)
+    }
+
+    r := stdin
+    if path := fs.Arg(0); path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        r = f
+    }
+    bw := bufio.NewWriter(stdout)
+    var total int64
+    whole, err := checksum.Config{Algorithm: alg}.Chunks(r, opts, func(c checksum.Chunk) error {
+        total += c.Length
+        _, err := fmt.Fprintf(bw, "%d %d %x\n", c.Offset, c.Length, c.Digest)
+        return err
+    })
+    if err != nil {
+        bw.Flush()
+        return err
+    }
+    fmt.Fprintf(bw, "total %d %x\n", total, whole)
+    return bw.Flush()
+}
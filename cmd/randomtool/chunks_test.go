@@ -0,0 +1,48 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestChunks(t *testing.T) {
+    const want = `0 4 88d4266fd4e6338d13b845fcf289579d209c897823b9217da3e161936f031589
+4 4 e5e088a0b66163a0a26a5e053d2a4496dc16ab6e0e3dd1adf2d16aa84a078c9d
+8 2 c9df9c3f2963b19b9b95f58c4d33b053fa9f8586dd6ee04126e52a868f882108
+total 10 72399361da6a7754fec986dca5b7cbaf1c810a28ded4abaf56b2106d06cb78b0
+`
+    path := writeFile(t, t.TempDir(), "f", "abcdefghij")
+    run(t, "", "chunks", "-size", "4", path).want(t, 0, want)
+    run(t, "abcdefghij", "chunks", "-size", "4B", "-").want(t, 0, want)
+    run(t, "", "chunks", path).want(t, 0, "0 10 72399361da6a7754fec986dca5b7cbaf1c810a28ded4abaf56b2106d06cb78b0\n"+
+        "total 10 72399361da6a7754fec986dca5b7cbaf1c810a28ded4abaf56b2106d06cb78b0\n")
+
+    r := run(t, strings.Repeat("0123456789abcdef", 1<<14), "chunks", "-cdc", "-size", "4KiB", "-algo", "sha1", "-")
+    lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+    if r.code != 0 || len(lines) < 2 || !strings.HasPrefix(lines[len(lines)-1], "total 262144 ") {
+        t.Errorf("chunks -cdc: exit %d, %d lines, stderr %q", r.code, len(lines), r.stderr)
+    }
+}
+
+func TestChunksUsage(t *testing.T) {
+    path := writeFile(t, t.TempDir(), "f", "abc")
+    for _, args := range [][]string{
+        {"chunks"},
+        {"chunks", path, path},
+        {"chunks", "-size", "0", path},
+        {"chunks", "-size", "4XB", path},
+        {"chunks", "-min", "1KiB", path},
+        {"chunks", "-cdc", "-size", "4KiB", "-max", "1KiB", path},
+        {"chunks", "-algo", "md4", path},
+        {"chunks", "-size", "1000GiB", path},
+        {"chunks", "-size", "257MiB", path},
+        {"chunks", "-cdc", "-size", "128MiB", path},
+        {"chunks", "-cdc", "-size", "1MiB", "-max", "1GiB", path},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+    run(t, "", "chunks", path+".missing").want(t, 3, "")
+    // The largest sizes allocate nothing up front.
+    run(t, "", "chunks", "-size", "256MiB", path).want(t, 0, "*")
+    run(t, "", "chunks", "-cdc", "-size", "64MiB", path).want(t, 0, "*")
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "slices"
+    "strconv"
+    "strings"
+)
+
+// command is a randomtool subcommand. run defines its flags on fs, parses
+// the arguments after the command name and returns an error for main to
+// report and turn into the exit status. Flag parsing must come before any
+// other work, so that running a command with -h only defines its flags.
+type command struct {
+    name    string
+    args    string
+    summary string
+    run     func(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+func commands() []command {
+    return []command{
+        {"hash", "[flags] [arg ...]", "print the checksum of arguments, files, directories, archives or URLs", hashRunner(modeHash)},
+        {"verify", "[flags] checksum [arg ...]", "recompute a checksum and exit 1 unless it matches", hashRunner(modeVerify)},
+        {"manifest", "[flags] file ...", "print a sha1sum-style manifest, or check one with -check", hashRunner(modeManifest)},
+        {"chunks", "[flags] file", "print checksums of fixed-size or content-defined chunks of a file", runChunks},
+        {"dedup", "[flags] dir ...", "print groups of byte-identical files found below the directories", runDedup},
+        {"random", "[flags] [n]", "print n random bytes (default 16), or a passphrase of n words", runRandom},
+        {"mnemonic", "[flags] [word ...]", "print a BIP39 mnemonic, or convert one to its entropy or seed", runMnemonic},
+        {"token", "[-template tmpl] [flags]", "print random API keys or tokens in the shape of a template", runToken},
+        {"uuid", "[-v5 namespace] [name ...]", "print a random UUID, or the version 5 UUID of the names", runUUID},
+        {"keygen", "[-out path]", "write a new ed25519 private key and print its public key", runKeygen},
+        {"sign", "-key path [flags] arg ...", "print an ed25519 signature of the full checksum of the arguments", runSign},
+        {"verify-sig", "-pub path -sig signature [flags] arg ...", "check a signature made by sign and exit 1 unless it is valid", runVerifySig},
+        {"gen", "-seed part -size n (-out path | -count n -out-dir dir)", "write files of deterministic data derived from a seed", runGen},
+        {"derive", "-key-file path [flags]", "derive a purpose-specific key from a master secret with HKDF", runDerive},
+        {"completion", "bash|zsh|fish", "print a shell completion script", runCompletion},
+        {"help", "[command]", "describe randomtool or one of its commands", runHelp},
+    }
+}
+
+func lookupCommand(name string) (command, bool) {
+    for _, c := range commands() {
+        if c.name == name {
+            return c, true
+        }
+    }
+    return command{}, false
+}
+
+// dispatch runs the command named by the first argument. Anything else is
+// the original flag-only invocation, so "randomtool a b c" still prints the
+// checksum of a, b and c; "randomtool -- hash" hashes the word hash.
+func dispatch(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    if len(args) > 0 {
+        if c, ok := lookupCommand(args[0]); ok {
+            return c.run(newFlagSet("randomtool "+c.name, stderr), args[1:], stdin, stdout, stderr)
+        }
+    }
+    return runHash(modeLegacy, newFlagSet("randomtool", stderr), args, stdin, stdout, stderr)
+}
+
+func newFlagSet(name string, output io.Writer) *flag.FlagSet {
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    fs.SetOutput(output)
+    return fs
+}
+
+// commandFlags returns the flags c defines, by running it with -h.
+func commandFlags(c command) *flag.FlagSet {
+    fs := newFlagSet("randomtool "+c.name, io.Discard)
+    fs.Usage = func() {}
+    c.run(fs, []string{"-h"}, strings.NewReader(""), io.Discard, io.Discard)
+    return fs
+}
+
+// hashMode selects how runHash treats its flags and arguments.
+type hashMode int
+
+const (
+    modeLegacy hashMode = iota
+    modeHash
+    modeVerify
+    modeManifest
+)
+
+// verifyFlags and manifestFlags are the hash flags that verify and manifest
+// take. Everything else selects a mode in which there would be nothing to
+// verify, or output that is not a manifest.
+var (
+    verifyFlags = []string{
+        "algo", "length", "encoding", "hrp", "format", "multibase", "allow-prefix", "quiet",
+        "hmac-key", "hmac-key-file", "hmac-key-env", "legacy", "normalize", "json-canonical",
+        "input-encoding", "input-list", "0", "max-bytes",
+        "file", "dir", "archive", "url", "reader", "buffer", "cache", "cache-verify", "progress",
+        "exclude", "git", "include-untracked", "archive-modes", "timeout", "max-size", "max-redirects",
+        "v", "log", "log-max-size",
+    }
+    manifestFlags = []string{
+        "algo", "length", "format", "multibase", "allow-prefix", "quiet",
+        "hmac-key", "hmac-key-file", "hmac-key-env", "legacy", "normalize", "json-canonical",
+        "check", "max-line", "jobs", "reader", "buffer", "cache", "cache-verify", "progress",
+        "v", "log", "log-max-size",
+    }
+    // randomFlags moved to the random and uuid commands.
+    randomFlags = []string{"random", "count", "passphrase", "separator", "entropy", "wordlist", "uuid", "uuid5"}
+)
+
+// takes reports whether the command running in mode m has the hash flag
+// name. The legacy invocation has them all.
+func (m hashMode) takes(name string) bool {
+    switch m {
+    case modeVerify:
+        return slices.Contains(verifyFlags, name)
+    case modeManifest:
+        return slices.Contains(manifestFlags, name)
+    case modeHash:
+        return !slices.Contains(randomFlags, name)
+    }
+    return true
+}
+
+func hashRunner(mode hashMode) func(*flag.FlagSet, []string, io.Reader, io.Writer, io.Writer) error {
+    return func(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+        return runHash(mode, fs, args, stdin, stdout, stderr)
+    }
+}
+
+func runRandom(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    encoding := fs.String("encoding", "hex", "output encoding: "+strings.Join(encodingNames(), ", "))
+    hrp := fs.String("hrp", "", "with -encoding bech32, the human-readable `prefix`, such as bc")
+    count := fs.Int("count", 1, "print `m` independent values, one per line")
+    passphrase := fs.Bool("passphrase", false, "print a passphrase of n words (default 6) instead of bytes")
+    separator := fs.String("separator", " ", "with -passphrase, the `string` placed between words")
+    entropy := fs.Bool("entropy", false, "with -passphrase, print the passphrase's bits of entropy on stderr")
+    wordlist := fs.String("wordlist", "", "with -passphrase, choose words from the newline-separated list at `path` instead of the EFF large list")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    n := 16
+    if *passphrase {
+        n = 6
+    }
+    switch fs.NArg() {
+    case 0:
+    case 1:
+        var err error
+        if n, err = strconv.Atoi(fs.Arg(0)); err != nil {
+            return usagef("random: %q is not a number", fs.Arg(0))
+        }
+    default:
+        return usagef("random takes at most one argument")
+    }
+    if *passphrase {
+        return printPassphrase(n, *wordlist, *separator, *entropy, stdout, stderr)
+    }
+    enc, err := parseTextEncoding(*encoding, *hrp)
+    if err != nil {
+        return usageError{err}
+    }
+    return printRandom(n, *count, enc, stdout)
+}
+
+func runUUID(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    v5 := fs.String("v5", "", "print the version 5 UUID of the names in `namespace` (dns, url, oid, x500 or a UUID)")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if *v5 == "" {
+        if fs.NArg() > 0 {
+            return usagef("uuid takes names only with -v5")
+        }
+        u, err := newUUIDv4()
+        if err != nil {
+            return err
+        }
+        fmt.Fprintln(stdout, u)
+        return nil
+    }
+    ns, err := parseUUID(*v5)
+    if err != nil {
+        return usagef("-v5: %v", err)
+    }
+    fmt.Fprintln(stdout, newUUIDv5(ns, fs.Args()))
+    return nil
+}
+
+// runHelp lists the commands, or prints the flags of one of them.
+func runHelp(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    args = fs.Args()
+    if len(args) > 1 {
+        return usagef("help takes at most one command")
+    }
+    if len(args) == 1 {
+        c, ok := lookupCommand(args[0])
+        if !ok || c.name == "help" {
+            return usagef("help: unknown command %q", args[0])
+        }
+        fmt.Fprintf(stdout, "randomtool %s %s\n    %s\n\n", c.name, c.args, c.summary)
+        err := c.run(newFlagSet("randomtool "+c.name, stdout), []string{"-h"}, stdin, stdout, stdout)
+        if errors.Is(err, flag.ErrHelp) {
+            return nil
+        }
+        return err
+    }
+    var b strings.Builder
+    b.WriteString("usage: randomtool <command> [flags] [arg ...]\n\ncommands:\n")
+    width := 0
+    for _, c := range commands() {
+        width = max(width, len(c.name))
+    }
+    for _, c := range commands() {
+        fmt.Fprintf(&b, "  %-*s  %s\n", width, c.name, c.summary)
+    }
+    b.WriteString("\nWithout a command, randomtool accepts the hash flags and arguments directly,\n")
+    b.WriteString("as earlier releases did. Run \"randomtool help <command>\" for its flags.\n")
+    b.WriteString("\nexit status: 0 success, 1 checksum or signature mismatch, 2 usage error,\n")
+    b.WriteString("3 an input could not be read or written, 4 internal error,\n")
+    b.WriteString("5 an input exceeded -max-bytes or -max-line.\n")
+    io.WriteString(stdout, b.String())
+    return nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "maps"
+    "slices"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// flagChoices lists the values of flags that accept one of a fixed set,
+// for completion. Flags whose value placeholder is "path" complete file
+// names; all other flags are read from the command registry as they are.
+var flagChoices = map[string]func() []string{
+    "algo":           checksum.AlgorithmNames,
+    "encoding":       encodingNames,
+    "multibase":      checksum.MultibaseNames,
+    "normalize":      checksum.NormalizationNames,
+    "input-encoding": inputEncodingNames,
+    "format":         formatNames,
+    "uuid5":          uuidNamespaceNames,
+    "v5":             uuidNamespaceNames,
+}
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+func uuidNamespaceNames() []string {
+    return slices.Sorted(maps.Keys(uuidNamespaces))
+}
+
+// completionFlag describes one flag of a command for the script generators.
+type completionFlag struct {
+    name    string
+    usage   string
+    isBool  bool
+    path    bool
+    choices []string
+}
+
+// completionCommand describes a command's flags and what its operands are:
+// file names when files is set, otherwise one of choices, if any.
+type completionCommand struct {
+    command
+    flags   []completionFlag
+    files   bool
+    choices []string
+}
+
+func completionCommands() []completionCommand {
+    var cmds []completionCommand
+    for _, c := range commands() {
+        cc := completionCommand{command: c}
+        commandFlags(c).VisitAll(func(f *flag.Flag) {
+            placeholder, usage := flag.UnquoteUsage(f)
+            cf := completionFlag{name: f.Name, usage: usage, path: placeholder == "path"}
+            if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+                cf.isBool = true
+            }
+            if choices, ok := flagChoices[f.Name]; ok {
+                cf.choices = choices()
+            }
+            cc.flags = append(cc.flags, cf)
+        })
+        switch c.name {
+        case "hash", "verify", "manifest", "chunks", "dedup", "sign", "verify-sig":
+            cc.files = true
+        case "completion":
+            cc.choices = completionShells
+        case "help":
+            for _, c := range commands() {
+                cc.choices = append(cc.choices, c.name)
+            }
+        }
+        cmds = append(cmds, cc)
+    }
+    return cmds
+}
+
+func runCompletion(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() != 1 {
+        return usagef("completion takes one of %s", strings.Join(completionShells, ", "))
+    }
+    cmds := completionCommands()
+    switch fs.Arg(0) {
+    case "bash":
+        io.WriteString(stdout, bashCompletion(cmds))
+    case "zsh":
+        io.WriteString(stdout, zshCompletion(cmds))
+    case "fish":
+        io.WriteString(stdout, fishCompletion(cmds))
+    default:
+        return usagef("completion: unknown shell %q (supported: %s)", fs.Arg(0), strings.Join(completionShells, ", "))
+    }
+    return nil
+}
+
+// bashCompletion and the other generators complete the hash flags whenever
+// the first word is not a command name, as randomtool then takes those.
+func bashCompletion(cmds []completionCommand) string {
+    var b strings.Builder
+    b.WriteString("# bash completion for randomtool, generated by \"randomtool completion bash\".\n")
+    b.WriteString("_randomtool() {\n")
+    b.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+    b.WriteString("    local cmd= flags= files= choices=\n")
+    b.WriteString("    COMPREPLY=()\n")
+    b.WriteString("    if [[ $COMP_CWORD -gt 1 ]]; then\n")
+    b.WriteString("        case ${COMP_WORDS[1]} in\n")
+    fmt.Fprintf(&b, "        %s) cmd=${COMP_WORDS[1]} ;;\n", strings.Join(commandNames(cmds), "|"))
+    b.WriteString("        esac\n")
+    b.WriteString("    elif [[ $cur != -* ]]; then\n")
+    fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(commandNames(cmds), " "))
+    b.WriteString("        return\n")
+    b.WriteString("    fi\n")
+    b.WriteString("    case $cmd in\n")
+    for _, c := range cmds {
+        pattern := c.name
+        if c.name == "hash" {
+            pattern = `hash|""`
+        }
+        fmt.Fprintf(&b, "    %s)\n", pattern)
+        b.WriteString("        case $prev in\n")
+        var names, paths, values []string
+        for _, f := range c.flags {
+            names = append(names, "-"+f.name)
+            switch {
+            case f.isBool:
+            case f.choices != nil:
+                fmt.Fprintf(&b, "        -%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.choices, " "))
+            case f.path:
+                paths = append(paths, "-"+f.name)
+            default:
+                values = append(values, "-"+f.name)
+            }
+        }
+        if paths != nil {
+            fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(paths, "|"))
+        }
+        if values != nil {
+            fmt.Fprintf(&b, "        %s) return ;;\n", strings.Join(values, "|"))
+        }
+        b.WriteString("        esac\n")
+        fmt.Fprintf(&b, "        flags=%q\n", strings.Join(names, " "))
+        switch {
+        case c.files:
+            b.WriteString("        files=1\n")
+        case c.choices != nil:
+            fmt.Fprintf(&b, "        choices=%q\n", strings.Join(c.choices, " "))
+        }
+        b.WriteString("        ;;\n")
+    }
+    b.WriteString("    esac\n")
+    b.WriteString("    if [[ $cur == -* ]]; then\n")
+    b.WriteString("        COMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n")
+    b.WriteString("    elif [[ -n $files ]]; then\n")
+    b.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+    b.WriteString("    else\n")
+    b.WriteString("        COMPREPLY=($(compgen -W \"$choices\" -- \"$cur\"))\n")
+    b.WriteString("    fi\n")
+    b.WriteString("}\n")
+    b.WriteString("complete -o filenames -F _randomtool randomtool\n")
+    return b.String()
+}
+
+func zshCompletion(cmds []completionCommand) string {
+    quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'" }
+    describe := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`)
+
+    var b strings.Builder
+    b.WriteString("#compdef randomtool\n")
+    b.WriteString("# zsh completion for randomtool, generated by \"randomtool completion zsh\".\n")
+    for _, c := range cmds {
+        fmt.Fprintf(&b, "\n_randomtool_%s() {\n", c.name)
+        b.WriteString("    _arguments")
+        for _, f := range c.flags {
+            spec := "-" + f.name + "[" + describe.Replace(f.usage) + "]"
+            switch {
+            case f.isBool:
+            case f.choices != nil:
+                spec += ":" + f.name + ":(" + strings.Join(f.choices, " ") + ")"
+            case f.path:
+                spec += ":path:_files"
+            default:
+                spec += ":" + f.name + ": "
+            }
+            fmt.Fprintf(&b, " \\\n        %s", quote(spec))
+        }
+        switch {
+        case c.files:
+            fmt.Fprintf(&b, " \\\n        %s", quote("*:file:_files"))
+        case c.choices != nil:
+            fmt.Fprintf(&b, " \\\n        %s", quote("1:"+c.name+":("+strings.Join(c.choices, " ")+")"))
+        }
+        b.WriteString("\n}\n")
+    }
+    b.WriteString("\n_randomtool() {\n")
+    b.WriteString("    local -a commands\n")
+    b.WriteString("    commands=(\n")
+    for _, c := range cmds {
+        fmt.Fprintf(&b, "        %s\n", quote(c.name+":"+strings.ReplaceAll(c.summary, ":", `\:`)))
+    }
+    b.WriteString("    )\n")
+    b.WriteString("    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n")
+    b.WriteString("        _describe -t commands command commands\n")
+    b.WriteString("        return\n")
+    b.WriteString("    fi\n")
+    b.WriteString("    case $words[2] in\n")
+    fmt.Fprintf(&b, "    %s)\n", strings.Join(commandNames(cmds), "|"))
+    b.WriteString("        local cmd=$words[2]\n")
+    b.WriteString("        shift words\n")
+    b.WriteString("        (( CURRENT-- ))\n")
+    b.WriteString("        _randomtool_$cmd\n")
+    b.WriteString("        ;;\n")
+    b.WriteString("    *)\n")
+    b.WriteString("        _randomtool_hash\n")
+    b.WriteString("        ;;\n")
+    b.WriteString("    esac\n")
+    b.WriteString("}\n")
+    b.WriteString("\n_randomtool \"$@\"\n")
+    return b.String()
+}
+
+func fishCompletion(cmds []completionCommand) string {
+    quote := func(s string) string {
+        return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+    }
+    flagLines := func(b *strings.Builder, cond string, flags []completionFlag) {
+        for _, f := range flags {
+            fmt.Fprintf(b, "complete -c randomtool -n %s -o %s -d %s", quote(cond), f.name, quote(f.usage))
+            switch {
+            case f.isBool:
+            case f.choices != nil:
+                fmt.Fprintf(b, " -x -a %s", quote(strings.Join(f.choices, " ")))
+            case f.path:
+                b.WriteString(" -r -F")
+            default:
+                b.WriteString(" -x")
+            }
+            b.WriteByte('\n')
+        }
+    }
+
+    var b strings.Builder
+    b.WriteString("# fish completion for randomtool, generated by \"randomtool completion fish\".\n")
+    for _, c := range cmds {
+        fmt.Fprintf(&b, "complete -c randomtool -n __fish_use_subcommand -f -a %s -d %s\n", c.name, quote(c.summary))
+    }
+    for _, c := range cmds {
+        cond := "__fish_seen_subcommand_from " + c.name
+        if c.name == "hash" {
+            flagLines(&b, "__fish_use_subcommand", c.flags)
+        }
+        flagLines(&b, cond, c.flags)
+        switch {
+        case c.choices != nil:
+            fmt.Fprintf(&b, "complete -c randomtool -n %s -f -a %s\n", quote(cond), quote(strings.Join(c.choices, " ")))
+        case !c.files:
+            fmt.Fprintf(&b, "complete -c randomtool -n %s -f\n", quote(cond))
+        }
+    }
+    return b.String()
+}
+
+func commandNames(cmds []completionCommand) []string {
+    names := make([]string, len(cmds))
+    for i, c := range cmds {
+        names[i] = c.name
+    }
+    return names
+}
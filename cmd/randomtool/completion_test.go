@@ -0,0 +1,53 @@
+package main
+
+import (
+    "os/exec"
+    "strings"
+    "testing"
+)
+
+// TestCompletionChoices checks that completion offers exactly the values
+// runHash accepts.
+func TestCompletionChoices(t *testing.T) {
+    r := run(t, "", "completion", "bash")
+    r.want(t, 0, "*")
+    for flag, values := range map[string][]string{"input-encoding": inputEncodingNames(), "format": formatNames()} {
+        if want := "-" + flag + `) COMPREPLY=($(compgen -W "` + strings.Join(values, " ") + `"`; !strings.Contains(r.stdout, want) {
+            t.Errorf("bash completion lacks %s", want)
+        }
+        for _, v := range values {
+            run(t, "", "hash", "-"+flag, v, "AAAA").want(t, 0, "*")
+        }
+        run(t, "", "hash", "-"+flag, "nosuch", "x").want(t, 2, "")
+    }
+    if _, err := exec.LookPath("bash"); err == nil {
+        if out, err := exec.Command("bash", "-n", "-c", r.stdout).CombinedOutput(); err != nil {
+            t.Errorf("bash rejects the completion script: %v\n%s", err, out)
+        }
+    }
+    for _, shell := range completionShells {
+        run(t, "", "completion", shell).want(t, 0, "*")
+    }
+    run(t, "", "completion", "tcsh").want(t, 2, "")
+}
+
+func TestHelpAlignsCommands(t *testing.T) {
+    r := run(t, "", "help")
+    column := -1
+    for _, c := range commands() {
+        line := ""
+        for l := range strings.Lines(r.stdout) {
+            if strings.HasPrefix(l, "  "+c.name+" ") {
+                line = l
+            }
+        }
+        i := strings.Index(line, c.summary)
+        if i < 0 {
+            t.Fatalf("help has no line for %s", c.name)
+        }
+        if column >= 0 && i != column {
+            t.Errorf("%s summary starts at column %d, want %d", c.name, i, column)
+        }
+        column = i
+    }
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+    "cmp"
+    "encoding/hex"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "runtime"
+    "slices"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// dedupHead is how much of every same-sized file dedup hashes before it
+// decides which ones need a full hash.
+const dedupHead = 64 << 10
+
+// dedupFile is one file found by dedup. Hard links to it are not files of
+// their own but further names in links.
+type dedupFile struct {
+    path   string
+    size   int64
+    links  []string
+    digest []byte
+}
+
+type fileKey struct{ dev, ino uint64 }
+
+type dedupGroup struct {
+    Checksum string          `json:"checksum"`
+    Bytes    int64           `json:"bytes"`
+    Files    []dedupJSONFile `json:"files"`
+}
+
+type dedupJSONFile struct {
+    Path      string   `json:"path"`
+    HardLinks []string `json:"hard_links,omitempty"`
+}
+
+// runDedup prints groups of byte-identical files below its directory
+// arguments. Files are grouped by size, then by a hash of their first
+// dedupHead bytes, and only the candidates left after that are hashed in
+// full, so most files are never read to the end.
+func runDedup(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    algo := fs.String("algo", "sha256", "hash algorithm: "+strings.Join(cryptographicNames(), ", "))
+    minSize := byteSize(1)
+    fs.Var(&minSize, "min-size", "ignore files smaller than `size`")
+    jobs := fs.Int("jobs", runtime.NumCPU(), "hash up to `n` files concurrently")
+    jsonOut := fs.Bool("json", false, "print the groups as a JSON document")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() == 0 {
+        return usagef("dedup needs at least one directory")
+    }
+    if *jobs < 1 {
+        return usagef("-jobs must be at least 1")
+    }
+    alg, err := checksum.ParseAlgorithm(*algo)
+    if err != nil {
+        return usageError{err}
+    }
+    if !alg.Cryptographic() {
+        return usagef("%s is not a cryptographic hash (use one of %s)", alg, strings.Join(cryptographicNames(), ", "))
+    }
+    cfg := checksum.Config{Algorithm: alg}
+
+    code := exitOK
+    fail := func(err error) {
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        code = exitIO
+    }
+    groups := groupBy(scanDedup(fs.Args(), int64(minSize), fail), func(f *dedupFile) string {
+        return fmt.Sprint(f.size)
+    })
+    groups = refineDedup(groups, *jobs, fail, func(path string) ([]byte, error) {
+        f, err := os.Open(path)
+        if err != nil {
+            return nil, err
+        }
+        defer f.Close()
+        digest, err := cfg.SumReader(io.LimitReader(f, dedupHead))
+        if err != nil {
+            return nil, &checksum.InputError{Path: path, Err: err}
+        }
+        return digest, nil
+    })
+    // A head hash covers smaller files whole.
+    small := slices.DeleteFunc(slices.Clone(groups), func(g []*dedupFile) bool { return g[0].size > dedupHead })
+    large := slices.DeleteFunc(groups, func(g []*dedupFile) bool { return g[0].size <= dedupHead })
+    groups = append(small, refineDedup(large, *jobs, fail, cfg.SumFile)...)
+
+    slices.SortFunc(groups, func(a, b []*dedupFile) int {
+        return cmp.Or(cmp.Compare(b[0].size, a[0].size), strings.Compare(a[0].path, b[0].path))
+    })
+    if *jsonOut {
+        doc := struct {
+            Algorithm string       `json:"algorithm"`
+            Groups    []dedupGroup `json:"groups"`
+        }{Algorithm: cfg.Name(), Groups: []dedupGroup{}}
+        for _, g := range groups {
+            group := dedupGroup{Checksum: hex.EncodeToString(g[0].digest), Bytes: g[0].size}
+            for _, f := range g {
+                group.Files = append(group.Files, dedupJSONFile{Path: f.path, HardLinks: f.links})
+            }
+            doc.Groups = append(doc.Groups, group)
+        }
+        enc := json.NewEncoder(stdout)
+        enc.SetIndent("", "  ")
+        if err := enc.Encode(doc); err != nil {
+            return err
+        }
+        return status(code)
+    }
+    for i, g := range groups {
+        if i > 0 {
+            fmt.Fprintln(stdout)
+        }
+        copies := fmt.Sprintf("%d copies", len(g))
+        if len(g) == 1 {
+            copies = "1 copy"
+        }
+        fmt.Fprintf(stdout, "%x  %d bytes, %s\n", g[0].digest, g[0].size, copies)
+        for _, f := range g {
+            fmt.Fprintf(stdout, "    %s\n", f.path)
+            for _, link := range f.links {
+                fmt.Fprintf(stdout, "    %s (hard link to %s)\n", link, f.path)
+            }
+        }
+    }
+    return status(code)
+}
+
+// scanDedup lists the regular files of at least minSize bytes below roots,
+// folding hard links into the first name found for each file.
+func scanDedup(roots []string, minSize int64, fail func(error)) []*dedupFile {
+    var files []*dedupFile
+    seen := make(map[fileKey]*dedupFile)
+    for _, root := range dedupRoots(roots) {
+        filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+            if err != nil {
+                fail(&checksum.InputError{Path: p, Err: err})
+                return nil
+            }
+            if !d.Type().IsRegular() {
+                return nil
+            }
+            info, err := d.Info()
+            if err != nil {
+                fail(&checksum.InputError{Path: p, Err: err})
+                return nil
+            }
+            if info.Size() < minSize {
+                return nil
+            }
+            key := fileKey{device(info), inode(info)}
+            if f := seen[key]; f != nil && key.ino != 0 {
+                f.links = append(f.links, p)
+                return nil
+            }
+            f := &dedupFile{path: p, size: info.Size()}
+            seen[key] = f
+            files = append(files, f)
+            return nil
+        })
+    }
+    return files
+}
+
+// dedupRoots cleans roots and drops repeated roots and those inside
+// another, which would otherwise be walked twice and report each file as a
+// hard link to itself.
+func dedupRoots(roots []string) []string {
+    var kept []string
+    for i, root := range roots {
+        root = filepath.Clean(root)
+        inside := false
+        for j, other := range roots {
+            other = filepath.Clean(other)
+            if j != i && (root == other && j < i || within(root, other)) {
+                inside = true
+                break
+            }
+        }
+        if !inside {
+            kept = append(kept, root)
+        }
+    }
+    return kept
+}
+
+// within reports whether path lies strictly below dir.
+func within(path, dir string) bool {
+    rel, err := filepath.Rel(dir, path)
+    return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// refineDedup hashes the files of every group with sum and splits the
+// groups by digest, dropping files that fail and groups of a single name.
+func refineDedup(groups [][]*dedupFile, jobs int, fail func(error), sum func(path string) ([]byte, error)) [][]*dedupFile {
+    var files []*dedupFile
+    for _, g := range groups {
+        files = append(files, g...)
+    }
+    paths := make([]string, len(files))
+    for i, f := range files {
+        paths[i] = f.path
+    }
+    var hashed []*dedupFile
+    i := 0
+    hashPaths(paths, jobs, func(path string) ([]byte, input, error) {
+        digest, err := sum(path)
+        return digest, input{}, err
+    }, func(path string, digest []byte, in input, err error) {
+        f := files[i]
+        i++
+        if err != nil {
+            fail(err)
+            return
+        }
+        f.digest = digest
+        hashed = append(hashed, f)
+    })
+    return groupBy(hashed, func(f *dedupFile) string {
+        return fmt.Sprint(f.size, string(f.digest))
+    })
+}
+
+// groupBy groups files by key, keeping their order, and drops groups of a
+// single name. A lone file with hard links is kept, so that its links are
+// reported.
+func groupBy(files []*dedupFile, key func(*dedupFile) string) [][]*dedupFile {
+    index := make(map[string]int)
+    var groups [][]*dedupFile
+    for _, f := range files {
+        k := key(f)
+        i, ok := index[k]
+        if !ok {
+            i = len(groups)
+            index[k] = i
+            groups = append(groups, nil)
+        }
+        groups[i] = append(groups[i], f)
+    }
+    return slices.DeleteFunc(groups, func(g []*dedupFile) bool { return len(g) == 1 && len(g[0].links) == 0 })
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "slices"
+    "strings"
+    "testing"
+)
+
+func TestDedup(t *testing.T) {
+    dir := t.TempDir()
+    head := strings.Repeat("h", dedupHead)
+    writeFile(t, dir, "a/dup1", "dup")
+    writeFile(t, dir, "b/dup2", "dup")
+    writeFile(t, dir, "a/unique", "unique")
+    // Same size and same first dedupHead bytes, so only a full hash tells
+    // them apart.
+    writeFile(t, dir, "a/large1", head+"x")
+    writeFile(t, dir, "b/large2", head+"x")
+    writeFile(t, dir, "b/large3", head+"y")
+    linked := writeFile(t, dir, "a/linked", "linked")
+    if err := os.Link(linked, filepath.Join(dir, "b", "link")); err != nil {
+        t.Skipf("hard links unsupported: %v", err)
+    }
+
+    r := runIn(t, dir, "", "dedup", "-json", ".")
+    r.want(t, 0, "*")
+    var doc struct {
+        Groups []dedupGroup `json:"groups"`
+    }
+    if err := json.Unmarshal([]byte(r.stdout), &doc); err != nil {
+        t.Fatal(err)
+    }
+    var got []string
+    for _, g := range doc.Groups {
+        var names []string
+        for _, f := range g.Files {
+            names = append(names, f.Path)
+            for _, l := range f.HardLinks {
+                names = append(names, l+"=>"+f.Path)
+            }
+        }
+        got = append(got, strings.Join(names, " "))
+    }
+    want := []string{
+        "a/large1 b/large2",
+        "a/linked b/link=>a/linked",
+        "a/dup1 b/dup2",
+    }
+    if !slices.Equal(got, want) {
+        t.Errorf("dedup -json groups %q, want %q", got, want)
+    }
+
+    // Repeated and nested roots are walked once.
+    text := runIn(t, dir, "", "dedup", ".").stdout
+    runIn(t, dir, "", "dedup", ".", "./", "a", "b").want(t, 0, text)
+    if !strings.Contains(text, "6 bytes, 1 copy\n    a/linked\n    b/link (hard link to a/linked)\n") {
+        t.Errorf("dedup does not report the hard link:\n%s", text)
+    }
+
+    runIn(t, dir, "", "dedup", "-min-size", "4", "-json", ".").want(t, 0, "*")
+    if r := runIn(t, dir, "", "dedup", "-min-size", "7", "."); strings.Contains(r.stdout, "dup1") || strings.Contains(r.stdout, "linked") {
+        t.Errorf("dedup -min-size 7 reported small files:\n%s", r.stdout)
+    }
+    run(t, "", "dedup").want(t, 2, "")
+    run(t, "", "dedup", "-algo", "crc32c", dir).want(t, 2, "")
+}
+
+func TestDedupRoots(t *testing.T) {
+    for _, tt := range []struct{ roots, want []string }{
+        {[]string{"a", "a"}, []string{"a"}},
+        {[]string{"a", "./a/", "b"}, []string{"a", "b"}},
+        {[]string{"a/b", "a"}, []string{"a"}},
+        {[]string{"a", "ab"}, []string{"a", "ab"}},
+        {[]string{".", "a"}, []string{"."}},
+    } {
+        if got := dedupRoots(tt.roots); !slices.Equal(got, tt.want) {
+            t.Errorf("dedupRoots(%q) = %q, want %q", tt.roots, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+    "encoding/hex"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// runDerive prints an HKDF key. A random -salt auto is printed in front of
+// the key as "salt:key", as hash does, since the key cannot be derived
+// again without it.
+func runDerive(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    keyFile := fs.String("key-file", "", "derive from the raw contents of the master secret at `path`, - for standard input")
+    info := fs.String("info", "", "the context `string` that makes the key specific to one purpose")
+    length := fs.Int("length", 32, "key length in `bytes`")
+    algo := fs.String("algo", "sha256", "hash algorithm: "+strings.Join(cryptographicNames(), ", "))
+    encoding := fs.String("encoding", "hex", "output encoding: "+strings.Join(encodingNames(), ", "))
+    hrp := fs.String("hrp", "", "with -encoding bech32, the human-readable `prefix`, such as bc")
+    saltFlag := fs.String("salt", "", "HKDF salt; `value` is hex or \"auto\" for a random salt")
+    saltBytes := fs.Int("salt-bytes", 32, "with -salt auto, the salt length in bytes")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() > 0 {
+        return usagef("derive takes no arguments")
+    }
+    if *keyFile == "" {
+        return usagef("derive needs -key-file")
+    }
+    alg, err := checksum.ParseAlgorithm(*algo)
+    if err != nil {
+        return usageError{err}
+    }
+    if !alg.Cryptographic() {
+        return usagef("%s is not a cryptographic hash (use one of %s)", alg, strings.Join(cryptographicNames(), ", "))
+    }
+    if max := checksum.MaxDerivedKey(alg); *length < 1 || *length > max {
+        return usagef("-length %d out of range for HKDF-%s (1-%d)", *length, alg, max)
+    }
+    enc, err := parseTextEncoding(*encoding, *hrp)
+    if err != nil {
+        return usageError{err}
+    }
+    if err := enc.fits(*length); err != nil {
+        return usagef("-encoding bech32: %v; give a smaller -length", err)
+    }
+    var noVerify string
+    salt, err := resolveSalt(*saltFlag, *saltBytes, &noVerify)
+    if err != nil {
+        return usageError{err}
+    }
+    if salt != nil && enc.is(checksum.Raw) {
+        return usagef("-salt cannot be combined with -encoding raw")
+    }
+
+    var secret []byte
+    if *keyFile == "-" {
+        secret, err = io.ReadAll(stdin)
+    } else {
+        secret, err = os.ReadFile(*keyFile)
+    }
+    if err != nil {
+        return err
+    }
+    if len(secret) == 0 {
+        return &checksum.InputError{Path: *keyFile, Err: errors.New("empty master secret")}
+    }
+    key, err := checksum.DeriveKey(alg, secret, salt, *info, *length)
+    if err != nil {
+        return err
+    }
+    if enc.is(checksum.Raw) {
+        stdout.Write(key)
+        return nil
+    }
+    out := enc.Encode(key)
+    if *saltFlag == "auto" {
+        out = hex.EncodeToString(salt) + ":" + out
+    }
+    fmt.Fprintln(stdout, out)
+    return nil
+}
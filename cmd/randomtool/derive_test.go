@@ -0,0 +1,44 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestDerive(t *testing.T) {
+    dir := t.TempDir()
+    rfc := writeFile(t, dir, "ikm", strings.Repeat("\x0b", 22))
+    // RFC 5869 test case 1.
+    run(t, "", "derive", "-key-file", rfc, "-salt", "000102030405060708090a0b0c",
+        "-info", "\xf0\xf1\xf2\xf3\xf4\xf5\xf6\xf7\xf8\xf9", "-length", "42").
+        want(t, 0, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865\n")
+
+    want := "76ab8ed06fd81c5f238c94e7c1d2226ddeb8e4d058aa3dc0e9cc42cea25af4c3\n"
+    secret := writeFile(t, dir, "secret", "master secret")
+    run(t, "", "derive", "-key-file", secret, "-info", "app v1").want(t, 0, want)
+    run(t, "master secret", "derive", "-key-file", "-", "-info", "app v1").want(t, 0, want)
+    if r := run(t, "", "derive", "-key-file", secret, "-info", "app v2"); r.code != 0 || r.stdout == want {
+        t.Errorf("a different -info gave exit %d, %q", r.code, r.stdout)
+    }
+
+    r := run(t, "", "derive", "-key-file", secret, "-salt", "auto", "-salt-bytes", "16")
+    salt, key, ok := strings.Cut(strings.TrimSpace(r.stdout), ":")
+    if r.code != 0 || !ok || len(salt) != 32 || len(key) != 64 {
+        t.Fatalf("derive -salt auto: exit %d, %q", r.code, r.stdout)
+    }
+    run(t, "", "derive", "-key-file", secret, "-salt", salt).want(t, 0, key+"\n")
+}
+
+func TestDeriveLimits(t *testing.T) {
+    secret := writeFile(t, t.TempDir(), "secret", "master secret")
+    if r := run(t, "", "derive", "-key-file", secret, "-length", "8160"); r.code != 0 || len(r.stdout) != 2*8160+1 {
+        t.Errorf("derive -length 8160: exit %d, %d bytes", r.code, len(r.stdout))
+    }
+    run(t, "", "derive", "-key-file", secret, "-length", "8161").want(t, 2, "")
+    run(t, "", "derive", "-key-file", secret, "-algo", "sha1", "-length", "5101").want(t, 2, "")
+    run(t, "", "derive", "-key-file", secret, "-length", "0").want(t, 2, "")
+    run(t, "", "derive", "-key-file", secret, "-algo", "crc32c").want(t, 2, "")
+    run(t, "", "derive").want(t, 2, "")
+    run(t, "", "derive", "-key-file", secret, "extra").want(t, 2, "")
+    run(t, "", "derive", "-key-file", writeFile(t, t.TempDir(), "empty", "")).want(t, 3, "")
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// diffPaths compares two files or directory trees by content, like cmp: it
+// returns exitOK if they are identical, exitMismatch if they differ and, as
+// cmp does, exitUsage if either cannot be read. For directories every
+// differing, missing or retyped relative path is listed. Files of different
+// sizes are never hashed.
+func diffPaths(cfg checksum.Config, a, b string, opts checksum.TreeOptions, quiet bool, stdout, stderr io.Writer) int {
+    ta, err := pathType(a)
+    if err != nil {
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        return exitUsage
+    }
+    tb, err := pathType(b)
+    if err != nil {
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        return exitUsage
+    }
+
+    var differences []string
+    switch {
+    case ta != tb:
+        differences = append(differences, fmt.Sprintf("type differs: %s is a %s, %s is a %s", a, ta, b, tb))
+    case ta == checksum.Dir:
+        opts.NoDigest = true
+        if differences, err = diffTrees(cfg, a, b, opts); err != nil {
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            return exitUsage
+        }
+    default:
+        same, err := sameEntry(cfg, checksum.TreeEntry{Type: ta}, checksum.TreeEntry{Type: tb}, a, b)
+        if err != nil {
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            return exitUsage
+        }
+        if !same {
+            differences = append(differences, fmt.Sprintf("%s and %s differ", a, b))
+        }
+    }
+
+    if len(differences) == 0 {
+        if !quiet {
+            fmt.Fprintf(stdout, "%s and %s are identical\n", a, b)
+        }
+        return exitOK
+    }
+    if !quiet {
+        for _, d := range differences {
+            fmt.Fprintln(stdout, d)
+        }
+    }
+    return exitMismatch
+}
+
+func diffTrees(cfg checksum.Config, a, b string, opts checksum.TreeOptions) ([]string, error) {
+    ea, err := cfg.Tree(a, opts)
+    if err != nil {
+        return nil, err
+    }
+    eb, err := cfg.Tree(b, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    // Both listings are sorted by path, so a single merge pass pairs them up.
+    var differences []string
+    i, j := 0, 0
+    for i < len(ea) || j < len(eb) {
+        switch {
+        case j == len(eb) || i < len(ea) && ea[i].Path < eb[j].Path:
+            differences = append(differences, fmt.Sprintf("only in %s: %s", a, ea[i].Path))
+            i++
+        case i == len(ea) || eb[j].Path < ea[i].Path:
+            differences = append(differences, fmt.Sprintf("only in %s: %s", b, eb[j].Path))
+            j++
+        default:
+            x, y := ea[i], eb[j]
+            i++
+            j++
+            if x.Type != y.Type {
+                differences = append(differences, fmt.Sprintf("type differs: %s (%s in %s, %s in %s)", x.Path, x.Type, a, y.Type, b))
+                continue
+            }
+            same, err := sameEntry(cfg, x, y, filepath.Join(a, x.Path), filepath.Join(b, y.Path))
+            if err != nil {
+                return nil, err
+            }
+            if !same {
+                differences = append(differences, "differs: "+x.Path)
+            }
+        }
+    }
+    return differences, nil
+}
+
+// sameEntry compares two entries of the same type found at pa and pb.
+func sameEntry(cfg checksum.Config, x, y checksum.TreeEntry, pa, pb string) (bool, error) {
+    switch x.Type {
+    case checksum.Dir:
+        return true, nil
+    case checksum.Symlink:
+        ta, err := os.Readlink(pa)
+        if err != nil {
+            return false, err
+        }
+        tb, err := os.Readlink(pb)
+        return ta == tb, err
+    }
+
+    ia, err := os.Stat(pa)
+    if err != nil {
+        return false, err
+    }
+    ib, err := os.Stat(pb)
+    if err != nil {
+        return false, err
+    }
+    if ia.Size() != ib.Size() {
+        return false, nil
+    }
+    da, err := cfg.SumFile(pa)
+    if err != nil {
+        return false, err
+    }
+    db, err := cfg.SumFile(pb)
+    if err != nil {
+        return false, err
+    }
+    return bytes.Equal(da, db), nil
+}
+
+func pathType(path string) (checksum.EntryType, error) {
+    info, err := os.Lstat(path)
+    if err != nil {
+        return 0, err
+    }
+    switch {
+    case info.Mode()&os.ModeSymlink != 0:
+        return checksum.Symlink, nil
+    case info.IsDir():
+        return checksum.Dir, nil
+    case info.Mode().IsRegular():
+        return checksum.File, nil
+    }
+    return 0, fmt.Errorf("%s: unsupported file type %v", path, info.Mode().Type())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestDiffTrees(t *testing.T) {
+    dir := t.TempDir()
+    for _, side := range []string{"a", "b"} {
+        writeFile(t, dir, side+"/same", "s\n")
+        writeFile(t, dir, side+"/n/m/f", side+"\n")
+        writeFile(t, dir, side+"/only-"+side, "o\n")
+    }
+    writeFile(t, dir, "a/size", "1\n")
+    writeFile(t, dir, "b/size", "12\n")
+    writeFile(t, dir, "a/t/inside", "")
+    writeFile(t, dir, "b/t", "t\n")
+    runIn(t, dir, "", "-diff", "a", "b").want(t, 1, `differs: n/m/f
+only in a: only-a
+only in b: only-b
+differs: size
+type differs: t (dir in a, file in b)
+only in a: t/inside
+`)
+    runIn(t, dir, "", "-diff", "-quiet", "a", "b").want(t, 1, "")
+    runIn(t, dir, "", "-diff", "a/same", "b/same").want(t, 0, "a/same and b/same are identical\n")
+    runIn(t, dir, "", "-diff", "a/n/m/f", "b/n/m/f").want(t, 1, "a/n/m/f and b/n/m/f differ\n")
+    runIn(t, dir, "", "-diff", "a", "b/same").want(t, 1, "type differs: a is a dir, b/same is a file\n")
+    runIn(t, dir, "", "-diff", "a", "a").want(t, 0, "a and a are identical\n")
+    runIn(t, dir, "", "-diff", "a").want(t, 2, "")
+
+    // The same content under different names is a difference of names.
+    writeFile(t, dir, "c/x", "content")
+    writeFile(t, dir, "d/y", "content")
+    runIn(t, dir, "", "-diff", "c", "d").want(t, 1, "only in c: x\nonly in d: y\n")
+
+    if err := os.Symlink("same", filepath.Join(dir, "a", "l")); err != nil {
+        t.Skip("symlinks unsupported:", err)
+    }
+    os.Symlink("only-b", filepath.Join(dir, "b", "l"))
+    if r := runIn(t, dir, "", "-diff", "a", "b"); r.code != 1 || !strings.Contains(r.stdout, "differs: l\n") {
+        t.Errorf("differing symlink targets: %+v", r)
+    }
+}
+
+func TestDiffRejectsVerify(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "a")
+    b := writeFile(t, dir, "b", "b")
+    run(t, "", "-diff", a, a).want(t, 0, "*")
+    run(t, "", "-diff", "-quiet", a, b).want(t, 1, "")
+    run(t, "", "-verify", "000000000000", "-diff", a, a).want(t, 2, "")
+    run(t, "", "-verify", "000000000000", "-serve", "127.0.0.1:0").want(t, 2, "")
+}
+
+func TestDiffUnreadableExitsTwo(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "a")
+    missing := filepath.Join(dir, "missing")
+    run(t, "", "-diff", a, missing).want(t, 2, "")
+    run(t, "", "-diff", missing, a).want(t, 2, "")
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// textEncoding is how digests are printed and read back: a checksum.Encoding,
+// or bech32, which also needs the human-readable part given by -hrp.
+type textEncoding struct {
+    enc checksum.Encoding
+    hrp string // set only for bech32
+}
+
+func encodingNames() []string {
+    return append(checksum.EncodingNames(), "bech32")
+}
+
+// inputEncodingNames lists the encodings -input-encoding decodes arguments
+// from.
+func inputEncodingNames() []string {
+    return []string{checksum.Raw.String(), checksum.Hex.String(), checksum.Base64.String()}
+}
+
+// formatNames lists the values of -format.
+func formatNames() []string {
+    return []string{"plain", "multihash"}
+}
+
+func parseTextEncoding(name, hrp string) (textEncoding, error) {
+    if name == "bech32" {
+        if hrp == "" {
+            return textEncoding{}, errors.New("-encoding bech32 needs -hrp")
+        }
+        if _, err := checksum.Bech32Encode(hrp, nil); err != nil {
+            return textEncoding{}, fmt.Errorf("-hrp: %v", err)
+        }
+        return textEncoding{hrp: hrp}, nil
+    }
+    if hrp != "" {
+        return textEncoding{}, errors.New("-hrp only applies to -encoding bech32")
+    }
+    enc, err := checksum.ParseEncoding(name)
+    if err != nil {
+        return textEncoding{}, fmt.Errorf("unknown encoding %q (supported: %s)", name, strings.Join(encodingNames(), ", "))
+    }
+    return textEncoding{enc: enc}, nil
+}
+
+func (t textEncoding) bech32() bool { return t.hrp != "" }
+
+// is reports whether t is the plain encoding e.
+func (t textEncoding) is(e checksum.Encoding) bool { return !t.bech32() && t.enc == e }
+
+func (t textEncoding) String() string {
+    if t.bech32() {
+        return "bech32"
+    }
+    return t.enc.String()
+}
+
+// fits fails if n bytes are too long for the encoding; only bech32 has a
+// limit.
+func (t textEncoding) fits(n int) error {
+    if !t.bech32() {
+        return nil
+    }
+    _, err := checksum.Bech32Encode(t.hrp, make([]byte, n))
+    return err
+}
+
+// Encode returns b in the encoding. Callers check bech32 lengths with fits
+// first.
+func (t textEncoding) Encode(b []byte) string {
+    if !t.bech32() {
+        return t.enc.Encode(b)
+    }
+    s, _ := checksum.Bech32Encode(t.hrp, b)
+    return s
+}
+
+// Decode is the inverse of Encode. A bech32 string must carry t's
+// human-readable part and a valid checksum.
+func (t textEncoding) Decode(s string) ([]byte, error) {
+    if !t.bech32() {
+        return t.enc.Decode(s)
+    }
+    hrp, data, err := checksum.Bech32Decode(s)
+    if err != nil {
+        return nil, err
+    }
+    if hrp != strings.ToLower(t.hrp) {
+        return nil, fmt.Errorf("human-readable part is %q, not %q", hrp, t.hrp)
+    }
+    return data, nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestEncodingFlag(t *testing.T) {
+    for _, tt := range []struct {
+        args []string
+        want string
+    }{
+        {[]string{"-encoding", "hex"}, "dcb7a8333405"},
+        {[]string{"-encoding", "hex", "-length", "6"}, "dcb7a8"},
+        {[]string{"-encoding", "base64"}, "3LeoMzQF"},
+        {[]string{"-encoding", "base64", "-length", "0"}, "3LeoMzQFLYmC+/myshMQq+57mm8="},
+        {[]string{"-encoding", "base64url", "-length", "0"}, "3LeoMzQFLYmC-_myshMQq-57mm8"},
+        {[]string{"-encoding", "base32"}, "3S32QMZUAU======"},
+        {[]string{"-encoding", "base32", "-length", "0"}, "3S32QMZUAUWYTAX37GZLEEYQVPXHXGTP"},
+        {[]string{"-encoding", "bech32", "-hrp", "rt", "-length", "6"}, "rt1mjm6sve5q5mm9vqx"},
+    } {
+        run(t, "", append(tt.args, "a", "b", "c")...).want(t, 0, tt.want+"\n")
+    }
+    run(t, "", "-encoding", "raw", "-length", "0", "a", "b", "c").want(t, 0, "\xdc\xb7\xa8\x33\x34\x05\x2d\x89\x82\xfb\xf9\xb2\xb2\x13\x10\xab\xee\x7b\x9a\x6f")
+    run(t, "", "-encoding", "raw", "a", "b", "c").want(t, 0, "\xdc\xb7\xa8\x33\x34\x05")
+    run(t, "", "-encoding", "base58", "a").want(t, 2, "")
+}
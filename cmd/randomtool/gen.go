@@ -0,0 +1,103 @@
+package main
+
+import (
+    "bufio"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strconv"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// maxGenSize is the most a single checksum.NewStream can produce.
+const maxGenSize = 256 << 30
+
+// runGen writes fixture files of deterministic data. The full sha256
+// checksum of the -seed parts seeds checksum.NewStream, as -stream does,
+// and each of -count files gets its own checksum.SubSeed, so the same
+// seed always gives the same files.
+func runGen(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    var seeds stringList
+    fs.Var(&seeds, "seed", "a seed `part`; repeat for more parts")
+    size := byteSize(0)
+    fs.Var(&size, "size", "bytes to write to each file, such as 10MiB")
+    out := fs.String("out", "", "write the data to `path`")
+    count := fs.Int("count", 0, "write `n` files, named by index, into -out-dir")
+    outDir := fs.String("out-dir", "", "with -count, the directory `path` to write into")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() > 0 {
+        return usagef("gen takes no arguments")
+    }
+    if len(seeds) == 0 {
+        return usagef("gen needs at least one -seed")
+    }
+    if limit := byteSize(maxGenSize); !flagSet(fs, "size") || size > limit {
+        return usagef("gen needs -size, at most %s", &limit)
+    }
+    switch {
+    case flagSet(fs, "count") != (*outDir != ""):
+        return usagef("-count and -out-dir go together")
+    case flagSet(fs, "count") && *out != "":
+        return usagef("-out cannot be combined with -count")
+    case flagSet(fs, "count") && *count < 1:
+        return usagef("-count must be positive")
+    case !flagSet(fs, "count") && *out == "":
+        return usagef("gen needs -out, or -count and -out-dir")
+    }
+
+    seed, _, err := argsChecksum(checksum.Config{Algorithm: checksum.SHA256}, seeds, checksum.Raw, stdin, 0, nil)
+    if err != nil {
+        return err
+    }
+    if *out != "" {
+        return writeAtomic(*out, checksum.NewStream(seed), int64(size))
+    }
+    if err := os.MkdirAll(*outDir, 0o755); err != nil {
+        return err
+    }
+    width := len(strconv.Itoa(*count - 1))
+    for i := range *count {
+        path := filepath.Join(*outDir, fmt.Sprintf("%0*d.bin", width, i))
+        if err := writeAtomic(path, checksum.NewStream(checksum.SubSeed(seed, uint64(i))), int64(size)); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// writeAtomic writes n bytes of r to a temporary file beside path, syncs it
+// and renames it into place, so path never holds a partial file, even after
+// a crash.
+func writeAtomic(path string, r io.Reader, n int64) error {
+    tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if err := tmp.Chmod(0o644); err != nil {
+        tmp.Close()
+        return err
+    }
+    bw := bufio.NewWriterSize(tmp, 1<<20)
+    if _, err := io.CopyN(bw, r, n); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := bw.Flush(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Sync(); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
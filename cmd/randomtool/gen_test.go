@@ -0,0 +1,63 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "os"
+    "path/filepath"
+    "slices"
+    "testing"
+)
+
+func sha256File(t *testing.T, path string) string {
+    t.Helper()
+    b, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    sum := sha256.Sum256(b)
+    return hex.EncodeToString(sum[:])
+}
+
+func TestGen(t *testing.T) {
+    dir := t.TempDir()
+    one := filepath.Join(dir, "one")
+    run(t, "", "gen", "-seed", "a", "-seed", "b", "-size", "1KiB", "-out", one).want(t, 0, "")
+    if got, want := sha256File(t, one), "278e1e94a03a17714e957a278f226792c014742c60a82e1896454b4cbce7fee3"; got != want {
+        t.Errorf("gen -out: sha256 %s, want %s", got, want)
+    }
+
+    // -out-dir is created, parents included.
+    out := filepath.Join(dir, "x", "y")
+    run(t, "", "gen", "-seed", "a", "-seed", "b", "-size", "1KiB", "-count", "3", "-out-dir", out).want(t, 0, "")
+    for name, want := range map[string]string{
+        "0.bin": "b9e086f739f0ddc817fbc3f98d5f615bd18c862e94e6848c2ab74401e3e0ca21",
+        "1.bin": "b3358f58f5e449c219d7e6e70ada8127520b43be6ede8a9f16975940a74ba2fd",
+        "2.bin": "e4d3a2a91458229fba41cecb40e34c8241f3084ff0942a0460a8a5343b354dec",
+    } {
+        if got := sha256File(t, filepath.Join(out, name)); got != want {
+            t.Errorf("gen -count: %s has sha256 %s, want %s", name, got, want)
+        }
+    }
+    entries, err := os.ReadDir(out)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var names []string
+    for _, e := range entries {
+        names = append(names, e.Name())
+    }
+    if want := []string{"0.bin", "1.bin", "2.bin"}; !slices.Equal(names, want) {
+        t.Errorf("gen -count left %q, want %q", names, want)
+    }
+
+    for _, args := range [][]string{
+        {"gen", "-size", "1", "-out", one},
+        {"gen", "-seed", "a", "-out", one},
+        {"gen", "-seed", "a", "-size", "1", "-count", "2"},
+        {"gen", "-seed", "a", "-size", "1", "-count", "0", "-out-dir", out},
+        {"gen", "-seed", "a", "-size", "1", "-count", "2", "-out-dir", out, "-out", one},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+}
@@ -0,0 +1,824 @@
+package main
+
+import (
+    "crypto/subtle"
+    "encoding/hex"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "net/http"
+    "path"
+    "regexp"
+    "runtime"
+    "slices"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// hashFlags are the flags of runHash, grouped by the mode or feature they
+// belong to.
+type hashFlags struct {
+    algo, encoding, hrp, outFormat, multibase string
+    length                                    int
+    legacy, jsonOut, verbose                  bool
+    logPath                                   string
+    logMax                                    byteSize
+
+    // Inputs: argument parts, or paths with one of the path modes.
+    files, manifest, dirs, archives, urls bool
+    inputEncoding, inputList              string
+    nulSep                                bool
+    maxBytes                              byteSize
+    jobs                                  int
+    showProgress                          bool
+
+    // Reading files and caching their checksums.
+    reader      string
+    buffer      byteSize
+    cachePath   string
+    cacheVerify float64
+
+    // Transforming the input.
+    normalize, saltFlag string
+    jsonCanonical       bool
+    saltBytes           int
+
+    // Verifying, and -also.
+    expected, also, verifyOld, verifyNew string
+    allowPrefix, quiet                   bool
+
+    // -check and -check-name.
+    check, namePattern string
+    checkName          bool
+    maxLine            byteSize
+
+    // -dir, -archive and -url.
+    exclude                     stringList
+    gitIgnore, includeUntracked bool
+    archiveModes                bool
+    timeout                     time.Duration
+    maxSize                     int64
+    maxRedirects                int
+
+    // The other modes.
+    lines, noEcho, watch, diff bool
+    pollInterval, debounce     time.Duration
+    serveAddr                  string
+    maxBody                    int64
+    drain                      time.Duration
+    streamLen                  int64
+
+    // Generators, which moved to the random and uuid commands.
+    random, count, passphrase  int
+    uuid4, entropy             bool
+    uuid5, separator, wordlist string
+}
+
+func defineHashFlags(fs *flag.FlagSet) *hashFlags {
+    h := new(hashFlags)
+    fs.StringVar(&h.algo, "algo", "sha1", "hash algorithm: "+strings.Join(checksum.AlgorithmNames(), ", "))
+    fs.IntVar(&h.length, "length", 12, "number of hex characters (bytes for other encodings) to print, 0 for the full digest")
+    fs.StringVar(&h.encoding, "encoding", "hex", "output encoding: "+strings.Join(encodingNames(), ", "))
+    fs.StringVar(&h.hrp, "hrp", "", "with -encoding bech32, the human-readable `prefix`, such as bc")
+    fs.StringVar(&h.outFormat, "format", "plain", "checksum format: "+strings.Join(formatNames(), ", ")+"; multihash is a self-describing <code><length><digest>")
+    fs.StringVar(&h.multibase, "multibase", "", "with -format multihash, encode as multibase `base` ("+strings.Join(checksum.MultibaseNames(), ", ")+") instead of bare hex")
+    fs.String("hmac-key", "", "compute an HMAC keyed with `key`")
+    fs.String("hmac-key-file", "", "compute an HMAC keyed with the raw contents of `path`; a trailing newline is part of the key")
+    fs.String("hmac-key-env", "", "compute an HMAC keyed with the value of environment variable `name`")
+    fs.BoolVar(&h.legacy, "legacy", false, "concatenate parts without length framing, as releases before framing did")
+    fs.BoolVar(&h.jsonOut, "json", false, "print a single JSON document describing every input and checksum")
+    fs.BoolVar(&h.verbose, "v", false, "describe every input part, with its length and own digest, and cache hits and misses on stderr")
+    fs.StringVar(&h.logPath, "log", "", "append a JSON line describing the run, without argument contents, to the file at `path`")
+    fs.Var(&h.logMax, "log-max-size", "with -log, first move the log to path.1 when it would grow past `size`")
+
+    fs.BoolVar(&h.files, "file", false, "treat arguments as paths and print one checksum per file")
+    fs.BoolVar(&h.manifest, "manifest", false, "like -file, but print full digests in sha1sum format unless -length is given")
+    fs.BoolVar(&h.dirs, "dir", false, "treat arguments as directories and print one tree checksum per directory")
+    fs.BoolVar(&h.archives, "archive", false, "treat arguments as tar, tar.gz or zip files and print one checksum of their contents per archive")
+    fs.BoolVar(&h.urls, "url", false, "treat arguments as URLs, download each and print one checksum per URL")
+    fs.StringVar(&h.inputEncoding, "input-encoding", "raw", "decode each argument from `encoding` ("+strings.Join(inputEncodingNames(), ", ")+") before hashing; stdin (-) is never decoded")
+    fs.StringVar(&h.inputList, "input-list", "", "hash the records of the file at `path` (\"-\" for stdin) as parts, one per line")
+    fs.BoolVar(&h.nulSep, "0", false, "separate -input-list and -lines records with NUL bytes instead of newlines")
+    fs.Var(&h.maxBytes, "max-bytes", "with arguments or -input-list, fail with status 5 once more than `size` bytes of input are read (0 for no limit)")
+    fs.IntVar(&h.jobs, "jobs", runtime.NumCPU(), "with -file or -dir, hash up to `n` paths concurrently")
+    fs.BoolVar(&h.showProgress, "progress", false, "with -file, -manifest or -url, report bytes hashed, throughput and ETA on stderr")
+
+    fs.StringVar(&h.reader, "reader", "buffered", "with -file, -manifest or -check, how files are read: "+strings.Join(checksum.ReadModeNames(), ", "))
+    h.buffer = 64 << 10
+    fs.Var(&h.buffer, "buffer", "with -file, -manifest or -check, the read buffer `size`")
+    fs.StringVar(&h.cachePath, "cache", "", "with -file, -manifest or -check, reuse checksums recorded in the cache file at `path` for files whose size, modification time and inode are unchanged")
+    fs.Float64Var(&h.cacheVerify, "cache-verify", 0, "with -cache, re-hash this `fraction` (0 to 1) of cache hits to detect stale entries")
+
+    fs.StringVar(&h.normalize, "normalize", "", "normalize text before hashing; `list` is a comma list of "+strings.Join(checksum.NormalizationNames(), ", "))
+    fs.BoolVar(&h.jsonCanonical, "json-canonical", false, "parse each input as JSON and hash its RFC 8785 canonical form; the same as adding json to -normalize")
+    fs.StringVar(&h.saltFlag, "salt", "", "prepend a salt to the input and print \"salt:checksum\"; `value` is hex or \"auto\" for a random salt")
+    fs.IntVar(&h.saltBytes, "salt-bytes", 16, "with -salt auto, the salt length in bytes")
+
+    fs.StringVar(&h.expected, "verify", "", "compare the checksum against `hex`, as printed or in full, and exit 1 on mismatch")
+    fs.StringVar(&h.also, "also", "", "also compute the `algo` checksum in the same pass over the input and print both, as algo:checksum")
+    fs.StringVar(&h.verifyOld, "verify-old", "", "with -also, compare the -algo checksum against `hex`; passes only if -verify-new matches too")
+    fs.StringVar(&h.verifyNew, "verify-new", "", "with -also, compare the -also checksum against `hex`")
+    fs.BoolVar(&h.allowPrefix, "allow-prefix", false, "with -verify or -check, also accept any shorter leading part of the checksum")
+    fs.BoolVar(&h.quiet, "quiet", false, "with -verify, print nothing and report only through the exit status; with -check, omit OK lines")
+
+    fs.StringVar(&h.check, "check", "", "read checksums from the manifest at `path` and verify each file")
+    fs.BoolVar(&h.checkName, "check-name", false, "verify each file path argument, or each file below them with -dir, against the hex checksum in its name")
+    fs.StringVar(&h.namePattern, "name-pattern", defaultNamePattern, "with -check-name, the `regexp` whose one group extracts the checksum from a file name")
+    h.maxLine = 1 << 20
+    fs.Var(&h.maxLine, "max-line", "with -check, fail with status 5 on a manifest line longer than `size`")
+
+    fs.Var(&h.exclude, "exclude", "with -dir, skip entries whose name or relative path matches `glob` (repeatable)")
+    fs.BoolVar(&h.gitIgnore, "git", false, "with -dir or -diff, skip .git and the paths that the work tree's .gitignore files ignore")
+    fs.BoolVar(&h.includeUntracked, "include-untracked", true, "with -git, also hash files that are not in the git index")
+    fs.BoolVar(&h.archiveModes, "archive-modes", true, "with -archive, include file permission bits in the checksum")
+    fs.DurationVar(&h.timeout, "timeout", 0, "with -url, give up on a download after `duration` (0 for no limit)")
+    fs.Int64Var(&h.maxSize, "max-size", 0, "with -url, fail downloads larger than `n` bytes (0 for no limit)")
+    fs.IntVar(&h.maxRedirects, "max-redirects", 10, "with -url, follow at most `n` redirects")
+
+    fs.BoolVar(&h.lines, "lines", false, "print the checksum and a tab before every line of stdin")
+    fs.BoolVar(&h.noEcho, "no-echo", false, "with -lines, print only the checksums")
+    fs.BoolVar(&h.watch, "watch", false, "with -file or -dir, keep running and print a timestamped checksum whenever a path changes")
+    fs.DurationVar(&h.pollInterval, "poll-interval", 250*time.Millisecond, "with -watch, how often to check paths for changes")
+    fs.DurationVar(&h.debounce, "debounce", 200*time.Millisecond, "with -watch, how long a path must stay unchanged before it is re-hashed")
+    fs.BoolVar(&h.diff, "diff", false, "compare the two file or directory arguments by content; exit 0 if identical, 1 if not, 2 on error")
+    fs.StringVar(&h.serveAddr, "serve", "", "run an HTTP server on `addr` with POST /checksum and GET /healthz")
+    fs.Int64Var(&h.maxBody, "max-body", 64<<20, "with -serve, reject request bodies over `n` bytes with 413")
+    fs.DurationVar(&h.drain, "drain-timeout", 10*time.Second, "with -serve, how long to let in-flight requests finish on shutdown")
+    fs.Int64Var(&h.streamLen, "stream", -1, "write `n` bytes of pseudo-random data derived from the full digest instead of the checksum")
+
+    fs.IntVar(&h.random, "random", 0, "print `n` bytes from crypto/rand in the selected encoding instead of a checksum")
+    fs.IntVar(&h.count, "count", 1, "with -random, print `m` independent values, one per line")
+    fs.BoolVar(&h.uuid4, "uuid", false, "print a random version 4 UUID")
+    fs.StringVar(&h.uuid5, "uuid5", "", "print the version 5 UUID of the arguments in `namespace` (dns, url, oid, x500 or a UUID)")
+    fs.IntVar(&h.passphrase, "passphrase", 0, "print a passphrase of `n` words chosen with crypto/rand")
+    fs.StringVar(&h.separator, "separator", " ", "with -passphrase, the `string` placed between words")
+    fs.BoolVar(&h.entropy, "entropy", false, "with -passphrase, print the passphrase's bits of entropy on stderr")
+    fs.StringVar(&h.wordlist, "wordlist", "", "with -passphrase, choose words from the newline-separated list at `path` instead of the EFF large list")
+    return h
+}
+
+// hashRun is one invocation of runHash: its flags, the checksum
+// configuration they select, and where results go.
+type hashRun struct {
+    *hashFlags
+    fs     *flag.FlagSet
+    runlog *runLog
+    stdin  io.Reader
+    stdout io.Writer
+    stderr io.Writer
+
+    cfg           checksum.Config
+    enc           textEncoding
+    inEnc         checksum.Encoding
+    multihashWant []byte // the digest a -format multihash -verify value names
+    verifyBoth    bool
+    treeOpts      checksum.TreeOptions
+    readOpts      checksum.ReadOptions
+    cache         *sumCache
+}
+
+// runHash parses the hashing flags and computes whatever they select. The
+// legacy invocation, hash, verify and manifest all run through it; mode
+// gives the latter two their fixed behavior. Checks that concern several
+// modes are made by the setup steps; each mode's own checks are in its run
+// method.
+func runHash(mode hashMode, fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) (runErr error) {
+    all := fs
+    if mode != modeLegacy {
+        // Define every flag on a scratch set and copy over only the ones
+        // this command takes, so that it neither parses nor lists the rest.
+        all = newFlagSet(fs.Name(), io.Discard)
+    }
+    h := defineHashFlags(all)
+    if all != fs {
+        all.VisitAll(func(f *flag.Flag) {
+            if mode.takes(f.Name) {
+                fs.Var(f.Value, f.Name, f.Usage)
+            }
+        })
+    }
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    r := &hashRun{hashFlags: h, fs: fs, stdin: stdin, stdout: stdout, stderr: stderr}
+    if h.logPath != "" {
+        r.runlog = newRunLog(h.logPath, int64(h.logMax), fs.Name())
+        defer func() { runErr = r.runlog.finish(runErr, stderr) }()
+    } else if r.set("log-max-size") {
+        return usagef("-log-max-size needs -log")
+    }
+    switch mode {
+    case modeVerify:
+        if fs.NArg() == 0 || fs.Arg(0) == "" {
+            return usagef("verify takes the expected checksum as its first argument")
+        }
+        h.expected = fs.Arg(0)
+        if err := fs.Parse(fs.Args()[1:]); err != nil {
+            return parseError(err)
+        }
+    case modeManifest:
+        h.manifest = true
+    }
+    if r.set("verify") && h.expected == "" {
+        return usagef("-verify needs a checksum")
+    }
+
+    if err := r.setupAlgorithm(); err != nil {
+        return err
+    }
+    if done, err := r.runGenerator(); done {
+        return err
+    }
+    for _, setup := range []func() error{
+        r.setupInputEncoding, r.setupLimits, r.setupSalt, r.setupNormalize,
+        r.setupOutput, r.setupTree, r.setupStream, r.setupAlso, r.setupVerify,
+    } {
+        if err := setup(); err != nil {
+            return err
+        }
+    }
+    switch {
+    case h.checkName:
+        return r.runCheckName()
+    case h.lines:
+        return r.runLines()
+    case h.serveAddr != "":
+        return r.runServe()
+    case h.diff:
+        return r.runDiff()
+    case h.check != "":
+        return r.runCheck()
+    }
+    return r.runInputs()
+}
+
+func (r *hashRun) set(name string) bool { return flagSet(r.fs, name) }
+
+// setupAlgorithm builds the checksum configuration from -algo, the
+// -hmac-key flags and -legacy, and parses the output encoding.
+func (r *hashRun) setupAlgorithm() error {
+    alg, err := checksum.ParseAlgorithm(r.algo)
+    if err != nil {
+        return usageError{err}
+    }
+    key, err := hmacKey(r.fs)
+    if err != nil {
+        return err
+    }
+    if key != nil && !alg.Cryptographic() {
+        return usagef("-hmac-key needs a cryptographic -algo, not %s", alg)
+    }
+    r.cfg = checksum.Config{Algorithm: alg, Key: key, Legacy: r.legacy}
+    r.runlog.setAlgorithm(r.cfg.Name())
+    if r.enc, err = parseTextEncoding(r.encoding, r.hrp); err != nil {
+        return usageError{err}
+    }
+    return nil
+}
+
+// runGenerator prints whatever -uuid, -uuid5, -passphrase or -random
+// asks for instead of a checksum, and reports whether one was given.
+func (r *hashRun) runGenerator() (bool, error) {
+    switch {
+    case r.uuid4:
+        u, err := newUUIDv4()
+        if err != nil {
+            return true, err
+        }
+        fmt.Fprintln(r.stdout, u)
+        return true, nil
+    case r.uuid5 != "":
+        ns, err := parseUUID(r.uuid5)
+        if err != nil {
+            return true, usagef("-uuid5: %v", err)
+        }
+        fmt.Fprintln(r.stdout, newUUIDv5(ns, r.fs.Args()))
+        return true, nil
+    case r.set("passphrase"):
+        return true, printPassphrase(r.passphrase, r.wordlist, r.separator, r.entropy, r.stdout, r.stderr)
+    case r.set("random"):
+        return true, printRandom(r.random, r.count, r.enc, r.stdout)
+    }
+    return false, nil
+}
+
+func (r *hashRun) setupInputEncoding() error {
+    if !slices.Contains(inputEncodingNames(), r.inputEncoding) {
+        return usagef("unknown -input-encoding %q (supported: %s)", r.inputEncoding, strings.Join(inputEncodingNames(), ", "))
+    }
+    var err error
+    if r.inEnc, err = checksum.ParseEncoding(r.inputEncoding); err != nil {
+        return usageError{err}
+    }
+    if r.inEnc != checksum.Raw && (r.files || r.dirs || r.archives || r.urls || r.manifest || r.inputList != "" || r.check != "" || r.diff) {
+        return usagef("-input-encoding only applies to argument parts")
+    }
+    return nil
+}
+
+func (r *hashRun) setupLimits() error {
+    if r.set("max-bytes") && (r.files || r.dirs || r.archives || r.urls || r.manifest || r.check != "" || r.checkName || r.diff || r.lines || r.serveAddr != "") {
+        return usagef("-max-bytes only applies to arguments and -input-list; downloads take -max-size and -serve takes -max-body")
+    }
+    if r.set("max-line") && (r.check == "" || r.maxLine < 1 || r.maxLine > 1<<30) {
+        return usagef("-max-line needs -check and a size between 1 byte and 1GiB")
+    }
+    return nil
+}
+
+func (r *hashRun) setupSalt() error {
+    var err error
+    if r.cfg.Salt, err = resolveSalt(r.saltFlag, r.saltBytes, &r.expected); err != nil {
+        return usageError{err}
+    }
+    if r.cfg.Salt != nil && (r.enc.is(checksum.Raw) || r.lines || r.check != "" || r.checkName || r.diff || r.serveAddr != "" || r.set("stream")) {
+        return usagef("-salt cannot be combined with -encoding raw, -lines, -check, -check-name, -diff, -serve or -stream")
+    }
+    return nil
+}
+
+func (r *hashRun) setupNormalize() error {
+    var err error
+    if r.cfg.Normalize, err = checksum.ParseNormalization(r.normalize); err != nil {
+        return usageError{err}
+    }
+    if r.jsonCanonical {
+        r.cfg.Normalize |= checksum.NormalizeJSON
+    }
+    if r.cfg.Normalize != 0 && (r.lines || r.inputList != "" || r.serveAddr != "" || r.cachePath != "") {
+        return usagef("-normalize cannot be combined with -lines, -input-list, -serve or -cache")
+    }
+    return nil
+}
+
+// setupOutput checks -format, -length and -encoding against each other
+// and the modes, and settles the output length.
+func (r *hashRun) setupOutput() error {
+    if r.manifest && !r.set("length") {
+        r.length = 0
+    }
+    if !slices.Contains(formatNames(), r.outFormat) {
+        return usagef("unknown -format %q (supported: %s)", r.outFormat, strings.Join(formatNames(), ", "))
+    }
+    var err error
+    if r.outFormat == "multihash" {
+        if (r.set("length") && r.length != 0) || !r.enc.is(checksum.Hex) || r.cfg.Key != nil {
+            return usagef("-format multihash always uses the full, unkeyed digest and cannot be combined with -length, -encoding or -hmac-key")
+        }
+        if _, err := checksum.MultibaseEncode(r.multibase, nil); r.multibase != "" && err != nil {
+            return usageError{err}
+        }
+        if r.expected != "" {
+            if r.cfg.Algorithm, r.multihashWant, err = checksum.DecodeMultihash(r.expected); err != nil {
+                return usagef("-verify: %v", err)
+            }
+        } else if _, ok := r.cfg.Algorithm.MultihashCode(); !ok {
+            return usagef("%s has no multihash code", r.cfg.Algorithm)
+        }
+        r.length = 0
+    }
+    if r.length, err = resolveLength(r.cfg, r.enc, r.length, r.set("length")); err != nil {
+        return usagef("-length %v", err)
+    }
+    if err := r.enc.fits(digestBytes(r.cfg, r.length)); err != nil {
+        return usagef("-encoding bech32: %v; give a smaller -length", err)
+    }
+    if r.enc.is(checksum.Raw) && (r.files || r.dirs || r.archives || r.urls || r.manifest || r.jsonOut || r.check != "") {
+        return usagef("-encoding raw only applies to a single argument checksum")
+    }
+    if r.jsonOut && (r.check != "" || r.expected != "") {
+        return usagef("-json cannot be combined with -check or -verify")
+    }
+    return nil
+}
+
+// setupTree checks the -dir and -diff tree options, -git and -exclude.
+func (r *hashRun) setupTree() error {
+    if r.gitIgnore && !r.dirs && !r.diff || r.set("include-untracked") && !r.gitIgnore {
+        return usagef("-git needs -dir or -diff, and -include-untracked needs -git")
+    }
+    for _, pattern := range r.exclude {
+        if _, err := path.Match(pattern, ""); err != nil {
+            return usagef("-exclude %q: %v", pattern, err)
+        }
+    }
+    r.treeOpts = checksum.TreeOptions{Exclude: r.exclude, Git: r.gitIgnore, TrackedOnly: !r.includeUntracked}
+    return nil
+}
+
+func (r *hashRun) setupStream() error {
+    if r.set("stream") && (r.streamLen < 0 || r.jsonOut || r.expected != "" || r.check != "") {
+        return usagef("-stream takes a non-negative size and cannot be combined with -json, -verify or -check")
+    }
+    if r.streamLen > maxGenSize {
+        return usagef("-stream is at most %d bytes, the most one stream can produce", int64(maxGenSize))
+    }
+    return nil
+}
+
+func (r *hashRun) setupAlso() error {
+    if r.also == "" {
+        return nil
+    }
+    a, err := checksum.ParseAlgorithm(r.also)
+    if err != nil {
+        return usagef("-also: %v", err)
+    }
+    if a == r.cfg.Algorithm {
+        return usagef("-also %s is already the -algo", a)
+    }
+    if r.cfg.Key != nil && !a.Cryptographic() {
+        return usagef("-hmac-key needs a cryptographic -also, not %s", a)
+    }
+    if r.dirs || r.archives || r.urls || r.manifest || r.check != "" || r.checkName || r.cachePath != "" || r.serveAddr != "" || r.lines || r.diff || r.watch || r.set("stream") || r.cfg.Salt != nil || r.outFormat != "plain" || r.enc.is(checksum.Raw) {
+        return usagef("-also only combines with arguments, -file and -input-list, in a plain text encoding without -salt")
+    }
+    if r.expected != "" {
+        return usagef("-also takes -verify-old and -verify-new instead of -verify")
+    }
+    r.cfg.Also = []checksum.Algorithm{a}
+    r.runlog.setAlgorithm(r.cfg.Name())
+    return nil
+}
+
+// setupVerify checks -verify-old and -verify-new, and the modes that have
+// nothing to verify.
+func (r *hashRun) setupVerify() error {
+    r.verifyBoth = r.set("verify-old") || r.set("verify-new")
+    if r.verifyBoth {
+        if r.also == "" || r.verifyOld == "" || r.verifyNew == "" || r.jsonOut {
+            return usagef("-verify-old and -verify-new go together, need -also and cannot be combined with -json")
+        }
+        for _, v := range []struct{ flag, sum string }{{"verify-old", r.verifyOld}, {"verify-new", r.verifyNew}} {
+            if !validChecksum(v.sum, r.enc) {
+                return usagef("-%s %q is not a %s checksum", v.flag, v.sum, r.enc)
+            }
+        }
+    }
+    if r.verifying() && (r.lines || r.diff || r.serveAddr != "") {
+        return usagef("-verify, -verify-old and -verify-new cannot be combined with -lines, -diff or -serve")
+    }
+    return nil
+}
+
+func (r *hashRun) verifying() bool { return r.expected != "" || r.verifyBoth }
+
+// setupReading applies -manifest, -reader, -buffer and -cache for the
+// modes that read files.
+func (r *hashRun) setupReading() error {
+    if r.manifest {
+        r.files = true
+    }
+    readMode, err := checksum.ParseReadMode(r.reader)
+    if err != nil {
+        return usageError{err}
+    }
+    if (r.set("reader") || r.set("buffer")) && !r.files && r.check == "" && !r.checkName {
+        return usagef("-reader and -buffer only apply to -file, -manifest, -check and -check-name")
+    }
+    if r.buffer < 1 || r.buffer > 1<<30 {
+        return usagef("-buffer must be between 1 byte and 1GiB")
+    }
+    r.readOpts = checksum.ReadOptions{Mode: readMode, Buffer: int(r.buffer)}
+    if r.cachePath != "" {
+        if !r.files && r.check == "" && !r.checkName || r.cfg.Key != nil || r.cfg.Salt != nil || r.cacheVerify < 0 || r.cacheVerify > 1 {
+            return usagef("-cache needs -file, -manifest, -check or -check-name, no -hmac-key or -salt, and a -cache-verify between 0 and 1")
+        }
+        if r.cache, err = openCache(r.cachePath, r.cacheVerify); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// recordSep is the separator of -input-list and -lines records.
+func (r *hashRun) recordSep() byte {
+    if r.nulSep {
+        return 0
+    }
+    return '\n'
+}
+
+// trace is where -v describes inputs, or nil.
+func (r *hashRun) trace() io.Writer {
+    if r.verbose {
+        return r.stderr
+    }
+    return nil
+}
+
+// sumText renders digest as the output lines show it.
+func (r *hashRun) sumText(digest []byte) string {
+    if len(r.cfg.Also) > 0 {
+        var sums []string
+        for i, d := range r.cfg.Split(digest) {
+            sums = append(sums, r.cfg.Algorithms()[i].String()+":"+format(d, r.enc, r.length))
+        }
+        return strings.Join(sums, " ")
+    }
+    sum := format(digest, r.enc, r.length)
+    if r.outFormat == "multihash" {
+        mh, _ := checksum.Multihash(r.cfg.Algorithm, digest)
+        sum = hex.EncodeToString(mh)
+        if r.multibase != "" {
+            sum, _ = checksum.MultibaseEncode(r.multibase, mh)
+        }
+    }
+    if r.cfg.Salt != nil {
+        sum = hex.EncodeToString(r.cfg.Salt) + ":" + sum
+    }
+    return sum
+}
+
+func (r *hashRun) runCheckName() error {
+    if r.check != "" || r.expected != "" || r.jsonOut || r.files || r.manifest || r.archives || r.urls || r.diff || r.watch || r.lines || r.serveAddr != "" || !r.enc.is(checksum.Hex) {
+        return usagef("-check-name only combines with -dir and hex output")
+    }
+    if err := r.setupReading(); err != nil {
+        return err
+    }
+    pattern, err := regexp.Compile(r.namePattern)
+    if err != nil {
+        return usagef("-name-pattern: %v", err)
+    }
+    if pattern.NumSubexp() != 1 {
+        return usagef("-name-pattern must have exactly one capture group, not %d", pattern.NumSubexp())
+    }
+    if r.fs.NArg() == 0 {
+        return usagef("-check-name needs file paths, or directories with -dir")
+    }
+    nc := nameChecker{cfg: r.cfg, pattern: pattern, cache: r.cache, opts: r.readOpts, stdin: r.stdin}
+    if r.set("length") {
+        nc.length = r.length
+    }
+    return status(r.cache.finish(checkNames(nc, r.fs.Args(), r.dirs, r.treeOpts, r.quiet, r.stdout, r.stderr), r.verbose, r.stderr))
+}
+
+func (r *hashRun) runLines() error {
+    return status(hashLines(r.cfg, r.stdin, r.recordSep(), r.noEcho, r.enc, r.length, r.stdout, r.stderr))
+}
+
+func (r *hashRun) runServe() error {
+    if r.enc.bech32() {
+        return usagef("-serve takes its encoding per request and does not support bech32")
+    }
+    return status(serve(r.serveAddr, newServer(r.cfg, r.maxBody), r.drain, r.stderr))
+}
+
+func (r *hashRun) runDiff() error {
+    if r.fs.NArg() != 2 {
+        return usagef("-diff takes exactly two paths")
+    }
+    return status(diffPaths(r.cfg, r.fs.Arg(0), r.fs.Arg(1), r.treeOpts, r.quiet, r.stdout, r.stderr))
+}
+
+func (r *hashRun) runCheck() error {
+    if err := r.setupReading(); err != nil {
+        return err
+    }
+    match := hexMatcher(r.cfg, r.length, r.allowPrefix)
+    if r.multihashWant != nil || r.outFormat == "multihash" {
+        match = multihashMatcher(r.cfg)
+    }
+    r.runlog.add(input{name: r.check, kind: "manifest"}, "", nil)
+    return status(r.cache.finish(checkManifest(r.check, match, r.cache, r.readOpts, int(r.maxLine), r.quiet, r.stdin, r.stdout, r.stderr), r.verbose, r.stderr))
+}
+
+// runInputs hashes the arguments: one by one as paths with -file, -dir,
+// -archive or -url, and otherwise together as parts.
+func (r *hashRun) runInputs() error {
+    if err := r.setupReading(); err != nil {
+        return err
+    }
+    if r.jobs < 1 {
+        return usagef("-jobs must be at least 1")
+    }
+    if countTrue(r.files, r.dirs, r.archives, r.urls) > 1 {
+        return usagef("-file, -dir, -archive and -url are mutually exclusive")
+    }
+    var progress *checksum.ProgressWriter
+    var progressOut io.Writer // nil unless -progress, so no copy is made
+    if r.showProgress {
+        if !r.files && !r.urls || r.watch {
+            return usagef("-progress needs -file, -manifest or -url, and no -watch")
+        }
+        total := int64(-1)
+        if r.files {
+            total = totalSize(r.fs.Args())
+        }
+        progress = checksum.NewProgressWriter(nil, r.stderr, total, isTerminal(r.stderr) && !isTerminal(r.stdout))
+        progressOut = progress
+    }
+    sum := r.pathChecksum(progressOut)
+    if r.expected != "" && r.multihashWant == nil && !validChecksum(r.expected, r.enc) {
+        return usagef("-verify %q is not a %s checksum", r.expected, r.enc)
+    }
+    if r.verifying() && sum != nil && r.fs.NArg() != 1 {
+        return usagef("-verify with -file, -dir, -archive or -url takes exactly one path")
+    }
+    if r.watch {
+        return r.runWatch(sum)
+    }
+    if sum == nil {
+        return r.runParts()
+    }
+    if !r.verifying() {
+        return r.runPaths(sum, progress)
+    }
+
+    digest, in, err := sum(r.fs.Arg(0))
+    r.runlog.add(in, "", err)
+    if progress != nil {
+        progress.Done()
+    }
+    if err != nil {
+        return err
+    }
+    if code := r.cache.finish(0, r.verbose, r.stderr); code != 0 {
+        return status(code)
+    }
+    return r.printDigest(digest)
+}
+
+// pathChecksum returns how the selected path mode hashes one path, or nil
+// for argument parts. With -v each result is also traced.
+func (r *hashRun) pathChecksum(progress io.Writer) func(path string) ([]byte, input, error) {
+    var sum func(path string) ([]byte, input, error)
+    switch {
+    case r.files:
+        r.readOpts.Progress = progress
+        sum = func(path string) ([]byte, input, error) {
+            return r.cache.fileChecksum(r.cfg, path, r.stdin, r.readOpts)
+        }
+    case r.dirs:
+        sum = func(path string) ([]byte, input, error) { return dirChecksum(r.cfg, path, r.treeOpts) }
+    case r.archives:
+        opts := checksum.ArchiveOptions{Modes: r.archiveModes}
+        sum = func(path string) ([]byte, input, error) { return archiveChecksum(r.cfg, path, opts) }
+    case r.urls:
+        client := &http.Client{
+            Timeout: r.timeout,
+            CheckRedirect: func(req *http.Request, via []*http.Request) error {
+                if len(via) > r.maxRedirects {
+                    return fmt.Errorf("stopped after %d redirects", r.maxRedirects)
+                }
+                return nil
+            },
+        }
+        opts := checksum.URLOptions{Client: client, MaxBytes: r.maxSize, Progress: progress}
+        sum = func(url string) ([]byte, input, error) { return urlChecksum(r.cfg, url, opts) }
+    default:
+        return nil
+    }
+    trace := r.trace()
+    if trace == nil {
+        return sum
+    }
+    var mu sync.Mutex
+    return func(path string) ([]byte, input, error) {
+        digest, in, err := sum(path)
+        if err == nil {
+            mu.Lock()
+            traceInput(trace, r.cfg, in, digest)
+            mu.Unlock()
+        }
+        return digest, in, err
+    }
+}
+
+func (r *hashRun) runWatch(sum func(path string) ([]byte, input, error)) error {
+    if sum == nil || r.urls || r.expected != "" || r.jsonOut || r.pollInterval <= 0 || r.debounce < 0 {
+        return usagef("-watch needs -file or -dir, a positive -poll-interval, and no -verify or -json")
+    }
+    return status(watchUntilInterrupted(r.fs.Args(), r.pollInterval, r.debounce, sum, func(path string, digest []byte, err error) {
+        stamp := time.Now().Format(time.RFC3339)
+        if err != nil {
+            fmt.Fprintf(r.stderr, "%s randomtool: %v\n", stamp, err)
+            return
+        }
+        fmt.Fprintf(r.stdout, "%s %s\n", stamp, checksum.FormatManifestLine(r.sumText(digest), path))
+    }))
+}
+
+// runPaths prints one checksum per path, in argument order.
+func (r *hashRun) runPaths(sum func(path string) ([]byte, input, error), progress *checksum.ProgressWriter) error {
+    report := &jsonReport{Algorithm: r.cfg.Name(), Length: r.length}
+    code := 0
+    hashPaths(r.fs.Args(), r.jobs, sum, func(path string, digest []byte, in input, err error) {
+        sum := r.sumText(digest)
+        if err != nil {
+            code = exitIO
+            sum = ""
+        }
+        r.runlog.add(in, sum, err)
+        if r.jsonOut {
+            report.add(in, sum, err)
+            return
+        }
+        if err != nil {
+            fmt.Fprintf(r.stderr, "randomtool: %v\n", err)
+            return
+        }
+        fmt.Fprintln(r.stdout, checksum.FormatManifestLine(sum, path))
+    })
+    if progress != nil {
+        progress.Done()
+    }
+    if r.jsonOut {
+        code = report.write(r.stdout, r.stderr, code)
+    }
+    return status(r.cache.finish(code, r.verbose, r.stderr))
+}
+
+// runParts hashes the arguments, or the records of -input-list, together
+// as framed parts.
+func (r *hashRun) runParts() error {
+    var digest []byte
+    var inputs []input
+    var err error
+    switch rest := r.fs.Args(); {
+    case r.inputList != "" && len(rest) > 0:
+        err = usageError{errors.New("-input-list cannot be combined with arguments")}
+    case r.inputList != "":
+        digest, inputs, err = listChecksum(r.cfg, r.inputList, r.recordSep(), r.stdin, int64(r.maxBytes), r.trace())
+    default:
+        if len(rest) == 0 {
+            rest = []string{"codex", "demo"}
+        }
+        digest, inputs, err = argsChecksum(r.cfg, rest, r.inEnc, r.stdin, int64(r.maxBytes), r.trace())
+    }
+    if errors.As(err, new(usageError)) {
+        return err
+    }
+    for _, in := range inputs {
+        r.runlog.add(in, "", nil)
+    }
+    if r.jsonOut {
+        report := &jsonReport{Algorithm: r.cfg.Name(), Length: r.length}
+        code := 0
+        for i, in := range inputs {
+            var inErr error
+            if err != nil && i == len(inputs)-1 {
+                inErr, code = err, exitCode(err, io.Discard)
+            }
+            report.add(in, "", inErr)
+        }
+        if err == nil {
+            report.Checksum = r.sumText(digest)
+            r.runlog.setChecksum(report.Checksum)
+        }
+        return status(report.write(r.stdout, r.stderr, code))
+    }
+    if err != nil {
+        return err
+    }
+    return r.printDigest(digest)
+}
+
+// printDigest writes the one checksum of a run: as a -stream, as the result
+// of -verify, or as text.
+func (r *hashRun) printDigest(digest []byte) error {
+    r.runlog.setChecksum(r.sumText(digest))
+    if r.streamLen >= 0 {
+        if _, err := io.CopyN(r.stdout, struct{ io.Reader }{checksum.NewStream(digest)}, r.streamLen); err != nil {
+            return err
+        }
+        return nil
+    }
+    if r.verifying() {
+        var ok bool
+        switch {
+        case r.verifyBoth:
+            sums := r.cfg.Split(digest)
+            // Compare both, so the time taken does not tell which failed.
+            okOld := verify(r.verifyOld, sums[0], r.enc, r.length, r.allowPrefix)
+            okNew := verify(r.verifyNew, sums[1], r.enc, r.length, r.allowPrefix)
+            ok = okOld && okNew
+        case r.multihashWant != nil:
+            ok = subtle.ConstantTimeCompare(r.multihashWant, digest) == 1
+        default:
+            ok = verify(r.expected, digest, r.enc, r.length, r.allowPrefix)
+        }
+        if !r.quiet {
+            if ok {
+                fmt.Fprintln(r.stdout, "OK")
+            } else {
+                fmt.Fprintln(r.stdout, "FAILED")
+            }
+        }
+        if !ok {
+            return checksum.ErrVerifyMismatch
+        }
+        return nil
+    }
+    if r.enc.is(checksum.Raw) {
+        io.WriteString(r.stdout, format(digest, r.enc, r.length))
+        return nil
+    }
+    fmt.Fprintln(r.stdout, r.sumText(digest))
+    return nil
+}
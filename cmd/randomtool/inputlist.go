@@ -0,0 +1,60 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// listChecksum computes the framed checksum of the parts listed in the file
+// at path ("-" for standard input), one per sep-terminated record. The list
+// is streamed, so neither the whole list nor a whole record is held in
+// memory. A final separator does not start an empty last part. A non-nil
+// trace receives a line per record. A positive maxBytes fails the checksum
+// with a *checksum.LimitError once the list is longer than maxBytes bytes.
+func listChecksum(cfg checksum.Config, path string, sep byte, stdin io.Reader, maxBytes int64, trace io.Writer) ([]byte, []input, error) {
+    in := input{name: path, kind: "list"}
+    r := stdin
+    if path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            return nil, []input{in}, &checksum.InputError{Path: path, Err: err}
+        }
+        defer f.Close()
+        r = f
+    }
+    if maxBytes > 0 {
+        r = checksum.LimitReader(r, maxBytes, &checksum.LimitError{What: "input list", Limit: maxBytes})
+    }
+
+    w := checksum.NewWriter(cfg)
+    traceParts(w, cfg, trace, func(i int) string { return fmt.Sprintf("list %q record %d", path, i+1) })
+    br := bufio.NewReaderSize(r, 64<<10)
+    partial := false
+    for {
+        chunk, err := br.ReadSlice(sep)
+        switch {
+        case err == nil:
+            w.Write(chunk[:len(chunk)-1])
+            w.EndPart()
+            partial = false
+        case errors.Is(err, bufio.ErrBufferFull):
+            w.Write(chunk)
+            partial = true
+        case err == io.EOF:
+            if len(chunk) > 0 || partial {
+                w.Write(chunk)
+                w.EndPart()
+            }
+            in.bytes += int64(len(chunk))
+            return w.Sum(nil), []input{in}, nil
+        default:
+            return nil, []input{in}, &checksum.InputError{Path: path, Err: err}
+        }
+        in.bytes += int64(len(chunk))
+    }
+}
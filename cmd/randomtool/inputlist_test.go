@@ -0,0 +1,35 @@
+package main
+
+import (
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestInputList(t *testing.T) {
+    dir := t.TempDir()
+    long := strings.Repeat("y", 100<<10)
+    for _, tt := range []struct {
+        list  string
+        flags []string
+        parts []string
+    }{
+        {"a\nb\nc\n", nil, []string{"a", "b", "c"}},
+        // A trailing separator does not add an empty last part.
+        {"a\nb\nc", nil, []string{"a", "b", "c"}},
+        {"a\n\nb\n", nil, []string{"a", "", "b"}},
+        {"\n", nil, []string{""}},
+        {"x\n" + long + "\n", nil, []string{"x", long}},
+        {"a\x00b\nc\x00", []string{"-0"}, []string{"a", "b\nc"}},
+        {"a\x00b\nc", []string{"-0"}, []string{"a", "b\nc"}},
+    } {
+        list := writeFile(t, dir, "list", tt.list)
+        want := run(t, "", append([]string{"-length", "0", "--"}, tt.parts...)...).stdout
+        run(t, "", append(tt.flags, "-length", "0", "-input-list", list)...).want(t, 0, want)
+        run(t, tt.list, append(tt.flags, "-length", "0", "-input-list", "-")...).want(t, 0, want)
+    }
+    if r := run(t, "", "-input-list", writeFile(t, dir, "empty", "")); r.code != 0 || r.stdout == run(t, "", "").stdout {
+        t.Errorf("an empty list should have no parts, not one empty part: %+v", r)
+    }
+    run(t, "", "-input-list", filepath.Join(dir, "missing")).want(t, 3, "")
+}
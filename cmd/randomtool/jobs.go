@@ -0,0 +1,38 @@
+package main
+
+// hashPaths applies sum to every path on up to jobs goroutines and hands the
+// results to emit in the original order, as soon as each one and all paths
+// before it are done. A failing path does not stop the others.
+func hashPaths(paths []string, jobs int, sum func(path string) ([]byte, input, error), emit func(path string, digest []byte, in input, err error)) {
+    type result struct {
+        digest []byte
+        in     input
+        err    error
+        done   chan struct{}
+    }
+    results := make([]result, len(paths))
+    for i := range results {
+        results[i].done = make(chan struct{})
+    }
+
+    next := make(chan int)
+    go func() {
+        for i := range paths {
+            next <- i
+        }
+        close(next)
+    }()
+    for range min(jobs, len(paths)) {
+        go func() {
+            for i := range next {
+                results[i].digest, results[i].in, results[i].err = sum(paths[i])
+                close(results[i].done)
+            }
+        }()
+    }
+
+    for i, path := range paths {
+        <-results[i].done
+        emit(path, results[i].digest, results[i].in, results[i].err)
+    }
+}
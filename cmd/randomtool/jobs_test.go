@@ -0,0 +1,76 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "runtime"
+    "slices"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+func TestHashPaths(t *testing.T) {
+    paths := []string{"a", "b", "bad", "c", "d", "e"}
+    for _, jobs := range []int{1, 3, 16} {
+        var got []string
+        hashPaths(paths, jobs, func(path string) ([]byte, input, error) {
+            // Earlier paths finish last, so results arrive out of order.
+            time.Sleep(time.Duration(len(paths)-slices.Index(paths, path)) * time.Millisecond)
+            if path == "bad" {
+                return nil, input{}, errors.New("unreadable")
+            }
+            return []byte(path), input{name: path, kind: "file"}, nil
+        }, func(path string, digest []byte, in input, err error) {
+            got = append(got, fmt.Sprintf("%s=%s/%s/%v", path, digest, in.name, err))
+        })
+        want := []string{"a=a/a/<nil>", "b=b/b/<nil>", "bad=//unreadable", "c=c/c/<nil>", "d=d/d/<nil>", "e=e/e/<nil>"}
+        if !slices.Equal(got, want) {
+            t.Errorf("-jobs %d: got %q", jobs, got)
+        }
+    }
+    hashPaths(nil, 4, nil, func(string, []byte, input, error) { t.Error("emit called without paths") })
+}
+
+func TestJobs(t *testing.T) {
+    dir := t.TempDir()
+    var paths []string
+    for i := range 20 {
+        paths = append(paths, writeFile(t, dir, fmt.Sprint(i), strings.Repeat("x", i)))
+    }
+    serial := run(t, "", append([]string{"-file", "-jobs", "1"}, paths...)...)
+    serial.want(t, 0, "*")
+    run(t, "", append([]string{"-file", "-jobs", "8"}, paths...)...).want(t, 0, serial.stdout)
+    run(t, "", "-file", "-jobs", "0", paths[0]).want(t, 2, "")
+}
+
+func BenchmarkHashPaths(b *testing.B) {
+    dir := b.TempDir()
+    data := make([]byte, 1<<20)
+    var paths []string
+    for i := range 64 {
+        p := filepath.Join(dir, fmt.Sprint(i))
+        if err := os.WriteFile(p, data, 0o644); err != nil {
+            b.Fatal(err)
+        }
+        paths = append(paths, p)
+    }
+    cfg := checksum.Config{Algorithm: checksum.SHA256}
+    sum := func(path string) ([]byte, input, error) {
+        digest, err := cfg.SumFile(path)
+        return digest, input{name: path, kind: "file"}, err
+    }
+    // The second run uses the default of -jobs, at least 4.
+    for _, jobs := range []int{1, max(4, runtime.NumCPU())} {
+        b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+            b.SetBytes(int64(len(paths) * len(data)))
+            for b.Loop() {
+                hashPaths(paths, jobs, sum, func(string, []byte, input, error) {})
+            }
+        })
+    }
+}
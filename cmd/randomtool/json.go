@@ -0,0 +1,49 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+)
+
+// jsonReport is the document printed by -json. Field names are part of the
+// tool's interface and must not change.
+type jsonReport struct {
+    Algorithm string `json:"algorithm"`
+    Length    int    `json:"length"`
+    // Checksum is the combined checksum of all arguments; it is omitted in
+    // -file and -dir mode, where every entry carries its own.
+    Checksum string      `json:"checksum,omitempty"`
+    Entries  []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+    Input    string `json:"input"`
+    Type     string `json:"type"`
+    Checksum string `json:"checksum,omitempty"`
+    Bytes    int64  `json:"bytes"`
+    Error    string `json:"error,omitempty"`
+}
+
+func (r *jsonReport) add(in input, sum string, err error) {
+    e := jsonEntry{Input: in.name, Type: in.kind, Checksum: sum, Bytes: in.bytes}
+    if err != nil {
+        e.Error = err.Error()
+    }
+    r.Entries = append(r.Entries, e)
+}
+
+// write prints the report and passes status through, unless the report
+// itself cannot be written.
+func (r *jsonReport) write(stdout, stderr io.Writer, status int) int {
+    if r.Entries == nil {
+        r.Entries = []jsonEntry{}
+    }
+    enc := json.NewEncoder(stdout)
+    enc.SetIndent("", "  ")
+    if err := enc.Encode(r); err != nil {
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        return exitIO
+    }
+    return status
+}
@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+// The -json field names are parsed by CI scripts; these documents pin them.
+func TestJSON(t *testing.T) {
+    dir := t.TempDir()
+    writeFile(t, dir, "a", "hi\n")
+    writeFile(t, dir, "d/z", "z\n")
+
+    runIn(t, dir, "", "-json", "x", "-").want(t, 0, `{
+  "algorithm": "sha1",
+  "length": 12,
+  "checksum": "8a70f0f143d2",
+  "entries": [
+    {
+      "input": "x",
+      "type": "arg",
+      "bytes": 1
+    },
+    {
+      "input": "-",
+      "type": "stdin",
+      "bytes": 0
+    }
+  ]
+}
+`)
+
+    r := runIn(t, dir, "", "-json", "-file", "a", "missing")
+    r.want(t, 3, `{
+  "algorithm": "sha1",
+  "length": 12,
+  "entries": [
+    {
+      "input": "a",
+      "type": "file",
+      "checksum": "55ca6286e3e4",
+      "bytes": 3
+    },
+    {
+      "input": "missing",
+      "type": "file",
+      "bytes": 0,
+      "error": "open missing: no such file or directory"
+    }
+  ]
+}
+`)
+    if r.stderr != "" {
+        t.Errorf("-json wrote to stderr: %q", r.stderr)
+    }
+
+    runIn(t, dir, "", "-json", "-dir", "d").want(t, 0, `{
+  "algorithm": "sha1",
+  "length": 12,
+  "entries": [
+    {
+      "input": "d",
+      "type": "dir",
+      "checksum": "557ca0837348",
+      "bytes": 2
+    }
+  ]
+}
+`)
+
+    run(t, "", "-json", "-algo", "sha256", "-length", "0", "-encoding", "base64", "x").want(t, 0, `{
+  "algorithm": "sha256",
+  "length": 0,
+  "checksum": "3np/e/R88SGasSFlI3K6wm94FGO2qp+gX0YiaIQfOPM=",
+  "entries": [
+    {
+      "input": "x",
+      "type": "arg",
+      "bytes": 1
+    }
+  ]
+}
+`)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+    "bufio"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// hashLines prints the checksum of every sep-terminated record read from r,
+// followed by a tab and the record itself unless noEcho is set. Each record
+// is hashed as a single part, so its checksum matches passing the record as
+// the only argument. Output records end in sep too. Records of any length
+// are handled; the hash state and buffers are reused from one record to
+// the next.
+func hashLines(cfg checksum.Config, r io.Reader, sep byte, noEcho bool, enc textEncoding, length int, stdout, stderr io.Writer) int {
+    br := bufio.NewReaderSize(r, 64<<10)
+    bw := bufio.NewWriterSize(stdout, 64<<10)
+    w := checksum.NewWriter(cfg)
+    var record, digest, out []byte
+    for {
+        chunk, err := br.ReadSlice(sep)
+        if errors.Is(err, bufio.ErrBufferFull) {
+            w.Write(chunk)
+            if !noEcho {
+                record = append(record, chunk...)
+            }
+            continue
+        }
+        if err != nil && err != io.EOF {
+            bw.Flush()
+            fmt.Fprintf(stderr, "randomtool: reading input: %v\n", err)
+            return exitIO
+        }
+        last := err == io.EOF
+        if last && len(chunk) == 0 && len(record) == 0 && !w.Pending() {
+            break
+        }
+        if !last {
+            chunk = chunk[:len(chunk)-1]
+        }
+        w.Write(chunk)
+        digest = w.Sum(digest[:0])
+        w.Reset()
+
+        out = appendFormat(out[:0], digest, enc, length)
+        if !noEcho {
+            out = append(out, '\t')
+            out = append(out, record...)
+            out = append(out, chunk...)
+            record = record[:0]
+        }
+        out = append(out, sep)
+        if _, err := bw.Write(out); err != nil {
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            return exitIO
+        }
+        if last {
+            break
+        }
+    }
+    if err := bw.Flush(); err != nil {
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        return exitIO
+    }
+    return exitOK
+}
+
+// appendFormat is format without the intermediate string for hex output.
+func appendFormat(dst, digest []byte, enc textEncoding, n int) []byte {
+    if !enc.is(checksum.Hex) {
+        return append(dst, format(digest, enc, n)...)
+    }
+    start := len(dst)
+    dst = hex.AppendEncode(dst, digest)
+    if n > 0 && start+n < len(dst) {
+        dst = dst[:start+n]
+    }
+    return dst
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestLines(t *testing.T) {
+    a, b := run(t, "", "a").stdout, run(t, "", "b").stdout
+    run(t, "a\nb\n", "-lines").want(t, 0, strings.TrimSuffix(a, "\n")+"\ta\n"+strings.TrimSuffix(b, "\n")+"\tb\n")
+    run(t, "a\nb", "-lines", "-no-echo").want(t, 0, a+b)
+    run(t, "a\x00b\x00", "-lines", "-0", "-no-echo").want(t, 0, strings.ReplaceAll(a+b, "\n", "\x00"))
+
+    // Longer than the 64KiB a bufio.Scanner allows by default.
+    long := strings.Repeat("x", 200<<10)
+    run(t, long+"\n", "-lines", "-no-echo").want(t, 0, run(t, long, "-").stdout)
+}
+
+func TestLinesRejectsVerify(t *testing.T) {
+    for _, args := range [][]string{
+        {"-verify", "000000000000", "-lines"},
+        {"-also", "sha256", "-verify-old", "0", "-verify-new", "0", "-lines"},
+    } {
+        run(t, "hello\n", args...).want(t, 2, "")
+    }
+}
@@ -1,25 +1,487 @@
 package main
 
 import (
-    "crypto/sha1"
+    "bytes"
+    "context"
+    "crypto/subtle"
     "encoding/hex"
+    "errors"
+    "flag"
     "fmt"
+    "io"
+    "io/fs"
     "os"
+    "slices"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
 )
 
-func checksum(parts ...string) string {
-    h := sha1.New()
-    for _, part := range parts {
-        h.Write([]byte(part))
+// input describes one hashed argument, file or directory.
+type input struct {
+    name  string
+    kind  string // "arg", "stdin", "list", "file", "dir", "archive" or "url"
+    bytes int64
+}
+
+// argsChecksum computes the framed checksum of command-line arguments. A "-"
+// argument stands for standard input at that position. Every other argument
+// is first decoded from enc, which must be Hex, Base64 or Raw; standard input
+// is always hashed as is. A non-nil trace receives a line per part. A
+// positive maxBytes fails the checksum with a *checksum.LimitError once the
+// parts total more than maxBytes bytes.
+func argsChecksum(cfg checksum.Config, args []string, enc checksum.Encoding, stdin io.Reader, maxBytes int64, trace io.Writer) ([]byte, []input, error) {
+    decoded := make([][]byte, len(args))
+    for i, arg := range args {
+        if arg == "-" {
+            if slices.Contains(args[i+1:], "-") {
+                return nil, nil, usageError{errors.New("standard input (-) given more than once")}
+            }
+            continue
+        }
+        b, err := enc.Decode(arg)
+        if err != nil {
+            return nil, nil, usageError{fmt.Errorf("argument %d (%s) is not valid %s: %v", i+1, quoteArg(arg), enc, err)}
+        }
+        decoded[i] = b
+    }
+    w := checksum.NewWriter(cfg)
+    traceParts(w, cfg, trace, func(i int) string {
+        if args[i] == "-" {
+            return "stdin"
+        }
+        return "arg " + quoteArg(args[i])
+    })
+    inputs := make([]input, len(args))
+    var total int64
+    for i, arg := range args {
+        in := &inputs[i]
+        *in = input{name: arg, kind: "arg"}
+        var r io.Reader = bytes.NewReader(decoded[i])
+        if arg == "-" {
+            in.kind, r = "stdin", stdin
+        }
+        if maxBytes > 0 {
+            r = checksum.LimitReader(r, maxBytes-total, &checksum.LimitError{What: "input", Limit: maxBytes})
+        }
+        n, err := w.ReadPart(r)
+        in.bytes = n
+        total += n
+        if err != nil {
+            name := fmt.Sprintf("argument %d (%s)", i+1, quoteArg(arg))
+            if arg == "-" {
+                name = "standard input"
+            }
+            return nil, inputs[:i+1], &checksum.InputError{Path: name, Err: err}
+        }
     }
-    return hex.EncodeToString(h.Sum(nil))[:12]
+    return w.Sum(nil), inputs, nil
 }
 
-func main() {
-    args := os.Args[1:]
-    if len(args) == 0 {
-        fmt.Println(checksum("codex", "demo"))
+// quoteArg quotes an argument for a message, eliding all but the start of
+// a long one so that a huge argument does not flood the terminal.
+func quoteArg(arg string) string {
+    const max = 64
+    if len(arg) <= max {
+        return strconv.Quote(arg)
+    }
+    return fmt.Sprintf("%q... (%d bytes)", arg[:max], len(arg))
+}
+
+// Exit statuses. Scripts rely on them, so they must not change.
+const (
+    exitOK       = 0
+    exitMismatch = 1 // a checksum, signature or comparison did not match
+    exitUsage    = 2 // bad flags or arguments, or -diff could not read a path
+    exitIO       = 3 // an input could not be read, or output written
+    exitInternal = 4 // anything else
+    exitLimit    = 5 // an input exceeded -max-bytes or -max-line
+)
+
+// usageError marks errors caused by how the tool was invoked.
+type usageError struct{ error }
+
+func usagef(format string, args ...any) error {
+    return usageError{fmt.Errorf(format, args...)}
+}
+
+// exitStatus is returned by code that has already reported its outcome and
+// only needs the process to exit with the given status.
+type exitStatus int
+
+func (s exitStatus) Error() string { return fmt.Sprintf("exit status %d", int(s)) }
+
+// status converts an exit status to an error, nil for 0.
+func status(code int) error {
+    if code == 0 {
+        return nil
+    }
+    return exitStatus(code)
+}
+
+// parseError passes on flag.ErrHelp and otherwise reports a flag parsing
+// failure, which the flag package has already printed, as status 2.
+func parseError(err error) error {
+    if errors.Is(err, flag.ErrHelp) {
+        return err
+    }
+    return exitStatus(exitUsage)
+}
+
+// exitCode prints err and maps it to the process exit status. An
+// exitStatus and a bare checksum.ErrVerifyMismatch have already been
+// reported on stdout and are not printed.
+func exitCode(err error, stderr io.Writer) int {
+    var st exitStatus
+    switch {
+    case err == nil, errors.Is(err, flag.ErrHelp):
+        return exitOK
+    case errors.As(err, &st):
+        return int(st)
+    case err == checksum.ErrVerifyMismatch:
+        return exitMismatch
+    }
+    fmt.Fprintf(stderr, "randomtool: %v\n", err)
+    switch {
+    case errors.As(err, new(usageError)):
+        return exitUsage
+    case errors.As(err, new(*checksum.LimitError)):
+        return exitLimit
+    case errors.As(err, new(*checksum.InputError)), errors.As(err, new(*fs.PathError)):
+        return exitIO
+    case errors.Is(err, checksum.ErrVerifyMismatch):
+        return exitMismatch
+    }
+    return exitInternal
+}
+
+// fileChecksum hashes the raw contents of path, with no part framing, so the
+// result matches what sha1sum and friends print. "-" reads standard input.
+// opts selects how the contents are read and where progress is reported.
+func fileChecksum(cfg checksum.Config, path string, stdin io.Reader, opts checksum.ReadOptions) ([]byte, input, error) {
+    in := input{name: path, kind: "file"}
+    r := stdin
+    if path == "-" {
+        in.kind = "stdin"
+    } else {
+        f, err := os.Open(path)
+        if err != nil {
+            return nil, in, err
+        }
+        defer f.Close()
+        r = f
+    }
+    digest, n, err := cfg.SumReaderWith(r, opts)
+    in.bytes = n
+    if err != nil {
+        name := path
+        if path == "-" {
+            name = "standard input"
+        }
+        return nil, in, &checksum.InputError{Path: name, Err: err}
+    }
+    return digest, in, nil
+}
+
+// traceParts has w describe each part it closes on trace, unless trace is
+// nil. describe names the part with the given index.
+func traceParts(w *checksum.Writer, cfg checksum.Config, trace io.Writer, describe func(i int) string) {
+    if trace == nil {
         return
     }
-    fmt.Println(checksum(args...))
+    w.Trace(func(p checksum.PartTrace) {
+        fmt.Fprintf(trace, "randomtool: part %d: %s, %d bytes, %s %x\n", p.Index, describe(p.Index), p.Bytes, cfg.Algorithm, p.Digest)
+    })
+}
+
+// traceInput describes a path input and its full checksum for -v. Files
+// also get their size and modification time.
+func traceInput(w io.Writer, cfg checksum.Config, in input, digest []byte) {
+    detail := fmt.Sprintf("%d bytes", in.bytes)
+    if in.kind == "file" {
+        if info, err := os.Stat(in.name); err == nil {
+            detail = fmt.Sprintf("%d bytes, mtime %s", info.Size(), info.ModTime().Format(time.RFC3339Nano))
+        }
+    }
+    fmt.Fprintf(w, "randomtool: %s %q: %s, %s %x\n", in.kind, in.name, detail, cfg.Name(), digest)
+}
+
+// urlChecksum hashes the raw body downloaded from url.
+func urlChecksum(cfg checksum.Config, url string, opts checksum.URLOptions) ([]byte, input, error) {
+    in := input{name: url, kind: "url"}
+    body, err := checksum.OpenURL(context.Background(), url, opts)
+    if err != nil {
+        return nil, in, err
+    }
+    defer body.Close()
+    digest, n, err := cfg.SumReaderWith(body, checksum.ReadOptions{})
+    in.bytes = n
+    if err != nil {
+        return nil, in, &checksum.InputError{Path: url, Err: err}
+    }
+    return digest, in, nil
+}
+
+// archiveChecksum is the tree hash of the contents of the archive at path.
+func archiveChecksum(cfg checksum.Config, path string, opts checksum.ArchiveOptions) ([]byte, input, error) {
+    in := input{name: path, kind: "archive"}
+    entries, err := cfg.Archive(path, opts)
+    if err != nil {
+        return nil, in, err
+    }
+    for _, e := range entries {
+        in.bytes += e.Size
+    }
+    return cfg.TreeSum(entries), in, nil
+}
+
+// dirChecksum is the tree hash of the directory at path.
+func dirChecksum(cfg checksum.Config, path string, opts checksum.TreeOptions) ([]byte, input, error) {
+    in := input{name: path, kind: "dir"}
+    entries, err := cfg.Tree(path, opts)
+    if err != nil {
+        return nil, in, err
+    }
+    for _, e := range entries {
+        in.bytes += e.Size
+    }
+    return cfg.TreeSum(entries), in, nil
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+    *l = append(*l, s)
+    return nil
+}
+
+// resolveSalt returns the salt selected by -salt: nil when unset, saltBytes
+// random bytes for "auto", or the decoded hex value. A -verify value in
+// "salt:checksum" form supplies the salt itself and is reduced to the
+// checksum.
+func resolveSalt(flagValue string, saltBytes int, expected *string) ([]byte, error) {
+    if salt, sum, ok := strings.Cut(*expected, ":"); ok {
+        if flagValue != "" {
+            return nil, errors.New("-salt cannot be combined with a salt:checksum -verify value")
+        }
+        if sum == "" {
+            return nil, fmt.Errorf("-verify %q has no checksum after the salt", *expected)
+        }
+        *expected = sum
+        flagValue = salt
+    }
+    switch flagValue {
+    case "":
+        return nil, nil
+    case "auto":
+        if saltBytes < 1 || saltBytes > maxRandomBytes {
+            return nil, fmt.Errorf("-salt-bytes must be between 1 and %d", maxRandomBytes)
+        }
+        return randomBytes(saltBytes)
+    }
+    salt, err := hex.DecodeString(flagValue)
+    if err != nil || len(salt) == 0 {
+        return nil, fmt.Errorf("salt %q is not a hex value", flagValue)
+    }
+    return salt, nil
+}
+
+// resolveLength checks a requested output length against the digest size of
+// cfg and applies the defaults: when the length was not given explicitly it
+// is halved for byte-counting encodings, keeping the same 48 bits as 12 hex
+// characters, and dropped to 0 for digests shorter than that.
+func resolveLength(cfg checksum.Config, enc textEncoding, n int, explicit bool) (int, error) {
+    max := cfg.Size()
+    if enc.is(checksum.Hex) {
+        max *= 2
+    } else if !explicit {
+        n /= 2
+    }
+    if !explicit && n > max {
+        n = 0
+    }
+    if n < 0 || n > max {
+        return 0, fmt.Errorf("%d out of range for %s with %s encoding (0-%d)", n, cfg.Name(), enc, max)
+    }
+    return n, nil
+}
+
+// format renders digest in enc, truncated to n hex characters or, for other
+// encodings, to n bytes before encoding. n == 0 keeps the whole digest.
+func format(digest []byte, enc textEncoding, n int) string {
+    if enc.is(checksum.Hex) {
+        return truncate(hex.EncodeToString(digest), n)
+    }
+    if n > 0 && n < len(digest) {
+        digest = digest[:n]
+    }
+    return enc.Encode(digest)
+}
+
+// digestBytes is the number of digest bytes format encodes for a byte
+// length n.
+func digestBytes(cfg checksum.Config, n int) int {
+    if n > 0 && n < cfg.Size() {
+        return n
+    }
+    return cfg.Size()
+}
+
+// verify reports whether expected is digest as it would be printed at
+// length n (hex characters, or bytes for other encodings) or in full. Any
+// other truncation is a mismatch unless allowPrefix is set, in which case
+// every non-empty leading part matches. Hex is compared in either case;
+// other encodings are compared as decoded bytes. The comparison runs in
+// constant time for a given expected length.
+func verify(expected string, digest []byte, enc textEncoding, n int, allowPrefix bool) bool {
+    if enc.is(checksum.Hex) {
+        full := hex.EncodeToString(digest)
+        if allowPrefix {
+            return checksum.EqualPrefix(expected, full)
+        }
+        return checksum.Equal(expected, full) || checksum.Equal(expected, truncate(full, n))
+    }
+    want, err := enc.Decode(expected)
+    if err != nil || len(want) == 0 || len(want) > len(digest) {
+        return false
+    }
+    if !allowPrefix && len(want) != len(digest) && len(want) != n {
+        return false
+    }
+    return subtle.ConstantTimeCompare(want, digest[:len(want)]) == 1
+}
+
+// multihashMatcher accepts multihash manifest entries, hashing each file
+// with the algorithm the entry names.
+func multihashMatcher(cfg checksum.Config) sumMatcher {
+    return func(sum string) (checksum.Config, func([]byte) bool, error) {
+        alg, want, err := checksum.DecodeMultihash(sum)
+        if err != nil {
+            return cfg, nil, err
+        }
+        cfg.Algorithm = alg
+        return cfg, func(digest []byte) bool { return subtle.ConstantTimeCompare(want, digest) == 1 }, nil
+    }
+}
+
+// validChecksum reports whether s can be compared by verify.
+func validChecksum(s string, enc textEncoding) bool {
+    if enc.is(checksum.Hex) {
+        s = padHex(s)
+    }
+    _, err := enc.Decode(s)
+    return err == nil
+}
+
+// padHex makes an odd-length hex string decodable so it can be validated.
+func padHex(s string) string {
+    if len(s)%2 == 1 {
+        return s + "0"
+    }
+    return s
+}
+
+// hmacKey returns the key from whichever of the -hmac-key flags was given,
+// or nil if none was. The key file is used byte for byte, so a key written
+// with echo includes its trailing newline. An empty key is refused: it would
+// make the HMAC no more secret than a plain checksum.
+func hmacKey(fs *flag.FlagSet) ([]byte, error) {
+    var sources []string
+    for _, name := range []string{"hmac-key", "hmac-key-file", "hmac-key-env"} {
+        if flagSet(fs, name) {
+            sources = append(sources, name)
+        }
+    }
+    switch len(sources) {
+    case 0:
+        return nil, nil
+    case 1:
+    default:
+        return nil, usagef("only one of -%s may be given", strings.Join(sources, ", -"))
+    }
+
+    value := fs.Lookup(sources[0]).Value.String()
+    switch sources[0] {
+    case "hmac-key-file":
+        key, err := os.ReadFile(value)
+        if err == nil && len(key) == 0 {
+            err = &checksum.InputError{Path: value, Err: errors.New("HMAC key file is empty")}
+        }
+        return key, err
+    case "hmac-key-env":
+        key, ok := os.LookupEnv(value)
+        if !ok {
+            return nil, usagef("environment variable %s is not set", value)
+        }
+        if key == "" {
+            return nil, usagef("environment variable %s is empty", value)
+        }
+        return []byte(key), nil
+    }
+    if value == "" {
+        return nil, usagef("-hmac-key must not be empty")
+    }
+    return []byte(value), nil
+}
+
+// totalSize is the combined size of the regular files at paths, or -1 if
+// any of them is standard input, not a regular file, or cannot be read.
+func totalSize(paths []string) int64 {
+    var total int64
+    for _, path := range paths {
+        info, err := os.Stat(path)
+        if path == "-" || err != nil || !info.Mode().IsRegular() {
+            return -1
+        }
+        total += info.Size()
+    }
+    return total
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+    f, ok := w.(*os.File)
+    if !ok {
+        return false
+    }
+    info, err := f.Stat()
+    return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func countTrue(bs ...bool) int {
+    n := 0
+    for _, b := range bs {
+        if b {
+            n++
+        }
+    }
+    return n
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+    found := false
+    fs.Visit(func(f *flag.Flag) {
+        if f.Name == name {
+            found = true
+        }
+    })
+    return found
+}
+
+// truncate shortens sum to n characters; 0 keeps it whole.
+func truncate(sum string, n int) string {
+    if n > 0 && n < len(sum) {
+        return sum[:n]
+    }
+    return sum
+}
+
+func main() {
+    os.Exit(exitCode(dispatch(os.Args[1:], os.Stdin, os.Stdout, os.Stderr), os.Stderr))
 }
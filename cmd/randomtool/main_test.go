@@ -0,0 +1,653 @@
+package main
+
+import (
+    "bytes"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "regexp"
+    "runtime"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// toolPath is the binary TestMain builds for the integration tests.
+var toolPath string
+
+func TestMain(m *testing.M) {
+    dir, err := os.MkdirTemp("", "randomtool-test")
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+    toolPath = filepath.Join(dir, "randomtool")
+    if runtime.GOOS == "windows" {
+        toolPath += ".exe"
+    }
+    if out, err := exec.Command("go", "build", "-o", toolPath, ".").CombinedOutput(); err != nil {
+        fmt.Fprintf(os.Stderr, "building randomtool: %v\n%s", err, out)
+        os.Exit(1)
+    }
+    code := m.Run()
+    os.RemoveAll(dir)
+    os.Exit(code)
+}
+
+type result struct {
+    stdout, stderr string
+    code           int
+}
+
+// runIn executes the built binary with args and stdin in dir, or the test's
+// working directory if dir is "".
+func runIn(t *testing.T, dir, stdin string, args ...string) result {
+    t.Helper()
+    cmd := exec.Command(toolPath, args...)
+    cmd.Dir = dir
+    cmd.Stdin = strings.NewReader(stdin)
+    var stdout, stderr bytes.Buffer
+    cmd.Stdout, cmd.Stderr = &stdout, &stderr
+    err := cmd.Run()
+    var exit *exec.ExitError
+    if err != nil && !errors.As(err, &exit) {
+        t.Fatalf("running randomtool %q: %v", args, err)
+    }
+    return result{stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()}
+}
+
+func run(t *testing.T, stdin string, args ...string) result {
+    t.Helper()
+    return runIn(t, "", stdin, args...)
+}
+
+// want fails the test unless r exited with code and printed stdout, when
+// stdout is not "*".
+func (r result) want(t *testing.T, code int, stdout string) {
+    t.Helper()
+    if r.code != code || stdout != "*" && r.stdout != stdout {
+        t.Errorf("got exit %d, stdout %q, stderr %q; want exit %d, stdout %q", r.code, r.stdout, r.stderr, code, stdout)
+    }
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+    t.Helper()
+    p := filepath.Join(dir, name)
+    if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+func TestHMACKeyFile(t *testing.T) {
+    dir := t.TempDir()
+    bare := writeFile(t, dir, "bare", "secret")
+    newline := writeFile(t, dir, "newline", "secret\n")
+    withKey := run(t, "", "-hmac-key", "secret", "x")
+    withKey.want(t, 0, "*")
+
+    // The file is the key byte for byte: a trailing newline is not
+    // stripped, so it gives a different HMAC.
+    run(t, "", "-hmac-key-file", bare, "x").want(t, 0, withKey.stdout)
+    r := run(t, "", "-hmac-key-file", newline, "x")
+    if r.code != 0 || r.stdout == withKey.stdout {
+        t.Errorf("a key file ending in a newline: %+v, want an HMAC other than %q", r, withKey.stdout)
+    }
+    run(t, "", "-hmac-key", "secret\n", "x").want(t, 0, r.stdout)
+    t.Setenv("RANDOMTOOL_TEST_KEY", "secret\n")
+    run(t, "", "-hmac-key-env", "RANDOMTOOL_TEST_KEY", "x").want(t, 0, r.stdout)
+    run(t, "", "-hmac-key-file", newline, "-file", newline).want(t, 0, "*")
+
+    empty := writeFile(t, dir, "empty", "")
+    r = run(t, "", "-hmac-key-file", empty, "x")
+    r.want(t, 3, "")
+    if !strings.Contains(r.stderr, empty+": HMAC key file is empty") {
+        t.Errorf("an empty key file: stderr %q", r.stderr)
+    }
+    run(t, "", "-hmac-key", "", "x").want(t, 2, "")
+    t.Setenv("RANDOMTOOL_TEST_KEY", "")
+    run(t, "", "-hmac-key-env", "RANDOMTOOL_TEST_KEY", "x").want(t, 2, "")
+
+    missing := filepath.Join(dir, "missing")
+    r = run(t, "", "-hmac-key-file", missing, "x")
+    r.want(t, 3, "")
+    if !strings.Contains(r.stderr, missing) {
+        t.Errorf("a missing key file: stderr %q does not name it", r.stderr)
+    }
+    run(t, "", "-hmac-key-file", dir, "x").want(t, 3, "")
+    run(t, "", "-hmac-key-file", bare, "-hmac-key", "secret", "x").want(t, 2, "")
+}
+
+func TestLegacy(t *testing.T) {
+    run(t, "", "-legacy", "ab", "c").want(t, 0, "a9993e364706\n")
+    run(t, "", "-legacy", "a", "bc").want(t, 0, "a9993e364706\n")
+    run(t, "", "ab", "c").want(t, 0, "2a4df762ee2e\n")
+    run(t, "", "a", "bc").want(t, 0, "0667d744d497\n")
+    run(t, "", "", "").want(t, 0, "e129f27c5103\n")
+}
+
+func TestAlgo(t *testing.T) {
+    for alg, want := range map[string]string{
+        "sha1":        "dcb7a8333405",
+        "sha256":      "e1a225c6dcc0",
+        "sha512":      "84136ded3e81",
+        "blake2b-256": "b1fab9515395",
+        "crc32c":      "16ef33c4",
+        "fnv1a-64":    "ee4f898b8469",
+        "xxhash64":    "dd756fdfe3ee",
+    } {
+        run(t, "", "-algo", alg, "a", "b", "c").want(t, 0, want+"\n")
+    }
+    r := run(t, "", "-algo", "md5", "a")
+    r.want(t, 2, "")
+    if !strings.Contains(r.stderr, "sha256") {
+        t.Errorf("-algo md5 does not list the algorithms: %q", r.stderr)
+    }
+}
+
+func TestVerbose(t *testing.T) {
+    plain := run(t, "hi\n", "a", "bb", "-")
+    r := run(t, "hi\n", "-v", "a", "bb", "-")
+    r.want(t, 0, plain.stdout)
+    want := `randomtool: part 0: arg "a", 1 bytes, sha1 86f7e437faa5a7fce15d1ddcb9eaeaea377667b8
+randomtool: part 1: arg "bb", 2 bytes, sha1 9a900f538965a426994e1e90600920aff0b4e8d2
+randomtool: part 2: stdin, 3 bytes, sha1 55ca6286e3e4f4fba5d0448333fa99fc5a404a73
+`
+    if r.stderr != want {
+        t.Errorf("-v: stderr %q, want %q", r.stderr, want)
+    }
+
+    dir := t.TempDir()
+    cache := filepath.Join(dir, "cache.jsonl")
+    a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+    writeOld(t, a, "hello\n", oldTime)
+    writeOld(t, b, "bb", oldTime)
+    mtime := oldTime.Local().Format(time.RFC3339Nano)
+    trace := fmt.Sprintf("randomtool: file %q: 6 bytes, mtime %s, sha1 %s\nrandomtool: file %q: 2 bytes, mtime %s, sha1 %s\n", a, mtime, rawSHA1("hello\n"), b, mtime, rawSHA1("bb"))
+    plain = run(t, "", "-file", a, b)
+    for _, stats := range []string{"0 hits, 2 misses, 0 stale", "2 hits, 0 misses, 0 stale"} {
+        r := run(t, "", "-v", "-file", "-cache", cache, a, b)
+        r.want(t, 0, plain.stdout)
+        if want := trace + "randomtool: cache: " + stats + "\n"; r.stderr != want {
+            t.Errorf("-v -cache: stderr %q, want %q", r.stderr, want)
+        }
+    }
+}
+
+func TestStdin(t *testing.T) {
+    sum := func(parts ...string) string {
+        var p [][]byte
+        for _, s := range parts {
+            p = append(p, []byte(s))
+        }
+        return hex.EncodeToString(checksum.Sum(p...))[:12] + "\n"
+    }
+    raw := "\x00\xff\n\r\x00"
+    // Longer than any single read, so it can only match if all of it is
+    // streamed into the hash.
+    large := strings.Repeat("0123456789abcdef", 100<<10)
+    for _, tt := range []struct {
+        stdin string
+        args  []string
+        want  string
+    }{
+        {"", []string{"-"}, sum("")},
+        {raw, []string{"-"}, sum(raw)},
+        {large, []string{"-"}, sum(large)},
+        {"middle", []string{"a", "-", "b"}, sum("a", "middle", "b")},
+        {"", []string{"a", "-"}, sum("a", "")},
+    } {
+        run(t, tt.stdin, tt.args...).want(t, 0, tt.want)
+    }
+    run(t, "abc", "-legacy", "-length", "0", "-").want(t, 0, "a9993e364706816aba3e25717850c26c9cd0d89d\n")
+    run(t, "x", "-", "a", "-").want(t, 2, "")
+}
+
+func TestMaxBytes(t *testing.T) {
+    dir := t.TempDir()
+    list := writeFile(t, dir, "list", "ab\ncde\n")
+    // The limit covers all parts together, and input exactly at the limit
+    // passes.
+    for _, tc := range []struct {
+        stdin string
+        args  []string
+        code  int
+    }{
+        {"", []string{"-max-bytes", "5", "ab", "cde"}, 0},
+        {"", []string{"-max-bytes", "4", "ab", "cde"}, 5},
+        {"hello", []string{"-max-bytes", "5", "-"}, 0},
+        {"hello!", []string{"-max-bytes", "5", "-"}, 5},
+        {"hel", []string{"-max-bytes", "5", "ab", "-"}, 0},
+        {"hell", []string{"-max-bytes", "5", "ab", "-"}, 5},
+        {strings.Repeat("x", 2<<20), []string{"-max-bytes", "1MiB", "-"}, 5},
+        {"", []string{"-max-bytes", "7", "-input-list", list}, 0},
+        {"", []string{"-max-bytes", "6", "-input-list", list}, 5},
+        {"ab\ncde\n", []string{"-max-bytes", "6", "-input-list", "-"}, 5},
+        {"", []string{"-max-bytes", "0", "abcdef"}, 0},
+    } {
+        r := run(t, tc.stdin, tc.args...)
+        if tc.code == 0 {
+            without := append([]string{}, tc.args[2:]...)
+            r.want(t, 0, run(t, tc.stdin, without...).stdout)
+            continue
+        }
+        r.want(t, 5, "")
+        if !strings.Contains(r.stderr, "exceeds the limit of") {
+            t.Errorf("%q: stderr %q", tc.args, r.stderr)
+        }
+    }
+
+    a := writeFile(t, dir, "a", "a")
+    for _, args := range [][]string{
+        {"-max-bytes", "1", "-file", a},
+        {"-max-bytes", "1", "-manifest", a},
+        {"-max-bytes", "1", "-dir", dir},
+        {"-max-bytes", "1", "-url", "http://127.0.0.1:1/"},
+        {"-max-bytes", "1", "-check", list},
+        {"-max-bytes", "1", "-check-name", a},
+        {"-max-bytes", "1", "-diff", a, a},
+        {"-max-bytes", "1", "-lines"},
+        {"-max-bytes", "1", "-serve", "127.0.0.1:0"},
+        {"-max-bytes", "-1", "x"},
+        {"-max-bytes", "lots", "x"},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+}
+
+func TestMaxLine(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "hello\n")
+    manifest := writeFile(t, dir, "SUMS", run(t, "", "-manifest", a).stdout)
+    line := len(strings.TrimSuffix(run(t, "", "-manifest", a).stdout, "\n"))
+
+    run(t, "", "-check", manifest, "-max-line", fmt.Sprint(line)).want(t, 0, a+": OK\n")
+    run(t, "", "-check", manifest, "-max-line", fmt.Sprint(line+1)).want(t, 0, a+": OK\n")
+    r := run(t, "", "-check", manifest, "-max-line", fmt.Sprint(line-1))
+    r.want(t, 5, "")
+    if want := fmt.Sprintf("%s:1: manifest line exceeds the limit of %d bytes", manifest, line-1); !strings.Contains(r.stderr, want) {
+        t.Errorf("stderr %q does not say %q", r.stderr, want)
+    }
+    // The default limit of 1MiB stops a manifest with no newlines.
+    huge := writeFile(t, dir, "huge", strings.Repeat("0", 2<<20))
+    run(t, "", "-check", huge).want(t, 5, "")
+    run(t, strings.Repeat("0", 2<<20), "-check", "-").want(t, 5, "")
+
+    for _, args := range [][]string{
+        {"-max-line", "100", "x"},
+        {"-max-line", "100", "-file", a},
+        {"-check", manifest, "-max-line", "0"},
+        {"-check", manifest, "-max-line", "2GiB"},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+}
+
+func TestFiles(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "hi\n")
+    missing := filepath.Join(dir, "missing")
+    line := "55ca6286e3e4  " + a + "\n"
+    run(t, "", "-file", a).want(t, 0, line)
+    r := run(t, "", "-file", a, missing, a)
+    r.want(t, 3, line+line)
+    if !strings.Contains(r.stderr, missing) {
+        t.Errorf("stderr %q does not name %s", r.stderr, missing)
+    }
+    run(t, "hi\n", "-file", "-").want(t, 0, "55ca6286e3e4  -\n")
+}
+
+// TestProgress checks that -progress reports on stderr only; the report
+// itself is covered with a fake clock in pkg/checksum.
+func TestProgress(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", strings.Repeat("a", 3000))
+    b := writeFile(t, dir, "b", strings.Repeat("b", 72))
+    for _, args := range [][]string{{"-file", a, b}, {"-manifest", a, b}, {"-file", "-jobs", "2", a, b}} {
+        plain := run(t, "", args...)
+        r := run(t, "", append([]string{"-progress"}, args...)...)
+        r.want(t, 0, plain.stdout)
+        // stderr is not a terminal here, so only the final totals are shown.
+        if !regexp.MustCompile(`^3\.0 KiB / 3\.0 KiB \(100\.0%\), .*/s in \S+\n$`).MatchString(r.stderr) {
+            t.Errorf("%q: stderr %q", args, r.stderr)
+        }
+    }
+    run(t, "", "-progress", "x").want(t, 2, "")
+    run(t, "", "-progress", "-dir", dir).want(t, 2, "")
+    run(t, "", "-progress", "-file", "-watch", a).want(t, 2, "")
+}
+
+func TestVerify(t *testing.T) {
+    const full = "dcb7a83334052d8982fbf9b2b21310abee7b9a6f"
+    for _, tt := range []struct {
+        args []string
+        code int
+    }{
+        {[]string{"verify", "DCB7A8333405"}, 0},
+        {[]string{"verify", full}, 0},
+        {[]string{"verify", strings.ToUpper(full)}, 0},
+        {[]string{"verify", full + "00"}, 1},
+        {[]string{"verify", "dcb7"}, 1},
+        {[]string{"verify", "-allow-prefix", "dcb7"}, 0},
+        {[]string{"verify", "-length", "4", "dcb7"}, 0},
+        {[]string{"verify", "dcb7a8333406"}, 1},
+        {[]string{"verify", "xyz"}, 2},
+        {[]string{"-verify", "dcb7a8333405"}, 0},
+        {[]string{"-verify", "DCB7A8333406"}, 1},
+    } {
+        r := run(t, "", append(tt.args, "a", "b", "c")...)
+        if r.code != tt.code {
+            t.Errorf("randomtool %q: exit %d, stderr %q; want %d", tt.args, r.code, r.stderr, tt.code)
+        }
+    }
+}
+
+func TestDir(t *testing.T) {
+    dir := t.TempDir()
+    writeFile(t, dir, "a.txt", "top\n")
+    writeFile(t, dir, "b/c", "x\n")
+    sum := func(opts checksum.TreeOptions) string {
+        d, err := checksum.Config{}.SumTree(dir, opts)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return hex.EncodeToString(d)[:12]
+    }
+    run(t, "", "-dir", dir).want(t, 0, sum(checksum.TreeOptions{})+"  "+dir+"\n")
+    run(t, "", "-dir", "-exclude", "b", dir).want(t, 0, sum(checksum.TreeOptions{Exclude: []string{"b"}})+"  "+dir+"\n")
+    run(t, "", "-dir", filepath.Join(dir, "a.txt")).want(t, 3, "")
+    run(t, "", "-dir", "-exclude", "[", dir).want(t, 2, "")
+
+    writeFile(t, dir, ".git/info/exclude", "")
+    writeFile(t, dir, ".gitignore", "b/\n")
+    gitSum := sum(checksum.TreeOptions{Git: true})
+    if gitSum == sum(checksum.TreeOptions{}) {
+        t.Fatal("the work tree hashes the same with and without its .gitignore")
+    }
+    run(t, "", "-dir", "-git", dir).want(t, 0, gitSum+"  "+dir+"\n")
+    run(t, "", "-git", dir).want(t, 2, "")
+    run(t, "", "-dir", "-include-untracked=false", dir).want(t, 2, "")
+}
+
+func TestStream(t *testing.T) {
+    r := run(t, "", "-stream", "80", "a", "b", "c")
+    if r.code != 0 || hex.EncodeToString([]byte(r.stdout)) != "dfaed995866cf7a6eb0645b6c2fc7d43f0273d27c148f31f11471d5824eff474a215119114716db0806611a84a5e408537fb99a456a2a6f016be6522a6755ef2d2775e3ff336a9f245e0d40fd0a44673" {
+        t.Errorf("-stream 80: exit %d, %x", r.code, r.stdout)
+    }
+    if r := run(t, "", "-stream", "3000000", "x"); r.code != 0 || len(r.stdout) != 3000000 {
+        t.Errorf("-stream 3000000: exit %d, %d bytes", r.code, len(r.stdout))
+    }
+    run(t, "", "-stream", "0", "x").want(t, 0, "")
+    run(t, "", "-stream", "-1", "x").want(t, 2, "")
+    run(t, "", "-stream", "274877906945", "x").want(t, 2, "")
+    run(t, "", "-stream", "10", "-json", "x").want(t, 2, "")
+}
+
+func TestHMACNeedsCryptographicAlgorithm(t *testing.T) {
+    run(t, "", "-hmac-key", "k", "-algo", "sha256", "x").want(t, 0, "*")
+    for _, alg := range []string{"crc32c", "fnv1a-64", "xxhash64"} {
+        run(t, "", "-hmac-key", "k", "-algo", alg, "x").want(t, 2, "")
+        run(t, "", "-hmac-key", "k", "-also", alg, "x").want(t, 2, "")
+    }
+}
+
+func TestURL(t *testing.T) {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/abc", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "abc") })
+    mux.Handle("/r1", http.RedirectHandler("/abc", http.StatusFound))
+    mux.Handle("/r2", http.RedirectHandler("/r1", http.StatusFound))
+    srv := httptest.NewServer(mux)
+    defer srv.Close()
+
+    abc := srv.URL + "/abc"
+    run(t, "", "-url", abc).want(t, 0, "a9993e364706  "+abc+"\n")
+    run(t, "", "-url", srv.URL+"/r2").want(t, 0, "a9993e364706  "+srv.URL+"/r2\n")
+    run(t, "", "-url", "-verify", "a9993e364706", abc).want(t, 0, "OK\n")
+    run(t, "", "-url", "-verify", "a9993e364707", abc).want(t, 1, "FAILED\n")
+    run(t, "", "-url", "-max-size", "3", abc).want(t, 0, "*")
+
+    r := run(t, "", "-url", abc, srv.URL+"/missing", abc)
+    r.want(t, 3, "a9993e364706  "+abc+"\na9993e364706  "+abc+"\n")
+    if !strings.Contains(r.stderr, "404") {
+        t.Errorf("a 404 is reported as %q", r.stderr)
+    }
+    for _, args := range [][]string{
+        {"-url", "-max-redirects", "1", srv.URL + "/r2"},
+        {"-url", "-max-size", "2", abc},
+        {"-url", "-timeout", "1ns", abc},
+    } {
+        if r := run(t, "", args...); r.code == 0 || r.stdout != "" {
+            t.Errorf("randomtool %q: %+v", args, r)
+        }
+    }
+}
+
+func TestSalt(t *testing.T) {
+    r := run(t, "", "-salt", "00112233", "user@example.com")
+    r.want(t, 0, "*")
+    stored := strings.TrimSpace(r.stdout)
+    if !strings.HasPrefix(stored, "00112233:") {
+        t.Fatalf("-salt output %q is not salt:checksum", stored)
+    }
+    run(t, "", "-salt", "00112233", "user@example.com").want(t, 0, r.stdout)
+    run(t, "", "-verify", stored, "user@example.com").want(t, 0, "OK\n")
+    run(t, "", "-verify", stored, "user@example.org").want(t, 1, "FAILED\n")
+    for _, bad := range []string{"00112233:", ":", "zz:" + stored[9:]} {
+        run(t, "", "-verify", bad, "user@example.com").want(t, 2, "")
+    }
+
+    auto := run(t, "", "-salt", "auto", "-salt-bytes", "4", "user@example.com")
+    salt, sum, _ := strings.Cut(strings.TrimSpace(auto.stdout), ":")
+    if len(salt) != 8 || len(sum) != 12 {
+        t.Errorf("-salt auto -salt-bytes 4 printed %q", auto.stdout)
+    }
+    run(t, "", "-verify", strings.TrimSpace(auto.stdout), "user@example.com").want(t, 0, "OK\n")
+
+    // The default salt is 16 bytes.
+    salt, _, _ = strings.Cut(run(t, "", "-salt", "auto", "x").stdout, ":")
+    if len(salt) != 32 {
+        t.Errorf("-salt auto printed a salt of %d hex digits, want 32", len(salt))
+    }
+    run(t, "", "-salt", "auto", "-salt-bytes", "0", "x").want(t, 2, "")
+    run(t, "", "-salt", "xyz", "x").want(t, 2, "")
+}
+
+func TestSaltAutoNeverRepeats(t *testing.T) {
+    if testing.Short() {
+        t.Skip("runs the binary thousands of times")
+    }
+    const runs, workers = 2000, 8
+    salts := make(chan string, runs)
+    errs := make(chan error, workers)
+    for range workers {
+        go func() {
+            for range runs / workers {
+                out, err := exec.Command(toolPath, "-salt", "auto", "x").Output()
+                if err != nil {
+                    errs <- err
+                    return
+                }
+                salt, _, _ := strings.Cut(string(out), ":")
+                salts <- salt
+            }
+            errs <- nil
+        }()
+    }
+    for range workers {
+        if err := <-errs; err != nil {
+            t.Fatal(err)
+        }
+    }
+    close(salts)
+    seen := make(map[string]bool)
+    for s := range salts {
+        if seen[s] {
+            t.Fatalf("salt %s repeated", s)
+        }
+        seen[s] = true
+    }
+}
+
+func TestMultihashFormat(t *testing.T) {
+    foo := writeFile(t, t.TempDir(), "foo", "foo")
+    run(t, "", "-format", "multihash", "-algo", "sha256", "-file", foo).want(t, 0, "12202c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae  "+foo+"\n")
+    run(t, "", "-format", "multihash", "-algo", "sha256", "-multibase", "base58btc", "-file", foo).want(t, 0, "zQmRJzsvyCQyizr73Gmms8ZRtvNxmgqumxc2KUp71dfEmoj  "+foo+"\n")
+    // -verify takes the algorithm from the multihash, whatever -algo says.
+    for _, mh := range []string{
+        "zQmRJzsvyCQyizr73Gmms8ZRtvNxmgqumxc2KUp71dfEmoj",
+        "11140beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",
+        "bciqcyjvunnup7rup7gnukpa5gbatie2cfvygja57ud4yuxuimjtoplq",
+    } {
+        run(t, "", "-format", "multihash", "-algo", "sha512", "-verify", mh, "-file", foo).want(t, 0, "OK\n")
+    }
+    run(t, "", "-format", "multihash", "-verify", "11140beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a34", "-file", foo).want(t, 1, "FAILED\n")
+    run(t, "", "-format", "multihash", "-verify", "11100beec7b5ea3f0fdbc95d0dd47f3c", "-file", foo).want(t, 2, "")
+    run(t, "", "-format", "multihash", "-algo", "crc32c", "x").want(t, 2, "")
+    run(t, "", "-format", "multihash", "-length", "6", "x").want(t, 2, "")
+}
+
+func TestSubcommands(t *testing.T) {
+    dir := t.TempDir()
+    hello := writeFile(t, dir, "hello.txt", "hello")
+
+    run(t, "", "a", "b", "c").want(t, 0, "dcb7a8333405\n")
+    run(t, "", "hash", "a", "b", "c").want(t, 0, "dcb7a8333405\n")
+    run(t, "", "hash", "-legacy", "-length", "0", "a", "b", "c").want(t, 0, "a9993e364706816aba3e25717850c26c9cd0d89d\n")
+    run(t, "", "--", "hash").want(t, 0, run(t, "", "hash", "hash").stdout)
+
+    run(t, "", "verify", "dcb7a8333405", "a", "b", "c").want(t, 0, "OK\n")
+    run(t, "", "verify", "dcb7a8333406", "a", "b", "c").want(t, 1, "FAILED\n")
+    run(t, "", "verify", "-quiet", "dcb7a8333406", "a", "b", "c").want(t, 1, "")
+    run(t, "", "verify", "-file", "aaf4c61ddcc5", hello).want(t, 0, "OK\n")
+
+    run(t, "", "manifest", hello).want(t, 0, "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  "+hello+"\n")
+    manifest := writeFile(t, dir, "SHA1SUMS", run(t, "", "manifest", hello).stdout)
+    run(t, "", "manifest", "-check", manifest).want(t, 0, hello+": OK\n")
+
+    if r := run(t, "", "random", "8"); r.code != 0 || !regexp.MustCompile(`^[0-9a-f]{16}\n$`).MatchString(r.stdout) {
+        t.Errorf("random 8: %+v", r)
+    }
+    if r := run(t, "", "uuid"); r.code != 0 || !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\n$`).MatchString(r.stdout) {
+        t.Errorf("uuid: %+v", r)
+    }
+    run(t, "", "uuid", "-v5", "dns", "www.example.com").want(t, 0, "2ed6657d-e927-568b-95e1-2665a8aea6a2\n")
+
+    r := run(t, "", "help")
+    r.want(t, 0, "*")
+    for _, c := range commands() {
+        if !strings.Contains(r.stdout, "  "+c.name+" ") {
+            t.Errorf("help does not list %s", c.name)
+        }
+    }
+    run(t, "", "help", "verify").want(t, 0, "*")
+    run(t, "", "help", "nosuch").want(t, 2, "")
+}
+
+// TestVerifyChecksSomething makes sure that verify never exits 0 without
+// comparing a checksum.
+func TestVerifyChecksSomething(t *testing.T) {
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "a")
+    for _, args := range [][]string{
+        {"verify"},
+        {"verify", "", "foo"},
+        {"verify", "000000000000", "-lines"},
+        {"verify", "000000000000", "-diff", a, a},
+        {"verify", "000000000000", "-serve", "127.0.0.1:0"},
+        {"verify", "000000000000", "-json", "foo"},
+        {"verify", "000000000000", "-watch", "-file", a},
+        {"verify", "000000000000", "-stream", "10", "foo"},
+        {"verify", "000000000000", "-verify", "1", "foo"},
+        {"verify", "000000000000", "-verify-old", "1", "foo"},
+        {"manifest", "-serve", "127.0.0.1:0"},
+        {"manifest", "-diff", a, a},
+        {"hash", "-random", "16"},
+        {"-verify", "", "foo"},
+    } {
+        if r := run(t, "hello\n", args...); r.code != 2 {
+            t.Errorf("randomtool %q: exit %d, stdout %q; want a usage error", args, r.code, r.stdout)
+        }
+    }
+}
+
+func TestNormalize(t *testing.T) {
+    // sha1 of the framed part "a\nb\nc\n".
+    const want = "5f9d8d5b0977acbbbd17f577e6c8e0c284a63b57\n"
+    dos := "a \r\nb\rc\t\r\n"
+    run(t, dos, "-length", "0", "-normalize", "eol,trailing-ws", "-").want(t, 0, want)
+    run(t, "a\nb\nc\n", "-length", "0", "-").want(t, 0, want)
+    if r := run(t, dos, "-length", "0", "-normalize", "eol", "-"); r.code != 0 || r.stdout == want {
+        t.Errorf("-normalize eol dropped trailing whitespace: exit %d, %q", r.code, r.stdout)
+    }
+    run(t, "cafe\u0301\n", "-normalize", "nfc", "-").want(t, 0, run(t, "caf\u00e9\n", "-").stdout)
+
+    file := writeFile(t, t.TempDir(), "dos", dos)
+    // Files are hashed unframed, as sha1sum would hash "a\nb\nc\n".
+    run(t, "", "-length", "0", "-normalize", "eol,trailing-ws", "-file", file).want(t, 0, "3ca69e8d6c234a469d16ac28a4a658c92267c423  "+file+"\n")
+    run(t, "", "-normalize", "crlf", "-").want(t, 2, "")
+    run(t, "", "-normalize", "eol", "-lines", file).want(t, 2, "")
+}
+
+func TestJSONCanonical(t *testing.T) {
+    sorted := run(t, `{"b":1,"a":2}`, "-json-canonical", "-")
+    sorted.want(t, 0, "*")
+    run(t, "{ \"a\": 2,\n  \"b\": 1 }\n", "-json-canonical", "-").want(t, 0, sorted.stdout)
+    run(t, `{"a":2,"b":1}`, "-normalize", "json", "-").want(t, 0, sorted.stdout)
+    run(t, `{"a":2,"b":1}`, "-").want(t, 0, sorted.stdout)
+    if r := run(t, `{"b":1,"a":3}`, "-json-canonical", "-"); r.code != 0 || r.stdout == sorted.stdout {
+        t.Errorf("a changed value: exit %d, %q", r.code, r.stdout)
+    }
+    r := run(t, `{"b":1,`, "-json-canonical", "-")
+    r.want(t, 3, "")
+    if !strings.Contains(r.stderr, "invalid JSON") {
+        t.Errorf("stderr %q does not say invalid JSON", r.stderr)
+    }
+}
+
+func TestAlso(t *testing.T) {
+    const (
+        sha1ABC   = "25c444341bac945310d37a5ef93d1ae4384d511e"
+        sha256ABC = "b74ffbd3cb2a7ef1b7af06ef43ff7400fea78948dd7d10e226fbd1f4bb0737db"
+    )
+    run(t, "", "-also", "sha256", "abc").want(t, 0, "sha1:"+sha1ABC[:12]+" sha256:"+sha256ABC[:12]+"\n")
+    run(t, "", "-length", "0", "-also", "sha256", "abc").want(t, 0, "sha1:"+sha1ABC+" sha256:"+sha256ABC+"\n")
+    run(t, "", "-algo", "sha256", "-length", "0", "abc").want(t, 0, sha256ABC+"\n")
+
+    run(t, "", "-also", "sha256", "-verify-old", sha1ABC[:12], "-verify-new", sha256ABC[:12], "abc").want(t, 0, "OK\n")
+    run(t, "", "-also", "sha256", "-verify-old", sha1ABC[:12], "-verify-new", "000000000000", "abc").want(t, 1, "FAILED\n")
+    run(t, "", "-also", "sha256", "-verify-old", "000000000000", "-verify-new", sha256ABC[:12], "abc").want(t, 1, "FAILED\n")
+
+    for _, args := range [][]string{
+        {"-also", "sha1", "abc"},
+        {"-also", "md4", "abc"},
+        {"-also", "sha256", "-verify", sha1ABC[:12], "abc"},
+        {"-also", "sha256", "-verify-old", sha1ABC[:12], "abc"},
+        {"-verify-old", sha1ABC[:12], "-verify-new", sha256ABC[:12], "abc"},
+        {"-also", "sha256", "-salt", "auto", "abc"},
+    } {
+        run(t, "", args...).want(t, 2, "")
+    }
+}
+
+func TestReader(t *testing.T) {
+    path := writeFile(t, t.TempDir(), "f", strings.Repeat("reader\n", 30000))
+    want := run(t, "", "-file", path)
+    want.want(t, 0, "*")
+    for _, mode := range checksum.ReadModeNames() {
+        run(t, "", "-file", "-reader", mode, path).want(t, 0, want.stdout)
+        run(t, "", "-file", "-reader", mode, "-buffer", "1000", path).want(t, 0, want.stdout)
+    }
+    run(t, "", "-file", "-reader", "direct", path).want(t, 2, "")
+    run(t, "", "-reader", "mmap", "x").want(t, 2, "")
+}
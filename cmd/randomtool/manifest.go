@@ -0,0 +1,111 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// sumMatcher interprets the checksum field of a manifest entry. It returns
+// the configuration to hash the file with and a function that reports
+// whether the resulting digest matches, or an error when the field is not a
+// valid checksum.
+type sumMatcher func(sum string) (checksum.Config, func(digest []byte) bool, error)
+
+// hexMatcher accepts hex checksums computed with cfg, in full or truncated
+// to n characters, or to any length with allowPrefix.
+func hexMatcher(cfg checksum.Config, n int, allowPrefix bool) sumMatcher {
+    return func(sum string) (checksum.Config, func([]byte) bool, error) {
+        if !validChecksum(sum, textEncoding{enc: checksum.Hex}) {
+            return cfg, nil, checksum.ErrMalformedLine
+        }
+        return cfg, func(digest []byte) bool { return verify(sum, digest, textEncoding{enc: checksum.Hex}, n, allowPrefix) }, nil
+    }
+}
+
+// checkManifest recomputes every entry of the manifest at path ("-" for
+// standard input) and reports per-file results on stdout and a summary on
+// stderr. The exit status is exitMismatch if any entry failed, and
+// otherwise exitIO if the manifest or an entry could not be read, a line
+// was malformed, or the manifest held no entries at all. A line longer
+// than maxLine bytes stops the check with exitLimit. A nil cache hashes
+// every file.
+func checkManifest(path string, match sumMatcher, cache *sumCache, opts checksum.ReadOptions, maxLine int, quiet bool, stdin io.Reader, stdout, stderr io.Writer) int {
+    r := stdin
+    if path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            return exitIO
+        }
+        defer f.Close()
+        r = f
+    }
+
+    var ok, failed, unreadable, malformed int
+    tooLong := func(lineno int) int {
+        fmt.Fprintf(stderr, "randomtool: %s:%d: %v\n", path, lineno, &checksum.LimitError{What: "manifest line", Limit: int64(maxLine)})
+        return exitLimit
+    }
+    sc := bufio.NewScanner(r)
+    // Leave room for the line ending, so that a line of exactly maxLine
+    // bytes is still read whole.
+    sc.Buffer(make([]byte, 0, min(64<<10, maxLine+2)), maxLine+2)
+    lineno := 0
+    for sc.Scan() {
+        lineno++
+        line := strings.TrimSuffix(sc.Text(), "\r")
+        if len(line) > maxLine {
+            return tooLong(lineno)
+        }
+        if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        entry, err := checksum.ParseManifestLine(line)
+        var cfg checksum.Config
+        var matches func([]byte) bool
+        if err == nil {
+            cfg, matches, err = match(entry.Sum)
+        }
+        if err != nil {
+            fmt.Fprintf(stderr, "randomtool: %s:%d: %v\n", path, lineno, err)
+            malformed++
+            continue
+        }
+        digest, _, err := cache.fileChecksum(cfg, entry.Name, stdin, opts)
+        switch {
+        case err != nil:
+            fmt.Fprintf(stderr, "randomtool: %v\n", err)
+            fmt.Fprintf(stdout, "%s: FAILED open or read\n", entry.Name)
+            unreadable++
+        case matches(digest):
+            if !quiet {
+                fmt.Fprintf(stdout, "%s: OK\n", entry.Name)
+            }
+            ok++
+        default:
+            fmt.Fprintf(stdout, "%s: FAILED\n", entry.Name)
+            failed++
+        }
+    }
+    if err := sc.Err(); errors.Is(err, bufio.ErrTooLong) {
+        return tooLong(lineno + 1)
+    } else if err != nil {
+        fmt.Fprintf(stderr, "randomtool: %s: %v\n", path, err)
+        return exitIO
+    }
+
+    fmt.Fprintf(stderr, "randomtool: %d OK, %d FAILED, %d unreadable, %d malformed\n", ok, failed, unreadable, malformed)
+    switch {
+    case failed > 0:
+        return exitMismatch
+    case unreadable > 0 || malformed > 0 || ok == 0:
+        return exitIO
+    }
+    return exitOK
+}
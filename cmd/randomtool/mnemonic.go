@@ -0,0 +1,157 @@
+package main
+
+import (
+    "crypto/pbkdf2"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/sha512"
+    _ "embed"
+    "encoding/hex"
+    "flag"
+    "fmt"
+    "io"
+    "strings"
+
+    "golang.org/x/text/unicode/norm"
+)
+
+// bip39English is the BIP39 English wordlist
+// (https://github.com/bitcoin/bips/blob/master/bip-0039/english.txt).
+//
+//go:embed wordlists/bip39_english.txt
+var bip39English string
+
+var (
+    bip39Words = strings.Fields(bip39English)
+    bip39Index = func() map[string]int {
+        m := make(map[string]int, len(bip39Words))
+        for i, w := range bip39Words {
+            m[w] = i
+        }
+        return m
+    }()
+)
+
+// entropyToMnemonic encodes 16, 20, 24, 28 or 32 bytes of entropy as a
+// BIP39 mnemonic: the entropy followed by the first len/4 bits of its
+// SHA-256, read as 11-bit word indices.
+func entropyToMnemonic(entropy []byte) ([]string, error) {
+    if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+        return nil, fmt.Errorf("BIP39 entropy must be 128, 160, 192, 224 or 256 bits, not %d", len(entropy)*8)
+    }
+    sum := sha256.Sum256(entropy)
+    bits := append(append([]byte(nil), entropy...), sum[0])
+    words := make([]string, (len(entropy)*8+len(entropy)/4)/11)
+    for i := range words {
+        var idx int
+        for j := i * 11; j < (i+1)*11; j++ {
+            idx = idx<<1 | int(bits[j/8]>>(7-j%8)&1)
+        }
+        words[i] = bip39Words[idx]
+    }
+    return words, nil
+}
+
+// mnemonicToEntropy reverses entropyToMnemonic, rejecting unknown words,
+// word counts BIP39 does not define and checksum mismatches.
+func mnemonicToEntropy(words []string) ([]byte, error) {
+    n := len(words)
+    if n < 12 || n > 24 || n%3 != 0 {
+        return nil, fmt.Errorf("mnemonic has %d words; BIP39 uses 12, 15, 18, 21 or 24", n)
+    }
+    total := n * 11
+    csBits := total / 33
+    bits := make([]byte, (total+7)/8)
+    for i, w := range words {
+        idx, ok := bip39Index[w]
+        if !ok {
+            return nil, fmt.Errorf("word %d (%q) is not in the BIP39 English list", i+1, w)
+        }
+        for j := range 11 {
+            if idx>>(10-j)&1 == 1 {
+                k := i*11 + j
+                bits[k/8] |= 1 << (7 - k%8)
+            }
+        }
+    }
+    entropy := bits[:(total-csBits)/8]
+    sum := sha256.Sum256(entropy)
+    mask := byte(0xff) << (8 - csBits)
+    if bits[len(entropy)]&mask != sum[0]&mask {
+        return nil, fmt.Errorf("mnemonic checksum does not match; a word is wrong or out of order")
+    }
+    return entropy, nil
+}
+
+// mnemonicSeed derives the 64-byte BIP39 seed: PBKDF2-HMAC-SHA512 over the
+// NFKD-normalised mnemonic, salted with "mnemonic" and the passphrase, with
+// 2048 iterations.
+func mnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+    return pbkdf2.Key(sha512.New, norm.NFKD.String(mnemonic), []byte("mnemonic"+norm.NFKD.String(passphrase)), 2048, 64)
+}
+
+func runMnemonic(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    bits := fs.Int("bits", 128, "with no mnemonic or -from-entropy, generate `n` bits of entropy: 128, 160, 192, 224 or 256")
+    fromEntropy := fs.String("from-entropy", "", "encode the given `hex` entropy instead of random entropy")
+    toEntropy := fs.Bool("to-entropy", false, "print the hex entropy of the mnemonic given as arguments")
+    toSeed := fs.Bool("to-seed", false, "print the hex BIP39 seed of the mnemonic given as arguments or by -from-entropy")
+    passphrase := fs.String("passphrase", "", "with -to-seed, the BIP39 passphrase")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    given := strings.Fields(strings.ToLower(strings.Join(fs.Args(), " ")))
+    switch {
+    case *toEntropy && *toSeed:
+        return usagef("-to-entropy and -to-seed are mutually exclusive")
+    case len(given) > 0 && *fromEntropy != "":
+        return usagef("give either a mnemonic or -from-entropy, not both")
+    case len(given) > 0 && !*toEntropy && !*toSeed:
+        return usagef("a mnemonic argument needs -to-entropy or -to-seed")
+    case len(given) == 0 && *toEntropy:
+        return usagef("-to-entropy needs the mnemonic as arguments")
+    case len(given) == 0 && *toSeed && *fromEntropy == "":
+        return usagef("-to-seed needs a mnemonic or -from-entropy")
+    case *passphrase != "" && !*toSeed:
+        return usagef("-passphrase only applies to -to-seed")
+    }
+
+    var entropy []byte
+    var err error
+    switch {
+    case len(given) > 0:
+        if entropy, err = mnemonicToEntropy(given); err != nil {
+            return usageError{err}
+        }
+    case *fromEntropy != "":
+        if entropy, err = hex.DecodeString(*fromEntropy); err != nil {
+            return usagef("-from-entropy is not hex: %v", err)
+        }
+    default:
+        if *bits < 128 || *bits > 256 || *bits%32 != 0 {
+            return usagef("-bits must be 128, 160, 192, 224 or 256")
+        }
+        entropy = make([]byte, *bits/8)
+        if _, err := rand.Read(entropy); err != nil {
+            return err
+        }
+    }
+    if *toEntropy {
+        fmt.Fprintln(stdout, hex.EncodeToString(entropy))
+        return nil
+    }
+    words, err := entropyToMnemonic(entropy)
+    if err != nil {
+        return usageError{err}
+    }
+    mnemonic := strings.Join(words, " ")
+    if *toSeed {
+        seed, err := mnemonicSeed(mnemonic, *passphrase)
+        if err != nil {
+            return err
+        }
+        fmt.Fprintln(stdout, hex.EncodeToString(seed))
+        return nil
+    }
+    fmt.Fprintln(stdout, mnemonic)
+    return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+// bip39Vectors are from the official BIP39 English test vectors, whose
+// seeds use the passphrase "TREZOR".
+var bip39Vectors = []struct{ entropy, mnemonic, seed string }{
+    {
+        "00000000000000000000000000000000",
+        "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+        "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+    },
+    {
+        "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+        "legal winner thank year wave sausage worth useful legal winner thank yellow",
+        "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+    },
+    {
+        "80808080808080808080808080808080",
+        "letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+        "d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+    },
+    {
+        "ffffffffffffffffffffffffffffffff",
+        "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+        "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+    },
+    {
+        "ffffffffffffffffffffffffffffffffffffffff",
+        "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrist",
+        "bfee6f9d2bcfa1331bd6482a24abca521e5f7e769498b9a0146672194c7356e4e409be22bc379c8b64fee2aa24b54d3ec20d10a083eaa5d1d6b4b365941ad37c",
+    },
+    {
+        "9e885d952ad362caeb4efe34a8e91bd2",
+        "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic",
+        "274ddc525802f7c828d8ef7ddbcdc5304e87ac3535913611fbbfa986d0c9e5476c91689f9c8a54fd55bd38606aa6a8595ad213d4c9c9f9aca3fb217069a41028",
+    },
+    {
+        "0000000000000000000000000000000000000000000000000000000000000000",
+        "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+        "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+    },
+    {
+        "68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c",
+        "hamster diagram private dutch cause delay private meat slide toddler razor book happy fancy gospel tennis maple dilemma loan word shrug inflict delay length",
+        "64c87cde7e12ecf6704ab95bb1408bef047c22db4cc7491c4271d170a1b213d20b385bc1588d9c7b38f1b39d415665b8a9030c9ec653d75e65f847d8fc1fc440",
+    },
+}
+
+func TestMnemonicVectors(t *testing.T) {
+    for _, v := range bip39Vectors {
+        words := strings.Fields(v.mnemonic)
+        run(t, "", "mnemonic", "-from-entropy", v.entropy).want(t, 0, v.mnemonic+"\n")
+        run(t, "", append([]string{"mnemonic", "-to-entropy"}, words...)...).want(t, 0, v.entropy+"\n")
+        run(t, "", append([]string{"mnemonic", "-to-seed", "-passphrase", "TREZOR"}, words...)...).want(t, 0, v.seed+"\n")
+        run(t, "", "mnemonic", "-from-entropy", v.entropy, "-to-seed", "-passphrase", "TREZOR").want(t, 0, v.seed+"\n")
+    }
+    // Words are matched case-insensitively, as a single argument too.
+    run(t, "", "mnemonic", "-to-entropy", strings.ToUpper(bip39Vectors[3].mnemonic)).want(t, 0, bip39Vectors[3].entropy+"\n")
+}
+
+func TestMnemonicGenerate(t *testing.T) {
+    for bits, words := range map[string]int{"128": 12, "160": 15, "192": 18, "224": 21, "256": 24} {
+        r := run(t, "", "mnemonic", "-bits", bits)
+        if got := len(strings.Fields(r.stdout)); r.code != 0 || got != words {
+            t.Errorf("mnemonic -bits %s: exit %d, %d words, want %d", bits, r.code, got, words)
+            continue
+        }
+        run(t, "", append([]string{"mnemonic", "-to-entropy"}, strings.Fields(r.stdout)...)...).want(t, 0, "*")
+    }
+}
+
+func TestMnemonicInvalid(t *testing.T) {
+    for _, words := range []string{
+        "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo",                                       // bad checksum
+        "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", // 11 words
+        "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon notaword",
+        "about abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", // out of order
+    } {
+        r := run(t, "", append([]string{"mnemonic", "-to-entropy"}, strings.Fields(words)...)...)
+        if r.code != 2 || r.stderr == "" {
+            t.Errorf("mnemonic -to-entropy %s: exit %d, stderr %q", words, r.code, r.stderr)
+        }
+    }
+    for _, entropy := range []string{"00", "0000000000000000000000000000000000", "xyz"} {
+        run(t, "", "mnemonic", "-from-entropy", entropy).want(t, 2, "")
+    }
+    for _, bits := range []string{"100", "96", "288", "0", "-32", "-128", "1073741824"} {
+        run(t, "", "mnemonic", "-bits", bits).want(t, 2, "")
+    }
+}
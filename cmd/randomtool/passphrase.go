@@ -0,0 +1,108 @@
+package main
+
+import (
+    "bufio"
+    _ "embed"
+    "encoding/binary"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// effLargeWordlist is the EFF large diceware list
+// (https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt).
+//
+//go:embed wordlists/eff_large_wordlist.txt
+var effLargeWordlist string
+
+// minWordlistSize keeps custom lists from silently producing weak
+// passphrases.
+const minWordlistSize = 1000
+
+// parseWordlist reads one word per line. A leading dice-roll column, as in
+// the EFF lists, is dropped; blank lines and repeated words are ignored.
+func parseWordlist(r io.Reader) ([]string, error) {
+    var words []string
+    seen := make(map[string]bool)
+    sc := bufio.NewScanner(r)
+    for sc.Scan() {
+        fields := strings.Fields(sc.Text())
+        if len(fields) == 0 {
+            continue
+        }
+        word := fields[len(fields)-1]
+        if !seen[word] {
+            seen[word] = true
+            words = append(words, word)
+        }
+    }
+    if err := sc.Err(); err != nil {
+        return nil, err
+    }
+    if len(words) < minWordlistSize {
+        return nil, fmt.Errorf("word list has %d unique words, need at least %d", len(words), minWordlistSize)
+    }
+    return words, nil
+}
+
+// randomIndex returns a uniformly distributed integer in [0, n) from
+// randomSource, rejecting draws that would introduce modulo bias.
+func randomIndex(n int) (int, error) {
+    limit := math.MaxUint32 - math.MaxUint32%uint32(n)
+    var b [4]byte
+    for {
+        if _, err := io.ReadFull(randomSource, b[:]); err != nil {
+            return 0, fmt.Errorf("reading system random number generator: %w", err)
+        }
+        if v := binary.BigEndian.Uint32(b[:]); v < limit {
+            return int(v % uint32(n)), nil
+        }
+    }
+}
+
+// printPassphrase writes n words chosen from wordlist (the embedded EFF list
+// when empty) joined by sep. With entropy set, the passphrase strength is
+// reported on stderr.
+func printPassphrase(n int, wordlist, sep string, entropy bool, stdout, stderr io.Writer) error {
+    if n < 1 {
+        return usagef("-passphrase must be at least 1")
+    }
+    var r io.Reader = strings.NewReader(effLargeWordlist)
+    if wordlist != "" {
+        f, err := os.Open(wordlist)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        r = f
+    }
+    words, err := parseWordlist(r)
+    if err != nil {
+        return &checksum.InputError{Path: wordlistName(wordlist), Err: err}
+    }
+
+    chosen := make([]string, n)
+    for i := range chosen {
+        j, err := randomIndex(len(words))
+        if err != nil {
+            return err
+        }
+        chosen[i] = words[j]
+    }
+    if entropy {
+        fmt.Fprintf(stderr, "%.1f bits of entropy (%d words from a list of %d)\n", float64(n)*math.Log2(float64(len(words))), n, len(words))
+    }
+    fmt.Fprintln(stdout, strings.Join(chosen, sep))
+    return nil
+}
+
+func wordlistName(path string) string {
+    if path == "" {
+        return "embedded EFF word list"
+    }
+    return path
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "math"
+    "strings"
+    "testing"
+)
+
+// testWordlist returns n distinct words, one per line.
+func testWordlist(n int) string {
+    var b strings.Builder
+    for i := range n {
+        fmt.Fprintf(&b, "word%04d\n", i)
+    }
+    return b.String()
+}
+
+func TestPassphrase(t *testing.T) {
+    r := run(t, "", "-passphrase", "5", "-separator", "-", "-entropy")
+    words := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "-")
+    if r.code != 0 || len(words) != 5 {
+        t.Fatalf("-passphrase 5: %+v", r)
+    }
+    for _, w := range words {
+        if !strings.Contains(effLargeWordlist, "\t"+w+"\n") {
+            t.Errorf("%q is not in the EFF list", w)
+        }
+    }
+    if want := "64.6 bits of entropy (5 words from a list of 7776)\n"; r.stderr != want {
+        t.Errorf("-entropy: stderr %q, want %q", r.stderr, want)
+    }
+    if r := run(t, "", "random", "-passphrase"); r.code != 0 || len(strings.Fields(r.stdout)) != 6 {
+        t.Errorf("random -passphrase: %+v", r)
+    }
+    run(t, "", "-passphrase", "0").want(t, 2, "")
+}
+
+func TestPassphraseWordlist(t *testing.T) {
+    dir := t.TempDir()
+    for _, tc := range []struct {
+        name, list string
+        code       int
+    }{
+        {"exact", testWordlist(minWordlistSize), 0},
+        {"short", testWordlist(minWordlistSize - 1), 3},
+        {"empty", "", 3},
+        // 1000 lines, but only 999 different words.
+        {"duplicate", testWordlist(minWordlistSize-1) + "word0000\n", 3},
+        {"duplicates over the minimum", testWordlist(minWordlistSize) + testWordlist(minWordlistSize), 0},
+        {"blank lines", strings.ReplaceAll(testWordlist(minWordlistSize-1), "\n", "\n\n   \n"), 3},
+        {"dice column", strings.ReplaceAll(testWordlist(minWordlistSize), "word", "11111\tword"), 0},
+    } {
+        path := writeFile(t, dir, strings.ReplaceAll(tc.name, " ", "-"), tc.list)
+        r := run(t, "", "-passphrase", "4", "-wordlist", path, "-entropy")
+        r.want(t, tc.code, "*")
+        if tc.code != 0 {
+            if r.stdout != "" || !strings.Contains(r.stderr, "need at least 1000") {
+                t.Errorf("%s: stdout %q, stderr %q", tc.name, r.stdout, r.stderr)
+            }
+            continue
+        }
+        for _, w := range strings.Fields(r.stdout) {
+            if !strings.HasPrefix(w, "word") {
+                t.Errorf("%s: chose %q", tc.name, w)
+            }
+        }
+        if !strings.Contains(r.stderr, "from a list of 1000)") {
+            t.Errorf("%s: stderr %q", tc.name, r.stderr)
+        }
+    }
+    run(t, "", "-passphrase", "4", "-wordlist", dir+"/missing").want(t, 3, "")
+}
+
+func TestParseWordlist(t *testing.T) {
+    list := "11111 b\n\n11112 a\nb\n  c  \n" + testWordlist(minWordlistSize)
+    words, err := parseWordlist(strings.NewReader(list))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(words) != minWordlistSize+3 || words[0] != "b" || words[1] != "a" || words[2] != "c" {
+        t.Errorf("parseWordlist kept %d words starting %q", len(words), words[:3])
+    }
+    _, err = parseWordlist(strings.NewReader(strings.Repeat("same\n", 2*minWordlistSize)))
+    if err == nil || err.Error() != "word list has 1 unique words, need at least 1000" {
+        t.Errorf("a list of one repeated word: %v", err)
+    }
+}
+
+// TestPassphraseRejection checks that word choice discards draws from the
+// incomplete last block instead of reducing them modulo the list size.
+func TestPassphraseRejection(t *testing.T) {
+    defer func(r io.Reader) { randomSource = r }(randomSource)
+    path := writeFile(t, t.TempDir(), "words", testWordlist(minWordlistSize))
+    limit := math.MaxUint32 - math.MaxUint32%uint32(minWordlistSize)
+    randomSource = uint32Source(limit, math.MaxUint32, limit-1, limit+7, 7)
+
+    var stdout, stderr bytes.Buffer
+    if err := printPassphrase(2, path, " ", false, &stdout, &stderr); err != nil {
+        t.Fatal(err)
+    }
+    if got, want := stdout.String(), "word0999 word0007\n"; got != want {
+        t.Errorf("printPassphrase = %q, want %q", got, want)
+    }
+}
+
+// TestPassphraseDistribution is a chi-squared test of how often each word
+// of a 1000-word list is chosen.
+func TestPassphraseDistribution(t *testing.T) {
+    const perWord = 30
+    path := writeFile(t, t.TempDir(), "words", testWordlist(minWordlistSize))
+    var stdout bytes.Buffer
+    if err := printPassphrase(minWordlistSize*perWord, path, " ", false, &stdout, io.Discard); err != nil {
+        t.Fatal(err)
+    }
+    counts := make(map[string]int)
+    for _, w := range strings.Fields(stdout.String()) {
+        counts[w]++
+    }
+    if len(counts) != minWordlistSize {
+        t.Fatalf("chose %d different words, want all %d", len(counts), minWordlistSize)
+    }
+    var chi2 float64
+    for _, c := range counts {
+        d := float64(c - perWord)
+        chi2 += d * d / perWord
+    }
+    // 999 degrees of freedom: the mean is 999, the standard deviation about
+    // 45, and exceeding 1300 has probability below 1e-9.
+    if chi2 > 1300 {
+        t.Errorf("chi-squared = %.1f over %d words", chi2, len(counts))
+    }
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "crypto/rand"
+    "fmt"
+    "io"
+    "math"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// randomSource is where -random and the other generators draw bytes from.
+var randomSource io.Reader = rand.Reader
+
+// maxRandomBytes caps a single -random value, which is held in memory
+// while it is encoded.
+const maxRandomBytes = 1 << 20
+
+// randomBytes reads n bytes from randomSource. Either all n are read or an
+// error is returned, so callers never print a partial value.
+func randomBytes(n int) ([]byte, error) {
+    buf := make([]byte, n)
+    if _, err := io.ReadFull(randomSource, buf); err != nil {
+        return nil, fmt.Errorf("reading system random number generator: %w", err)
+    }
+    return buf, nil
+}
+
+// printRandom writes count random values of n bytes in enc, one per line.
+// Values are read and printed one at a time, so a large -count does not
+// need count*n bytes of memory.
+func printRandom(n, count int, enc textEncoding, stdout io.Writer) error {
+    if n < 1 || count < 1 {
+        return usagef("-random and -count must be at least 1")
+    }
+    if n > maxRandomBytes {
+        return usagef("-random is limited to %d bytes per value", maxRandomBytes)
+    }
+    if count > math.MaxInt/n {
+        return usagef("-random %d -count %d is too many bytes", n, count)
+    }
+    if enc.is(checksum.Raw) && count > 1 {
+        return usagef("-encoding raw cannot print more than one value")
+    }
+    if err := enc.fits(n); err != nil {
+        return usagef("-encoding bech32: %v", err)
+    }
+    for range count {
+        v, err := randomBytes(n)
+        if err != nil {
+            return err
+        }
+        if enc.is(checksum.Raw) {
+            stdout.Write(v)
+            return nil
+        }
+        io.WriteString(stdout, enc.Encode(v)+"\n")
+    }
+    return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+    "bytes"
+    "errors"
+    "io"
+    "regexp"
+    "strconv"
+    "strings"
+    "testing"
+    "testing/iotest"
+)
+
+func TestRandom(t *testing.T) {
+    r := run(t, "", "random", "-count", "3", "4")
+    if r.code != 0 || !regexp.MustCompile(`^([0-9a-f]{8}\n){3}$`).MatchString(r.stdout) {
+        t.Errorf("random -count 3 4: %+v", r)
+    }
+    if r := run(t, "", "random", "-encoding", "raw", "5"); r.code != 0 || len(r.stdout) != 5 {
+        t.Errorf("random -encoding raw 5: %+v", r)
+    }
+    run(t, "", "random", "0").want(t, 2, "")
+    run(t, "", "random", strconv.Itoa(maxRandomBytes+1)).want(t, 2, "")
+    run(t, "", "random", "-count", strconv.Itoa(1<<62), "4").want(t, 2, "")
+    run(t, "", "random", "-encoding", "raw", "-count", "2", "4").want(t, 2, "")
+    run(t, "", "-salt", "auto", "-salt-bytes", strconv.Itoa(maxRandomBytes+1), "x").want(t, 2, "")
+}
+
+// TestRandomShortRead checks that a failing generator never yields a
+// partial value.
+func TestRandomShortRead(t *testing.T) {
+    defer func(r io.Reader) { randomSource = r }(randomSource)
+    randomSource = io.MultiReader(strings.NewReader("abcdefgh"), iotest.ErrReader(errors.New("rng failure")))
+
+    var out bytes.Buffer
+    if err := printRandom(4, 3, textEncoding{}, &out); err == nil {
+        t.Fatal("printRandom succeeded on a short read")
+    }
+    if got, want := out.String(), "61626364\n65666768\n"; got != want {
+        t.Errorf("printed %q, want only the complete values %q", got, want)
+    }
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// runLog collects what one invocation hashed for -log. A nil *runLog
+// records nothing, so callers need not check whether -log was given.
+type runLog struct {
+    path    string
+    maxSize int64
+    start   time.Time
+    entry   runLogEntry
+}
+
+// runLogEntry is one line of the -log file. Field names are part of the
+// tool's interface and must not change.
+type runLogEntry struct {
+    Time      string        `json:"time"`
+    Command   string        `json:"command"`
+    Algorithm string        `json:"algorithm,omitempty"`
+    Inputs    []runLogInput `json:"inputs"`
+    Checksum  string        `json:"checksum,omitempty"`
+    Duration  float64       `json:"duration_ms"`
+    Status    int           `json:"status"`
+}
+
+type runLogInput struct {
+    Input    string `json:"input"`
+    Type     string `json:"type"`
+    Bytes    int64  `json:"bytes"`
+    Checksum string `json:"checksum,omitempty"`
+    Error    string `json:"error,omitempty"`
+}
+
+func newRunLog(path string, maxSize int64, command string) *runLog {
+    return &runLog{path: path, maxSize: maxSize, start: time.Now(), entry: runLogEntry{Command: command, Inputs: []runLogInput{}}}
+}
+
+func (l *runLog) setAlgorithm(name string) {
+    if l != nil {
+        l.entry.Algorithm = name
+    }
+}
+
+func (l *runLog) setChecksum(sum string) {
+    if l != nil {
+        l.entry.Checksum = sum
+    }
+}
+
+// add records an input. Arguments and standard input may be secrets, so
+// only their length is logged, and URLs lose any password.
+func (l *runLog) add(in input, sum string, err error) {
+    if l == nil {
+        return
+    }
+    name := in.name
+    switch in.kind {
+    case "arg", "stdin":
+        name = fmt.Sprintf("<%s:%d bytes>", in.kind, in.bytes)
+    case "url":
+        if u, perr := url.Parse(name); perr == nil {
+            name = u.Redacted()
+        }
+    }
+    e := runLogInput{Input: name, Type: in.kind, Bytes: in.bytes, Checksum: sum}
+    if err != nil && in.kind != "arg" && in.kind != "stdin" {
+        e.Error = strings.ReplaceAll(err.Error(), in.name, name)
+    }
+    l.entry.Inputs = append(l.entry.Inputs, e)
+}
+
+// finish appends the entry for an invocation ending with err. A log that
+// cannot be written fails an otherwise successful run.
+func (l *runLog) finish(err error, stderr io.Writer) error {
+    if l == nil {
+        return err
+    }
+    l.entry.Time = l.start.Format(time.RFC3339Nano)
+    l.entry.Duration = float64(time.Since(l.start).Microseconds()) / 1000
+    l.entry.Status = exitCode(err, io.Discard)
+    if werr := l.write(); werr != nil {
+        werr = &checksum.InputError{Path: l.path, Err: werr}
+        if err == nil {
+            return werr
+        }
+        fmt.Fprintf(stderr, "randomtool: log: %v\n", werr)
+    }
+    return err
+}
+
+// write appends the entry as one line with a single Write on a file
+// opened for appending, so lines from concurrent invocations never
+// interleave. When the line would take the file past maxSize, the file is
+// first renamed to path.1, replacing any earlier one.
+func (l *runLog) write() error {
+    var buf bytes.Buffer
+    enc := json.NewEncoder(&buf)
+    enc.SetEscapeHTML(false)
+    if err := enc.Encode(l.entry); err != nil {
+        return err
+    }
+    line := buf.Bytes()
+    f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+    if err != nil {
+        return err
+    }
+    if l.maxSize > 0 {
+        info, err := f.Stat()
+        if err != nil {
+            f.Close()
+            return err
+        }
+        if info.Size() > 0 && info.Size()+int64(len(line)) > l.maxSize {
+            // Rotate only if no other invocation has done so since the
+            // file was opened.
+            if cur, err := os.Stat(l.path); err == nil && os.SameFile(info, cur) {
+                if err := os.Rename(l.path, l.path+".1"); err != nil {
+                    f.Close()
+                    return err
+                }
+            }
+            f.Close()
+            if f, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644); err != nil {
+                return err
+            }
+        }
+    }
+    if _, err := f.Write(line); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
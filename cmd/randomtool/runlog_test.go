@@ -0,0 +1,100 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "sync"
+    "testing"
+)
+
+func readRunLog(t *testing.T, path string) []runLogEntry {
+    t.Helper()
+    data, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var entries []runLogEntry
+    for _, line := range strings.SplitAfter(string(data), "\n") {
+        if line == "" {
+            continue
+        }
+        var e runLogEntry
+        if !strings.HasSuffix(line, "\n") || json.Unmarshal([]byte(line), &e) != nil {
+            t.Fatalf("%s: bad line %q", path, line)
+        }
+        entries = append(entries, e)
+    }
+    return entries
+}
+
+func TestRunLog(t *testing.T) {
+    dir := t.TempDir()
+    log := filepath.Join(dir, "log")
+    f := writeFile(t, dir, "f", "hi\n")
+    run(t, "", "-log", log, "secret").want(t, 0, "*")
+    run(t, "", "-log", log, "-file", f, filepath.Join(dir, "missing")).want(t, 3, "*")
+
+    entries := readRunLog(t, log)
+    if len(entries) != 2 {
+        t.Fatalf("got %d entries, want 2", len(entries))
+    }
+    if e := entries[0]; e.Status != 0 || e.Algorithm != "sha1" || len(e.Inputs) != 1 || e.Inputs[0].Input != "<arg:6 bytes>" {
+        t.Errorf("first entry %+v", e)
+    }
+    if e := entries[1]; e.Status != 3 || len(e.Inputs) != 2 || e.Inputs[0].Input != f || e.Inputs[1].Error == "" {
+        t.Errorf("second entry %+v", e)
+    }
+    if data, _ := os.ReadFile(log); strings.Contains(string(data), "secret") {
+        t.Errorf("the log holds an argument: %s", data)
+    }
+    run(t, "", "-log-max-size", "1KiB", "x").want(t, 2, "")
+}
+
+func TestRunLogConcurrent(t *testing.T) {
+    log := filepath.Join(t.TempDir(), "log")
+    const n = 16
+    var wg sync.WaitGroup
+    for i := range n {
+        wg.Go(func() {
+            // Long arguments make long lines, which are more likely to
+            // interleave if they were not written at once.
+            args := []string{"-log", log}
+            for j := range 50 {
+                args = append(args, fmt.Sprintf("%d-%d-%s", i, j, strings.Repeat("x", 100)))
+            }
+            run(t, "", args...).want(t, 0, "*")
+        })
+    }
+    wg.Wait()
+    entries := readRunLog(t, log)
+    if len(entries) != n {
+        t.Errorf("got %d entries, want %d", len(entries), n)
+    }
+    for _, e := range entries {
+        if len(e.Inputs) != 50 {
+            t.Errorf("entry with %d inputs, want 50", len(e.Inputs))
+        }
+    }
+}
+
+func TestRunLogRotate(t *testing.T) {
+    log := filepath.Join(t.TempDir(), "log")
+    run(t, "", "-log", log, "-log-max-size", "300B", "a").want(t, 0, "*")
+    if _, err := os.Stat(log + ".1"); err == nil {
+        t.Fatal("rotated after one entry")
+    }
+    run(t, "", "-log", log, "-log-max-size", "300B", "b").want(t, 0, "*")
+    run(t, "", "-log", log, "-log-max-size", "300B", "c").want(t, 0, "*")
+    old, cur := readRunLog(t, log+".1"), readRunLog(t, log)
+    if len(old) == 0 || len(cur) == 0 || len(old)+len(cur) > 3 {
+        t.Errorf("%d entries in log.1 and %d in log", len(old), len(cur))
+    }
+    if info, err := os.Stat(log); err != nil {
+        t.Error(err)
+    } else if info.Size() > 300 {
+        t.Errorf("log is %d bytes after rotating", info.Size())
+    }
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "os"
+    "os/signal"
+    "strconv"
+    "syscall"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// checksumServer answers POST /checksum and GET /healthz.
+type checksumServer struct {
+    // base supplies the defaults for every request; its HMAC key, if any,
+    // is used for every algorithm.
+    base     checksum.Config
+    maxBytes int64
+}
+
+type checksumResponse struct {
+    Algorithm string `json:"algorithm"`
+    Encoding  string `json:"encoding"`
+    Length    int    `json:"length"`
+    Checksum  string `json:"checksum"`
+    Bytes     int64  `json:"bytes"`
+}
+
+type errorResponse struct {
+    Error string `json:"error"`
+}
+
+func newServer(base checksum.Config, maxBytes int64) http.Handler {
+    s := &checksumServer{base: base, maxBytes: maxBytes}
+    mux := http.NewServeMux()
+    mux.HandleFunc("POST /checksum", s.checksum)
+    mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+        io.WriteString(w, "ok\n")
+    })
+    return mux
+}
+
+// checksum hashes the request body. An application/json body must be an
+// array of strings, hashed as framed parts like command-line arguments; an
+// application/octet-stream or text/plain body (or one without a type) is
+// hashed raw, like -file. The algo, length and encoding query parameters
+// mirror the flags of the same names.
+func (s *checksumServer) checksum(w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    cfg := s.base
+    if name := q.Get("algo"); name != "" {
+        alg, err := checksum.ParseAlgorithm(name)
+        if err != nil {
+            writeJSON(w, http.StatusBadRequest, errorResponse{err.Error()})
+            return
+        }
+        if cfg.Key != nil && !alg.Cryptographic() {
+            writeJSON(w, http.StatusBadRequest, errorResponse{fmt.Sprintf("the server's HMAC key needs a cryptographic algo, not %s", alg)})
+            return
+        }
+        cfg.Algorithm = alg
+    }
+    enc := textEncoding{enc: checksum.Hex}
+    if name := q.Get("encoding"); name != "" {
+        var err error
+        if enc.enc, err = checksum.ParseEncoding(name); err != nil || enc.is(checksum.Raw) {
+            writeJSON(w, http.StatusBadRequest, errorResponse{fmt.Sprintf("unsupported encoding %q", name)})
+            return
+        }
+    }
+    length, explicit := 12, q.Has("length")
+    if explicit {
+        var err error
+        if length, err = strconv.Atoi(q.Get("length")); err != nil {
+            writeJSON(w, http.StatusBadRequest, errorResponse{"length must be an integer"})
+            return
+        }
+    }
+    length, err := resolveLength(cfg, enc, length, explicit)
+    if err != nil {
+        writeJSON(w, http.StatusBadRequest, errorResponse{"length " + err.Error()})
+        return
+    }
+
+    mediaType := "application/octet-stream"
+    if ct := r.Header.Get("Content-Type"); ct != "" {
+        if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+            writeJSON(w, http.StatusUnsupportedMediaType, errorResponse{err.Error()})
+            return
+        }
+    }
+    body := &checksum.CountingReader{R: http.MaxBytesReader(w, r.Body, s.maxBytes)}
+    var digest []byte
+    switch mediaType {
+    case "application/json":
+        digest, err = jsonPartsChecksum(cfg, body)
+    case "application/octet-stream", "text/plain":
+        digest, err = cfg.SumReader(body)
+    default:
+        writeJSON(w, http.StatusUnsupportedMediaType, errorResponse{"unsupported content type " + mediaType})
+        return
+    }
+    if err != nil {
+        status := http.StatusBadRequest
+        if errors.As(err, new(*http.MaxBytesError)) {
+            status = http.StatusRequestEntityTooLarge
+        }
+        writeJSON(w, status, errorResponse{err.Error()})
+        return
+    }
+    writeJSON(w, http.StatusOK, checksumResponse{
+        Algorithm: cfg.Name(),
+        Encoding:  enc.String(),
+        Length:    length,
+        Checksum:  format(digest, enc, length),
+        Bytes:     body.N,
+    })
+}
+
+// jsonPartsChecksum decodes a JSON array of strings one element at a time
+// and hashes each as a part.
+func jsonPartsChecksum(cfg checksum.Config, r io.Reader) ([]byte, error) {
+    dec := json.NewDecoder(r)
+    if tok, err := dec.Token(); err != nil {
+        return nil, err
+    } else if tok != json.Delim('[') {
+        return nil, errors.New("body must be a JSON array of strings")
+    }
+    cw := checksum.NewWriter(cfg)
+    for dec.More() {
+        var part string
+        if err := dec.Decode(&part); err != nil {
+            return nil, err
+        }
+        io.WriteString(cw, part)
+        cw.EndPart()
+    }
+    if _, err := dec.Token(); err != nil {
+        return nil, err
+    }
+    if _, err := dec.Token(); err != io.EOF {
+        return nil, errors.New("unexpected data after JSON array")
+    }
+    return cw.Sum(nil), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+// serve listens on addr until SIGINT or SIGTERM, then gives in-flight
+// requests up to drain to finish.
+func serve(addr string, h http.Handler, drain time.Duration, stderr io.Writer) int {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 10 * time.Second}
+    errc := make(chan error, 1)
+    go func() { errc <- srv.ListenAndServe() }()
+    fmt.Fprintf(stderr, "randomtool: serving on %s\n", addr)
+
+    select {
+    case err := <-errc:
+        fmt.Fprintf(stderr, "randomtool: %v\n", err)
+        return exitIO
+    case <-ctx.Done():
+    }
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+    defer cancel()
+    if err := srv.Shutdown(shutdownCtx); err != nil {
+        fmt.Fprintf(stderr, "randomtool: shutdown: %v\n", err)
+        return exitInternal
+    }
+    return exitOK
+}
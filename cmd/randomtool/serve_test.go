@@ -0,0 +1,161 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "os/exec"
+    "runtime"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+func TestServer(t *testing.T) {
+    srv := httptest.NewServer(newServer(checksum.Config{}, 64))
+    defer srv.Close()
+    post := func(query, contentType, body string) (int, string) {
+        t.Helper()
+        req, err := http.NewRequest("POST", srv.URL+"/checksum"+query, strings.NewReader(body))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if contentType != "" {
+            req.Header.Set("Content-Type", contentType)
+        }
+        resp, err := srv.Client().Do(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer resp.Body.Close()
+        b, _ := io.ReadAll(resp.Body)
+        return resp.StatusCode, string(b)
+    }
+
+    for _, tt := range []struct {
+        query, contentType, body string
+        want                     checksumResponse
+    }{
+        {"", "application/json", `["a", "b", "c"]`, checksumResponse{"sha1", "hex", 12, "dcb7a8333405", 15}},
+        {"", "application/json; charset=utf-8", `[]`, checksumResponse{"sha1", "hex", 12, "da39a3ee5e6b", 2}},
+        {"", "application/octet-stream", "abc", checksumResponse{"sha1", "hex", 12, "a9993e364706", 3}},
+        {"", "text/plain; charset=utf-8", "abc", checksumResponse{"sha1", "hex", 12, "a9993e364706", 3}},
+        {"", "", "abc", checksumResponse{"sha1", "hex", 12, "a9993e364706", 3}},
+        {"?algo=sha256&length=0", "", "abc", checksumResponse{"sha256", "hex", 0, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", 3}},
+        {"?encoding=base64url&length=3", "", "abc", checksumResponse{"sha1", "base64url", 3, "qZk-", 3}},
+    } {
+        code, body := post(tt.query, tt.contentType, tt.body)
+        var got checksumResponse
+        if err := json.Unmarshal([]byte(body), &got); err != nil || code != http.StatusOK || got != tt.want {
+            t.Errorf("POST %q as %q: %d %s, want %+v", tt.query, tt.contentType, code, body, tt.want)
+        }
+    }
+
+    for _, tt := range []struct {
+        query, contentType, body string
+        code                     int
+    }{
+        {"", "application/json", `{"a": 1}`, http.StatusBadRequest},
+        {"", "application/json", `["a", 1]`, http.StatusBadRequest},
+        {"", "application/json", `["a"] ["b"]`, http.StatusBadRequest},
+        {"", "application/json", `["a"`, http.StatusBadRequest},
+        {"", "application/xml", "<a/>", http.StatusUnsupportedMediaType},
+        {"", "not a type", "abc", http.StatusUnsupportedMediaType},
+        {"", "", strings.Repeat("x", 65), http.StatusRequestEntityTooLarge},
+        {"", "application/json", `["` + strings.Repeat("x", 65) + `"]`, http.StatusRequestEntityTooLarge},
+        {"?algo=md5", "", "abc", http.StatusBadRequest},
+        {"?encoding=raw", "", "abc", http.StatusBadRequest},
+        {"?length=x", "", "abc", http.StatusBadRequest},
+    } {
+        code, body := post(tt.query, tt.contentType, tt.body)
+        var e errorResponse
+        if code != tt.code || json.Unmarshal([]byte(body), &e) != nil || e.Error == "" {
+            t.Errorf("POST %q as %q with %.20q: %d %s, want %d and an error", tt.query, tt.contentType, tt.body, code, body, tt.code)
+        }
+    }
+    if code, _ := post("", "", strings.Repeat("x", 64)); code != http.StatusOK {
+        t.Errorf("a body of exactly the limit: %d", code)
+    }
+
+    // A keyed server only computes MACs with cryptographic algorithms.
+    keyed := httptest.NewServer(newServer(checksum.Config{Key: []byte("k")}, 64))
+    defer keyed.Close()
+    for query, want := range map[string]int{
+        "?algo=crc32c":   http.StatusBadRequest,
+        "?algo=fnv1a-64": http.StatusBadRequest,
+        "?algo=xxhash64": http.StatusBadRequest,
+        "?algo=sha256":   http.StatusOK,
+        "":               http.StatusOK,
+    } {
+        resp, err := keyed.Client().Post(keyed.URL+"/checksum"+query, "text/plain", strings.NewReader("abc"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        resp.Body.Close()
+        if resp.StatusCode != want {
+            t.Errorf("keyed POST %q: %d, want %d", query, resp.StatusCode, want)
+        }
+    }
+
+    resp, err := srv.Client().Get(srv.URL + "/healthz")
+    if err != nil {
+        t.Fatal(err)
+    }
+    b, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusOK || string(b) != "ok\n" {
+        t.Errorf("GET /healthz: %d %q", resp.StatusCode, b)
+    }
+    if resp, err := srv.Client().Get(srv.URL + "/checksum"); err != nil || resp.StatusCode != http.StatusMethodNotAllowed {
+        t.Errorf("GET /checksum: %v, %v", resp.Status, err)
+    }
+}
+
+// TestServeShutdown starts -serve and stops it with SIGTERM.
+func TestServeShutdown(t *testing.T) {
+    if runtime.GOOS == "windows" {
+        t.Skip("no SIGTERM")
+    }
+    l, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    addr := l.Addr().String()
+    l.Close()
+
+    cmd := exec.Command(toolPath, "-serve", addr)
+    stderr, _ := cmd.StderrPipe()
+    if err := cmd.Start(); err != nil {
+        t.Fatal(err)
+    }
+    defer cmd.Process.Kill()
+    if line, _ := bufio.NewReader(stderr).ReadString('\n'); !strings.Contains(line, "serving on "+addr) {
+        t.Fatalf("-serve printed %q", line)
+    }
+    var resp *http.Response
+    // serve prints its address just before it starts listening.
+    for range 50 {
+        if resp, err = http.Post("http://"+addr+"/checksum", "application/json", strings.NewReader(`["a","b","c"]`)); err == nil {
+            break
+        }
+        time.Sleep(20 * time.Millisecond)
+    }
+    if err != nil {
+        t.Fatal(err)
+    }
+    b, _ := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if !strings.Contains(string(b), `"checksum":"dcb7a8333405"`) {
+        t.Errorf("POST /checksum: %s", b)
+    }
+    cmd.Process.Signal(syscall.SIGTERM)
+    if err := cmd.Wait(); err != nil {
+        t.Errorf("-serve after SIGTERM: %v", err)
+    }
+}
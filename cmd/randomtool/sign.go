@@ -0,0 +1,212 @@
+package main
+
+import (
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/pem"
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// runKeygen stores the private key as PKCS #8 PEM, which openssl and most
+// other tools read. Public keys and signatures travel as base64 lines.
+func runKeygen(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    out := fs.String("out", "randomtool.key", "write the private key to `path`, which must not exist yet")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() > 0 {
+        return usagef("keygen takes no arguments")
+    }
+    pub, priv, err := ed25519.GenerateKey(rand.Reader)
+    if err != nil {
+        return err
+    }
+    der, err := x509.MarshalPKCS8PrivateKey(priv)
+    if err != nil {
+        return err
+    }
+    f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+    if err != nil {
+        return err
+    }
+    if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+        f.Close()
+        return err
+    }
+    if err := f.Close(); err != nil {
+        return err
+    }
+    fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(pub))
+    return nil
+}
+
+func runSign(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    keyPath := fs.String("key", "", "sign with the ed25519 private key at `path`, as written by keygen")
+    algo, file := signatureFlags(fs)
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if *keyPath == "" {
+        return usagef("sign needs -key")
+    }
+    cfg, err := signatureConfig(*algo)
+    if err != nil {
+        return err
+    }
+    priv, err := readPrivateKey(*keyPath)
+    if err != nil {
+        return err
+    }
+    digest, err := signedDigest(cfg, *file, fs.Args(), stdin)
+    if err != nil {
+        return err
+    }
+    sig, err := checksum.Sign(priv, cfg.Algorithm, digest)
+    if err != nil {
+        return err
+    }
+    fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(sig))
+    return nil
+}
+
+func runVerifySig(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    pubPath := fs.String("pub", "", "check against the base64 ed25519 public key in the file at `path`, as printed by keygen")
+    sigText := fs.String("sig", "", "the base64 `signature` printed by sign")
+    quiet := fs.Bool("quiet", false, "print nothing and report only through the exit status")
+    algo, file := signatureFlags(fs)
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if *pubPath == "" || *sigText == "" {
+        return usagef("verify-sig needs -pub and -sig")
+    }
+    cfg, err := signatureConfig(*algo)
+    if err != nil {
+        return err
+    }
+    sig, err := base64.StdEncoding.DecodeString(*sigText)
+    if err != nil {
+        return usagef("-sig is not base64: %v", err)
+    }
+    pub, err := readPublicKey(*pubPath)
+    if err != nil {
+        return err
+    }
+    digest, err := signedDigest(cfg, *file, fs.Args(), stdin)
+    if err != nil {
+        return err
+    }
+    ok := checksum.VerifySignature(pub, cfg.Algorithm, digest, sig)
+    if !*quiet {
+        if ok {
+            fmt.Fprintln(stdout, "OK")
+        } else {
+            fmt.Fprintln(stdout, "FAILED")
+        }
+    }
+    if !ok {
+        return checksum.ErrVerifyMismatch
+    }
+    return nil
+}
+
+// signatureFlags defines the flags that choose what sign and verify-sig
+// cover; both must be given the same ones.
+func signatureFlags(fs *flag.FlagSet) (algo *string, file *bool) {
+    algo = fs.String("algo", "sha256", "hash algorithm: "+strings.Join(cryptographicNames(), ", "))
+    file = fs.Bool("file", false, "cover the raw contents of the single path argument instead of the arguments as parts")
+    return algo, file
+}
+
+func cryptographicNames() []string {
+    var names []string
+    for _, a := range checksum.Algorithms() {
+        if a.Cryptographic() {
+            names = append(names, a.String())
+        }
+    }
+    return names
+}
+
+func signatureConfig(algo string) (checksum.Config, error) {
+    alg, err := checksum.ParseAlgorithm(algo)
+    if err != nil {
+        return checksum.Config{}, usageError{err}
+    }
+    if !alg.Cryptographic() {
+        return checksum.Config{}, usagef("%s is not a cryptographic hash and cannot be signed (use one of %s)", alg, strings.Join(cryptographicNames(), ", "))
+    }
+    return checksum.Config{Algorithm: alg}, nil
+}
+
+// signedDigest is the full digest that sign and verify-sig cover: of the
+// file named by the only argument when file is set, otherwise of the
+// arguments as parts, with "-" standing for standard input.
+func signedDigest(cfg checksum.Config, file bool, args []string, stdin io.Reader) ([]byte, error) {
+    if file {
+        if len(args) != 1 {
+            return nil, usagef("-file takes exactly one path")
+        }
+        digest, _, err := fileChecksum(cfg, args[0], stdin, checksum.ReadOptions{})
+        return digest, err
+    }
+    if len(args) == 0 {
+        return nil, usagef("give the arguments to cover, - for standard input, or -file and a path")
+    }
+    digest, _, err := argsChecksum(cfg, args, checksum.Raw, stdin, 0, nil)
+    return digest, err
+}
+
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    block, _ := pem.Decode(data)
+    if block == nil || block.Type != "PRIVATE KEY" {
+        return nil, &checksum.InputError{Path: path, Err: errors.New("not a PEM private key")}
+    }
+    key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+    if err != nil {
+        return nil, &checksum.InputError{Path: path, Err: err}
+    }
+    priv, ok := key.(ed25519.PrivateKey)
+    if !ok {
+        return nil, &checksum.InputError{Path: path, Err: errors.New("not an ed25519 key")}
+    }
+    return priv, nil
+}
+
+// readPublicKey accepts the base64 line printed by keygen or a PEM public
+// key.
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    if block, _ := pem.Decode(data); block != nil {
+        key, err := x509.ParsePKIXPublicKey(block.Bytes)
+        if err != nil {
+            return nil, &checksum.InputError{Path: path, Err: err}
+        }
+        pub, ok := key.(ed25519.PublicKey)
+        if !ok {
+            return nil, &checksum.InputError{Path: path, Err: errors.New("not an ed25519 key")}
+        }
+        return pub, nil
+    }
+    raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+    if err != nil || len(raw) != ed25519.PublicKeySize {
+        return nil, &checksum.InputError{Path: path, Err: errors.New("not a base64 ed25519 public key")}
+    }
+    return ed25519.PublicKey(raw), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+    "encoding/base64"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "testing"
+)
+
+func TestSignRoundTrip(t *testing.T) {
+    dir := t.TempDir()
+    key := filepath.Join(dir, "key")
+    r := run(t, "", "keygen", "-out", key)
+    r.want(t, 0, "*")
+    pub := writeFile(t, dir, "key.pub", r.stdout)
+    if info, err := os.Stat(key); err != nil {
+        t.Fatal(err)
+    } else if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+        t.Errorf("private key has mode %v, want 0600", info.Mode().Perm())
+    }
+    run(t, "", "keygen", "-out", key).want(t, 3, "")
+
+    sign := run(t, "", "sign", "-key", key, "a", "b")
+    sign.want(t, 0, "*")
+    sig := strings.TrimSpace(sign.stdout)
+    run(t, "", "verify-sig", "-pub", pub, "-sig", sig, "a", "b").want(t, 0, "OK\n")
+    run(t, "", "verify-sig", "-pub", pub, "-sig", sig, "a", "c").want(t, 1, "FAILED\n")
+    run(t, "", "verify-sig", "-pub", pub, "-sig", sig, "ab").want(t, 1, "FAILED\n")
+    run(t, "", "verify-sig", "-quiet", "-pub", pub, "-sig", sig, "a", "c").want(t, 1, "")
+    run(t, "", "verify-sig", "-algo", "blake2b-256", "-pub", pub, "-sig", sig, "a", "b").want(t, 1, "FAILED\n")
+
+    b, _ := base64.StdEncoding.DecodeString(sig)
+    b[10] ^= 0x01
+    run(t, "", "verify-sig", "-pub", pub, "-sig", base64.StdEncoding.EncodeToString(b), "a", "b").want(t, 1, "FAILED\n")
+
+    f := writeFile(t, dir, "data", "payload")
+    fileSig := strings.TrimSpace(run(t, "", "sign", "-key", key, "-file", f).stdout)
+    run(t, "", "verify-sig", "-pub", pub, "-sig", fileSig, "-file", f).want(t, 0, "OK\n")
+    writeFile(t, dir, "data", "payloaD")
+    run(t, "", "verify-sig", "-pub", pub, "-sig", fileSig, "-file", f).want(t, 1, "FAILED\n")
+
+    run(t, "", "sign", "-key", key, "-algo", "crc32c", "a").want(t, 2, "")
+    run(t, "", "verify-sig", "-pub", pub, "a").want(t, 2, "")
+    run(t, "", "verify-sig", "-pub", pub, "-sig", "!!", "a").want(t, 2, "")
+}
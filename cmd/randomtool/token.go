@@ -0,0 +1,153 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+// tokenClasses are the character classes a token template can draw from.
+var tokenClasses = []struct {
+    name     string
+    alphabet string
+}{
+    {"hex", "0123456789abcdef"},
+    {"b58", "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"},
+    {"b64url", "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"},
+    {"digits", "0123456789"},
+    {"alnum", "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"},
+}
+
+func tokenClassNames() []string {
+    names := make([]string, len(tokenClasses))
+    for i, c := range tokenClasses {
+        names[i] = c.name
+    }
+    return names
+}
+
+const maxTokenClassLength = 4096
+
+// tokenPart is literal text, or n characters drawn from alphabet.
+type tokenPart struct {
+    literal  string
+    alphabet string
+    n        int
+}
+
+// parseTokenTemplate splits a template such as "sk_live_{b58:24}" into
+// parts. {{ and }} stand for literal braces. Errors give the 1-based
+// position of the offending character.
+func parseTokenTemplate(tmpl string) ([]tokenPart, error) {
+    var parts []tokenPart
+    var lit strings.Builder
+    for i := 0; i < len(tmpl); i++ {
+        switch c := tmpl[i]; {
+        case c == '{' && strings.HasPrefix(tmpl[i:], "{{"), c == '}' && strings.HasPrefix(tmpl[i:], "}}"):
+            lit.WriteByte(c)
+            i++
+        case c == '}':
+            return nil, fmt.Errorf("position %d: unmatched }; write }} for a literal brace", i+1)
+        case c == '{':
+            end := strings.IndexByte(tmpl[i:], '}')
+            if end < 0 {
+                return nil, fmt.Errorf("position %d: unclosed {", i+1)
+            }
+            part, err := parseTokenPlaceholder(tmpl[i+1:i+end], i+2)
+            if err != nil {
+                return nil, err
+            }
+            if lit.Len() > 0 {
+                parts = append(parts, tokenPart{literal: lit.String()})
+                lit.Reset()
+            }
+            parts = append(parts, part)
+            i += end
+        default:
+            lit.WriteByte(c)
+        }
+    }
+    if lit.Len() > 0 {
+        parts = append(parts, tokenPart{literal: lit.String()})
+    }
+    for _, p := range parts {
+        if p.alphabet != "" {
+            return parts, nil
+        }
+    }
+    return nil, fmt.Errorf("no placeholder such as {b58:24}; the token would not be random")
+}
+
+// parseTokenPlaceholder parses the "class:N" inside braces that starts at
+// position pos of the template.
+func parseTokenPlaceholder(s string, pos int) (tokenPart, error) {
+    class, count, ok := strings.Cut(s, ":")
+    var alphabet string
+    for _, c := range tokenClasses {
+        if c.name == class {
+            alphabet = c.alphabet
+        }
+    }
+    switch {
+    case !ok:
+        return tokenPart{}, fmt.Errorf("position %d: placeholder {%s} needs a length, as in {%s:16}", pos, s, class)
+    case alphabet == "":
+        return tokenPart{}, fmt.Errorf("position %d: unknown class %q (supported: %s)", pos, class, strings.Join(tokenClassNames(), ", "))
+    }
+    n, err := strconv.Atoi(count)
+    if err != nil || n < 1 || n > maxTokenClassLength {
+        return tokenPart{}, fmt.Errorf("position %d: length %q must be a number from 1 to %d", pos+len(class)+1, count, maxTokenClassLength)
+    }
+    return tokenPart{alphabet: alphabet, n: n}, nil
+}
+
+// runToken prints tokens made from a template. Every placeholder
+// character is drawn from crypto/rand with rejection sampling, so each
+// character of a class is equally likely.
+func runToken(fs *flag.FlagSet, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+    tmpl := fs.String("template", "{alnum:32}", "the token `template`: literal text and placeholders {class:N}, where class is one of "+strings.Join(tokenClassNames(), ", ")+"; {{ and }} are literal braces")
+    count := fs.Int("count", 1, "print `m` tokens, one per line")
+    suffix := fs.Int("checksum-suffix", 0, "append the first `n` hex characters of the token's checksum, as \"randomtool -length n token\" prints it, so typos can be rejected without a lookup")
+    if err := fs.Parse(args); err != nil {
+        return parseError(err)
+    }
+    if fs.NArg() > 0 {
+        return usagef("token takes no arguments")
+    }
+    parts, err := parseTokenTemplate(*tmpl)
+    if err != nil {
+        return usagef("-template: %v", err)
+    }
+    if *count < 1 {
+        return usagef("-count must be at least 1")
+    }
+    if full := 2 * checksum.SHA1.Size(); *suffix < 0 || *suffix > full {
+        return usagef("-checksum-suffix must be from 0 to %d", full)
+    }
+
+    var b strings.Builder
+    for range *count {
+        var token strings.Builder
+        for _, p := range parts {
+            token.WriteString(p.literal)
+            for range p.n {
+                i, err := randomIndex(len(p.alphabet))
+                if err != nil {
+                    return err
+                }
+                token.WriteByte(p.alphabet[i])
+            }
+        }
+        b.WriteString(token.String())
+        if *suffix > 0 {
+            b.WriteString(checksum.Hex.Encode(checksum.Sum([]byte(token.String())))[:*suffix])
+        }
+        b.WriteByte('\n')
+    }
+    io.WriteString(stdout, b.String())
+    return nil
+}
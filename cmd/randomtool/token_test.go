@@ -0,0 +1,172 @@
+package main
+
+import (
+    "bytes"
+    "encoding/binary"
+    "io"
+    "math"
+    "regexp"
+    "strings"
+    "testing"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+func TestTokenClasses(t *testing.T) {
+    for _, c := range tokenClasses {
+        r := run(t, "", "token", "-template", "{"+c.name+":300}", "-count", "4")
+        if r.code != 0 {
+            t.Errorf("token {%s:300}: %+v", c.name, r)
+            continue
+        }
+        lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+        if len(lines) != 4 {
+            t.Errorf("token {%s:300} -count 4 printed %d lines", c.name, len(lines))
+        }
+        seen := make(map[rune]bool)
+        for _, line := range lines {
+            if len(line) != 300 {
+                t.Errorf("token {%s:300} printed %d characters", c.name, len(line))
+            }
+            for _, ch := range line {
+                if !strings.ContainsRune(c.alphabet, ch) {
+                    t.Errorf("token {%s:300} printed %q, outside its alphabet", c.name, ch)
+                }
+                seen[ch] = true
+            }
+        }
+        // 1200 draws miss a given character of a 64-character alphabet with
+        // probability about 1e-8.
+        if len(seen) != len(c.alphabet) {
+            t.Errorf("token {%s:300} used %d of %d characters", c.name, len(seen), len(c.alphabet))
+        }
+    }
+}
+
+func TestTokenTemplate(t *testing.T) {
+    r := run(t, "", "token", "-template", "sk_{{live}}_{hex:8}-{digits:4}")
+    if r.code != 0 || !regexp.MustCompile(`^sk_\{live\}_[0-9a-f]{8}-[0-9]{4}\n$`).MatchString(r.stdout) {
+        t.Errorf("token with literals: %+v", r)
+    }
+    if r := run(t, "", "token"); r.code != 0 || !regexp.MustCompile(`^[A-Za-z0-9]{32}\n$`).MatchString(r.stdout) {
+        t.Errorf("token with the default template: %+v", r)
+    }
+    if r := run(t, "", "token", "-template", "{hex:4096}"); r.code != 0 || len(r.stdout) != 4097 {
+        t.Errorf("token {hex:4096}: code %d, %d bytes", r.code, len(r.stdout))
+    }
+
+    run(t, "", "token", "-count", "0").want(t, 2, "")
+    run(t, "", "token", "-checksum-suffix", "-1").want(t, 2, "")
+    run(t, "", "token", "-checksum-suffix", "41").want(t, 2, "")
+    run(t, "", "token", "extra").want(t, 2, "")
+}
+
+func TestTokenTemplateErrors(t *testing.T) {
+    for _, tc := range []struct{ tmpl, err string }{
+        {"abc}", "position 4: unmatched }"},
+        {"a}}b}", "position 5: unmatched }"},
+        {"ab{hex:4", "position 3: unclosed {"},
+        {"{hex:4}{b58", "position 8: unclosed {"},
+        {"x{hex}", "position 3: placeholder {hex} needs a length"},
+        {"xy{base32:4}", `position 4: unknown class "base32"`},
+        {"{:4}", `position 2: unknown class ""`},
+        {"ab{hex:0}", `position 8: length "0" must be a number from 1 to 4096`},
+        {"{digits:4097}", `position 9: length "4097" must be a number from 1 to 4096`},
+        {"{b58:x}", `position 6: length "x" must be a number from 1 to 4096`},
+        {"{b58:}", `position 6: length "" must be a number from 1 to 4096`},
+        {"no placeholder", "no placeholder"},
+        {"{{hex:4}}", "no placeholder"},
+        {"", "no placeholder"},
+    } {
+        _, err := parseTokenTemplate(tc.tmpl)
+        if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+            t.Errorf("parseTokenTemplate(%q) = %v, want an error starting %q", tc.tmpl, err, tc.err)
+        }
+        r := run(t, "", "token", "-template", tc.tmpl)
+        r.want(t, 2, "")
+        if !strings.Contains(r.stderr, tc.err) {
+            t.Errorf("token -template %q: stderr %q does not mention %q", tc.tmpl, r.stderr, tc.err)
+        }
+    }
+}
+
+func TestTokenChecksumSuffix(t *testing.T) {
+    r := run(t, "", "token", "-template", "tok_{b58:20}", "-checksum-suffix", "8", "-count", "3")
+    if r.code != 0 {
+        t.Fatalf("token -checksum-suffix 8: %+v", r)
+    }
+    for _, line := range strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n") {
+        if len(line) != len("tok_")+20+8 {
+            t.Fatalf("token -checksum-suffix 8 printed %q", line)
+        }
+        token, suffix := line[:len(line)-8], line[len(line)-8:]
+        if want := checksum.Hex.Encode(checksum.Sum([]byte(token)))[:8]; suffix != want {
+            t.Errorf("%q has suffix %q, want %q", token, suffix, want)
+        }
+        run(t, "", "-length", "8", token).want(t, 0, suffix+"\n")
+    }
+}
+
+// uint32Source returns a random source that yields vs in order, big-endian.
+func uint32Source(vs ...uint32) *bytes.Reader {
+    b := make([]byte, 0, 4*len(vs))
+    for _, v := range vs {
+        b = binary.BigEndian.AppendUint32(b, v)
+    }
+    return bytes.NewReader(b)
+}
+
+// TestRandomIndexRejection checks that draws from the incomplete last
+// block of [0, 2^32) are thrown away rather than reduced modulo n, which
+// would favor the low indexes.
+func TestRandomIndexRejection(t *testing.T) {
+    defer func(r io.Reader) { randomSource = r }(randomSource)
+    for _, n := range []int{10, 58, 7776} {
+        limit := math.MaxUint32 - math.MaxUint32%uint32(n)
+        if limit%uint32(n) != 0 {
+            t.Fatalf("n = %d: limit %d is not a multiple of n", n, limit)
+        }
+        randomSource = uint32Source(limit, math.MaxUint32, limit-1, 0, uint32(n)+3)
+        for _, want := range []int{n - 1, 0, 3} {
+            got, err := randomIndex(n)
+            if err != nil || got != want {
+                t.Errorf("n = %d: randomIndex = %d, %v, want %d", n, got, err, want)
+            }
+        }
+        if _, err := randomIndex(n); err == nil {
+            t.Errorf("n = %d: randomIndex succeeded with the source exhausted", n)
+        }
+    }
+
+    // With the source stuck in the rejected block, no index is ever
+    // returned.
+    randomSource = uint32Source(math.MaxUint32, math.MaxUint32, math.MaxUint32)
+    if i, err := randomIndex(10); err == nil {
+        t.Errorf("randomIndex returned %d from rejected draws only", i)
+    }
+}
+
+// TestRandomIndexDistribution is a chi-squared test of randomIndex against
+// the uniform distribution over an alphabet whose size is not a power of
+// two.
+func TestRandomIndexDistribution(t *testing.T) {
+    const n, perBucket = 58, 2000
+    counts := make([]int, n)
+    for range n * perBucket {
+        i, err := randomIndex(n)
+        if err != nil {
+            t.Fatal(err)
+        }
+        counts[i]++
+    }
+    var chi2 float64
+    for _, c := range counts {
+        d := float64(c - perBucket)
+        chi2 += d * d / perBucket
+    }
+    // 57 degrees of freedom: the mean is 57 and exceeding 130 has
+    // probability below 1e-7.
+    if chi2 > 130 {
+        t.Errorf("chi-squared = %.1f over %d buckets: %v", chi2, n, counts)
+    }
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+    "crypto/sha1"
+    "encoding/binary"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "strings"
+)
+
+type uuid [16]byte
+
+// The name-based namespaces defined in RFC 4122 appendix C.
+var uuidNamespaces = map[string]string{
+    "dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+    "url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+    "oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+    "x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+func (u uuid) String() string {
+    h := hex.EncodeToString(u[:])
+    return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// parseUUID accepts a namespace name or a UUID in 8-4-4-4-12 form.
+func parseUUID(s string) (uuid, error) {
+    var u uuid
+    if ns, ok := uuidNamespaces[strings.ToLower(s)]; ok {
+        s = ns
+    }
+    if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+        return u, fmt.Errorf("invalid UUID %q", s)
+    }
+    b, err := hex.DecodeString(s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+    if err != nil {
+        return u, fmt.Errorf("invalid UUID %q", s)
+    }
+    copy(u[:], b)
+    return u, nil
+}
+
+func (u *uuid) setVersion(v byte) {
+    u[6] = u[6]&0x0f | v<<4
+    u[8] = u[8]&0x3f | 0x80
+}
+
+// newUUIDv4 returns a random UUID drawn from randomSource.
+func newUUIDv4() (uuid, error) {
+    var u uuid
+    if _, err := io.ReadFull(randomSource, u[:]); err != nil {
+        return u, fmt.Errorf("reading system random number generator: %w", err)
+    }
+    u.setVersion(4)
+    return u, nil
+}
+
+// newUUIDv5 derives a name-based UUID as in RFC 4122 section 4.3. A single
+// part is the name as is, giving the standard v5 UUID for that name. Two or
+// more parts are framed like checksum parts, each followed by its length, so
+// "a b" and "ab" get different UUIDs.
+func newUUIDv5(namespace uuid, parts []string) uuid {
+    h := sha1.New()
+    h.Write(namespace[:])
+    for _, p := range parts {
+        io.WriteString(h, p)
+        if len(parts) > 1 {
+            var n [8]byte
+            binary.BigEndian.PutUint64(n[:], uint64(len(p)))
+            h.Write(n[:])
+        }
+    }
+    var u uuid
+    copy(u[:], h.Sum(nil))
+    u.setVersion(5)
+    return u
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+    "regexp"
+    "testing"
+)
+
+func TestUUIDv5(t *testing.T) {
+    for _, tt := range []struct {
+        args []string
+        want string
+    }{
+        // RFC 4122 appendix B, as corrected by erratum 1352.
+        {[]string{"dns", "www.example.com"}, "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+        // The rest agree with Python's uuid.uuid5.
+        {[]string{"url", "http://python.org/"}, "4c565f0d-3f5a-5890-b41b-20cf47701c5e"},
+        {[]string{"oid", "1.3.6.1"}, "1447fa61-5277-5fef-a9b3-fbc6e44f4af3"},
+        {[]string{"6ba7b810-9dad-11d1-80b4-00c04fd430c8", "www.example.com"}, "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+        {[]string{"dns", "a", "b"}, "fab5261c-5aed-511f-a3eb-02c5f68684f8"},
+    } {
+        run(t, "", append([]string{"uuid", "-v5"}, tt.args...)...).want(t, 0, tt.want+"\n")
+    }
+
+    // Separate names must not be confused with their concatenation.
+    ab := run(t, "", "uuid", "-v5", "dns", "ab").stdout
+    if a, b := run(t, "", "uuid", "-v5", "dns", "a", "b").stdout, run(t, "", "uuid", "-v5", "dns", "a", "", "b").stdout; a == ab || b == ab || a == b {
+        t.Errorf("uuid -v5 does not frame its names: %q, %q and %q", ab, a, b)
+    }
+
+    run(t, "", "uuid", "-v5", "nosuch", "x").want(t, 2, "")
+    run(t, "", "uuid", "x").want(t, 2, "")
+}
+
+func TestUUIDv4(t *testing.T) {
+    v4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}\n$`)
+    a, b := run(t, "", "uuid"), run(t, "", "uuid")
+    if !v4.MatchString(a.stdout) || !v4.MatchString(b.stdout) || a.stdout == b.stdout {
+        t.Errorf("uuid printed %q and %q", a.stdout, b.stdout)
+    }
+}
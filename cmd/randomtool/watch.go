@@ -0,0 +1,83 @@
+package main
+
+import (
+    "context"
+    "encoding/binary"
+    "hash/fnv"
+    "io/fs"
+    "os"
+    "os/signal"
+    "path/filepath"
+    "syscall"
+    "time"
+)
+
+// watchUntilInterrupted is watchPaths stopped by SIGINT or SIGTERM.
+func watchUntilInterrupted(paths []string, interval, debounce time.Duration, sum func(path string) ([]byte, input, error), emit func(path string, digest []byte, err error)) int {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+    return watchPaths(ctx, paths, interval, debounce, sum, emit)
+}
+
+// watchPaths prints the checksum of every path immediately and again after
+// each change, until ctx is done. Changes are found by polling file metadata
+// every interval; a path is re-hashed once it has been quiet for debounce,
+// so a burst of writes produces a single line.
+func watchPaths(ctx context.Context, paths []string, interval, debounce time.Duration, sum func(path string) ([]byte, input, error), emit func(path string, digest []byte, err error)) int {
+    marks := make([]uint64, len(paths))
+    changed := make([]time.Time, len(paths))
+    for i, path := range paths {
+        marks[i] = fingerprint(path)
+        digest, _, err := sum(path)
+        emit(path, digest, err)
+    }
+
+    tick := time.NewTicker(interval)
+    defer tick.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return 0
+        case now := <-tick.C:
+            for i, path := range paths {
+                if mark := fingerprint(path); mark != marks[i] {
+                    marks[i], changed[i] = mark, now
+                    continue
+                }
+                if !changed[i].IsZero() && now.Sub(changed[i]) >= debounce {
+                    changed[i] = time.Time{}
+                    digest, _, err := sum(path)
+                    emit(path, digest, err)
+                }
+            }
+        }
+    }
+}
+
+// fingerprint summarises the metadata of path and, for a directory,
+// everything below it. It changes whenever a name, size, mode or
+// modification time does, including when the path appears or disappears.
+func fingerprint(path string) uint64 {
+    h := fnv.New64a()
+    var b [8]byte
+    add := func(rel string, info fs.FileInfo) {
+        h.Write([]byte(rel))
+        h.Write(binary.BigEndian.AppendUint64(b[:0], uint64(info.Size())))
+        h.Write(binary.BigEndian.AppendUint64(b[:0], uint64(info.ModTime().UnixNano())))
+        h.Write(binary.BigEndian.AppendUint64(b[:0], uint64(info.Mode())))
+    }
+    filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            h.Write([]byte(err.Error()))
+            return nil
+        }
+        info, err := d.Info()
+        if err != nil {
+            h.Write([]byte(err.Error()))
+            return nil
+        }
+        add(p, info)
+        return nil
+    })
+    return h.Sum64()
+}
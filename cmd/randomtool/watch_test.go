@@ -0,0 +1,193 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "syscall"
+    "testing"
+    "time"
+
+    "github.com/sparksat-wallet/github/pkg/checksum"
+)
+
+type watchEvent struct {
+    sum string // the hex digest, or "" on error
+    err error
+}
+
+// startWatch runs watchPaths on paths in the background and returns the
+// events it emits. The watch is stopped when the test ends.
+func startWatch(t *testing.T, debounce time.Duration, paths ...string) <-chan watchEvent {
+    t.Helper()
+    ctx, cancel := context.WithCancel(context.Background())
+    events := make(chan watchEvent, 100)
+    done := make(chan int)
+    sum := func(path string) ([]byte, input, error) {
+        return fileChecksum(checksum.Config{}, path, nil, checksum.ReadOptions{})
+    }
+    go func() {
+        done <- watchPaths(ctx, paths, 10*time.Millisecond, debounce, sum, func(path string, digest []byte, err error) {
+            events <- watchEvent{checksum.Hex.Encode(digest), err}
+        })
+    }()
+    t.Cleanup(func() {
+        cancel()
+        if code := <-done; code != 0 {
+            t.Errorf("watchPaths returned %d after cancellation, want 0", code)
+        }
+    })
+    return events
+}
+
+func nextEvent(t *testing.T, events <-chan watchEvent) watchEvent {
+    t.Helper()
+    select {
+    case e := <-events:
+        return e
+    case <-time.After(5 * time.Second):
+        t.Fatal("no update within 5s")
+    }
+    return watchEvent{}
+}
+
+// noEvent fails the test if events delivers anything within d.
+func noEvent(t *testing.T, events <-chan watchEvent, d time.Duration) {
+    t.Helper()
+    select {
+    case e := <-events:
+        t.Errorf("unexpected update %+v", e)
+    case <-time.After(d):
+    }
+}
+
+func TestWatchChange(t *testing.T) {
+    a := writeFile(t, t.TempDir(), "a", "one")
+    events := startWatch(t, 20*time.Millisecond, a)
+    if e := nextEvent(t, events); e.err != nil || e.sum != rawSHA1("one") {
+        t.Fatalf("first update %+v, want the sum of %q", e, "one")
+    }
+    noEvent(t, events, 100*time.Millisecond)
+
+    writeFile(t, filepath.Dir(a), "a", "two!")
+    if e := nextEvent(t, events); e.err != nil || e.sum != rawSHA1("two!") {
+        t.Errorf("after a change: %+v, want the sum of %q", e, "two!")
+    }
+    noEvent(t, events, 100*time.Millisecond)
+}
+
+// TestWatchDebounce writes a file repeatedly, faster than the debounce
+// interval, and expects a single update with the final contents.
+func TestWatchDebounce(t *testing.T) {
+    a := writeFile(t, t.TempDir(), "a", "start")
+    const debounce = 300 * time.Millisecond
+    events := startWatch(t, debounce, a)
+    nextEvent(t, events)
+
+    var last string
+    for i := range 10 {
+        last = strings.Repeat("x", i+1)
+        writeFile(t, filepath.Dir(a), "a", last)
+        time.Sleep(debounce / 10)
+    }
+    if e := nextEvent(t, events); e.err != nil || e.sum != rawSHA1(last) {
+        t.Errorf("after a burst of writes: %+v, want the sum of the last one", e)
+    }
+    noEvent(t, events, 3*debounce)
+}
+
+// TestWatchDelete checks that a deleted path is reported as an error, the
+// watch goes on, and the path is hashed again when it reappears.
+func TestWatchDelete(t *testing.T) {
+    a := writeFile(t, t.TempDir(), "a", "here")
+    events := startWatch(t, 20*time.Millisecond, a)
+    nextEvent(t, events)
+
+    if err := os.Remove(a); err != nil {
+        t.Fatal(err)
+    }
+    if e := nextEvent(t, events); !errors.Is(e.err, fs.ErrNotExist) {
+        t.Errorf("after deletion: %+v, want a not-exist error", e)
+    }
+    noEvent(t, events, 100*time.Millisecond)
+
+    writeFile(t, filepath.Dir(a), "a", "back")
+    if e := nextEvent(t, events); e.err != nil || e.sum != rawSHA1("back") {
+        t.Errorf("after re-creation: %+v, want the sum of %q", e, "back")
+    }
+}
+
+// TestWatchCLI runs -watch in the binary and stops it with SIGTERM, which
+// is a clean exit.
+func TestWatchCLI(t *testing.T) {
+    if runtime.GOOS == "windows" {
+        t.Skip("no SIGTERM")
+    }
+    dir := t.TempDir()
+    a := writeFile(t, dir, "a", "one")
+    r := runWatch(t, func() {
+        writeFile(t, dir, "a", "two!")
+        time.Sleep(500 * time.Millisecond)
+        os.Remove(a)
+        time.Sleep(500 * time.Millisecond)
+    }, "-file", "-watch", "-poll-interval", "20ms", "-debounce", "50ms", a)
+    if r.code != 0 {
+        t.Errorf("-watch exited %d after SIGTERM, want 0", r.code)
+    }
+    lines := strings.Split(strings.TrimSuffix(r.stdout, "\n"), "\n")
+    if len(lines) != 2 || !strings.HasSuffix(lines[0], " "+rawSHA1("one")[:12]+"  "+a) || !strings.HasSuffix(lines[1], " "+rawSHA1("two!")[:12]+"  "+a) {
+        t.Errorf("-watch stdout %q", r.stdout)
+    }
+    if _, err := time.Parse(time.RFC3339, strings.Fields(lines[0])[0]); err != nil {
+        t.Errorf("-watch line %q has no timestamp: %v", lines[0], err)
+    }
+    if !strings.Contains(r.stderr, "randomtool: open "+a) {
+        t.Errorf("-watch stderr %q does not report the deletion", r.stderr)
+    }
+
+    run(t, "", "-watch", "x").want(t, 2, "")
+    run(t, "", "-watch", "-file", "-poll-interval", "0", a).want(t, 2, "")
+    run(t, "", "-watch", "-file", "-debounce", "-1s", a).want(t, 2, "")
+    run(t, "", "-watch", "-file", "-json", a).want(t, 2, "")
+}
+
+// runWatch starts the binary with args, waits for its first line, calls
+// act, and then sends SIGTERM.
+func runWatch(t *testing.T, act func(), args ...string) result {
+    t.Helper()
+    dir := t.TempDir()
+    stdoutPath, stderrPath := filepath.Join(dir, "stdout"), filepath.Join(dir, "stderr")
+    stdout, err := os.Create(stdoutPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer stdout.Close()
+    stderr, err := os.Create(stderrPath)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer stderr.Close()
+
+    p, err := os.StartProcess(toolPath, append([]string{toolPath}, args...), &os.ProcAttr{Files: []*os.File{nil, stdout, stderr}})
+    if err != nil {
+        t.Fatal(err)
+    }
+    for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+        if b, _ := os.ReadFile(stdoutPath); len(b) > 0 || time.Now().After(deadline) {
+            break
+        }
+    }
+    act()
+    p.Signal(syscall.SIGTERM)
+    state, err := p.Wait()
+    if err != nil {
+        t.Fatal(err)
+    }
+    out, _ := os.ReadFile(stdoutPath)
+    errOut, _ := os.ReadFile(stderrPath)
+    return result{string(out), string(errOut), state.ExitCode()}
+}
@@ -0,0 +1,10 @@
+module github.com/sparksat-wallet/github
+
+go 1.26.0
+
+require (
+	golang.org/x/crypto v0.57.0
+	golang.org/x/text v0.42.0
+)
+
+require golang.org/x/sys v0.48.0 // indirect
@@ -0,0 +1,107 @@
+package checksum
+
+import (
+    "crypto/sha1"
+    "crypto/sha256"
+    "crypto/sha512"
+    "fmt"
+    "hash"
+    "hash/crc32"
+    "hash/fnv"
+    "strings"
+
+    "golang.org/x/crypto/blake2b"
+)
+
+// Algorithm selects the hash function behind a checksum. The zero value is
+// SHA1, the historical default.
+type Algorithm int
+
+const (
+    SHA1 Algorithm = iota
+    SHA256
+    SHA512
+    BLAKE2b256
+    // The remaining algorithms are fast, non-cryptographic checksums for
+    // cache keys and deduplication. They must not be relied on where an
+    // attacker controls the input.
+    CRC32C
+    FNV1a64
+    XXHash64
+)
+
+var algorithms = [...]struct {
+    name string
+    new  func() hash.Hash
+}{
+    SHA1:   {"sha1", sha1.New},
+    SHA256: {"sha256", sha256.New},
+    SHA512: {"sha512", sha512.New},
+    BLAKE2b256: {"blake2b-256", func() hash.Hash {
+        h, _ := blake2b.New256(nil)
+        return h
+    }},
+    CRC32C: {"crc32c", func() hash.Hash {
+        return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+    }},
+    FNV1a64:  {"fnv1a-64", func() hash.Hash { return fnv.New64a() }},
+    XXHash64: {"xxhash64", func() hash.Hash { return newXXHash64() }},
+}
+
+// Algorithms lists every supported algorithm in a stable order.
+func Algorithms() []Algorithm {
+    all := make([]Algorithm, len(algorithms))
+    for i := range algorithms {
+        all[i] = Algorithm(i)
+    }
+    return all
+}
+
+// AlgorithmNames lists the names accepted by ParseAlgorithm.
+func AlgorithmNames() []string {
+    names := make([]string, len(algorithms))
+    for i, a := range algorithms {
+        names[i] = a.name
+    }
+    return names
+}
+
+// ParseAlgorithm returns the algorithm with the given name, such as "sha256".
+func ParseAlgorithm(name string) (Algorithm, error) {
+    for i, a := range algorithms {
+        if a.name == name {
+            return Algorithm(i), nil
+        }
+    }
+    return 0, fmt.Errorf("unknown algorithm %q (supported: %s)", name, strings.Join(AlgorithmNames(), ", "))
+}
+
+func (a Algorithm) valid() bool {
+    return a >= 0 && int(a) < len(algorithms)
+}
+
+func (a Algorithm) String() string {
+    if !a.valid() {
+        return fmt.Sprintf("Algorithm(%d)", int(a))
+    }
+    return algorithms[a].name
+}
+
+// New returns a fresh hash.Hash for the algorithm.
+func (a Algorithm) New() hash.Hash {
+    if !a.valid() {
+        panic("checksum: invalid algorithm " + a.String())
+    }
+    return algorithms[a].new()
+}
+
+// Size is the length of the algorithm's digest in bytes.
+func (a Algorithm) Size() int {
+    return a.New().Size()
+}
+
+// Cryptographic reports whether the algorithm resists deliberate
+// collisions, which signatures and authentication rely on.
+func (a Algorithm) Cryptographic() bool {
+    return a.valid() && a < CRC32C
+}
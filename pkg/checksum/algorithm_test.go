@@ -0,0 +1,77 @@
+package checksum
+
+import (
+    "encoding/hex"
+    "fmt"
+    "strings"
+    "testing"
+)
+
+// algorithmVectors holds each algorithm's published digest of "abc" and its
+// framed checksum of the parts "a", "b", "c".
+var algorithmVectors = []struct {
+    alg         Algorithm
+    abc, framed string
+}{
+    {SHA1, "a9993e364706816aba3e25717850c26c9cd0d89d", "dcb7a83334052d8982fbf9b2b21310abee7b9a6f"},
+    {SHA256, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", "e1a225c6dcc06fe09af72117a5d874e716eb6499d3244d0ee31e5db724edd523"},
+    {SHA512, "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f", "84136ded3e81e272ac780fe05490476d884b6d467427e8c62beb3bbd0c4a09fc3dcee064e22d4226395f83e7231e16479e439858c3bc228f17c7ec1efa25a558"},
+    {BLAKE2b256, "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319", "b1fab9515395760395d91aed04d98fefafd66482ab1e990f9f111b8e79a93216"},
+    {CRC32C, "364b3fb7", "16ef33c4"},
+    {FNV1a64, "e71fa2190541574b", "ee4f898b8469fb42"},
+    {XXHash64, "44bc2cf5ad770999", "dd756fdfe3ee0f16"},
+}
+
+func TestAlgorithms(t *testing.T) {
+    if len(algorithmVectors) != len(Algorithms()) {
+        t.Fatalf("%d vectors for %d algorithms", len(algorithmVectors), len(Algorithms()))
+    }
+    for _, v := range algorithmVectors {
+        c := Config{Algorithm: v.alg}
+        digest, err := c.SumReader(strings.NewReader("abc"))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := hex.EncodeToString(digest); got != v.abc {
+            t.Errorf("%s of abc = %s, want %s", v.alg, got, v.abc)
+        }
+        if got := hex.EncodeToString(c.Sum(parts("a", "b", "c")...)); got != v.framed {
+            t.Errorf("%s checksum of a, b, c = %s, want %s", v.alg, got, v.framed)
+        }
+        if got := v.alg.Size(); got != len(v.abc)/2 {
+            t.Errorf("%s.Size() = %d, want %d", v.alg, got, len(v.abc)/2)
+        }
+        if a, err := ParseAlgorithm(v.alg.String()); err != nil || a != v.alg {
+            t.Errorf("ParseAlgorithm(%q) = %v, %v", v.alg, a, err)
+        }
+    }
+}
+
+func TestParseAlgorithmUnknown(t *testing.T) {
+    for _, name := range []string{"", "md5", "SHA1", "sha-256"} {
+        _, err := ParseAlgorithm(name)
+        if err == nil || !strings.Contains(err.Error(), strings.Join(AlgorithmNames(), ", ")) {
+            t.Errorf("ParseAlgorithm(%q) error %v does not list the algorithms", name, err)
+        }
+    }
+    if s := Algorithm(-1).String(); s != "Algorithm(-1)" {
+        t.Errorf("Algorithm(-1).String() = %q", s)
+    }
+}
+
+func BenchmarkAlgorithms(b *testing.B) {
+    for _, size := range []int{1 << 10, 1 << 20} {
+        data := make([]byte, size)
+        for _, a := range Algorithms() {
+            b.Run(fmt.Sprintf("%s/%dKiB", a, size>>10), func(b *testing.B) {
+                h := a.New()
+                b.SetBytes(int64(size))
+                for b.Loop() {
+                    h.Reset()
+                    h.Write(data)
+                    h.Sum(nil)
+                }
+            })
+        }
+    }
+}
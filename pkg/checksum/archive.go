@@ -0,0 +1,207 @@
+package checksum
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bufio"
+    "bytes"
+    "compress/gzip"
+    "errors"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "path"
+    "slices"
+    "strings"
+)
+
+// ArchiveOptions controls how an archive is listed.
+type ArchiveOptions struct {
+    // Modes includes each file's permission bits in the hash.
+    Modes bool
+}
+
+// Archive lists the contents of a tar, gzip-compressed tar or zip file,
+// recognised by its leading bytes rather than its name. Only names, types,
+// contents, link targets and, optionally, file permissions are kept;
+// timestamps, ownership, entry order and compression are ignored. Parent
+// directories are implied for every entry, so archives that do and do not
+// store directory entries list the same tree. Without modes the listing
+// matches Tree of the extracted directory.
+func (c Config) Archive(name string, opts ArchiveOptions) ([]TreeEntry, error) {
+    f, err := os.Open(name)
+    if err != nil {
+        return nil, inputError(name, err)
+    }
+    defer f.Close()
+
+    br := bufio.NewReader(f)
+    magic, _ := br.Peek(512)
+    a := &archiveListing{c: c, opts: opts, entries: make(map[string]TreeEntry)}
+    switch {
+    case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+        var info fs.FileInfo
+        if info, err = f.Stat(); err != nil {
+            return nil, inputError(name, err)
+        }
+        err = a.readZip(f, info.Size())
+    case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+        var zr *gzip.Reader
+        if zr, err = gzip.NewReader(br); err != nil {
+            return nil, inputError(name, err)
+        }
+        if err = a.readTar(zr); err == nil {
+            // Read to the end, so that a damaged gzip trailer is noticed.
+            _, err = io.Copy(io.Discard, zr)
+        }
+    case len(magic) >= 262 && string(magic[257:262]) == "ustar":
+        err = a.readTar(br)
+    default:
+        return nil, inputError(name, errors.New("not a tar, tar.gz or zip archive"))
+    }
+    if err != nil {
+        return nil, inputError(name, err)
+    }
+    return a.sorted(), nil
+}
+
+// SumArchive returns the tree hash of the archive's contents.
+func (c Config) SumArchive(name string, opts ArchiveOptions) ([]byte, error) {
+    entries, err := c.Archive(name, opts)
+    if err != nil {
+        return nil, err
+    }
+    return c.TreeSum(entries), nil
+}
+
+type archiveListing struct {
+    c       Config
+    opts    ArchiveOptions
+    entries map[string]TreeEntry
+}
+
+// cleanName normalises an archive member name to a slash-separated path
+// relative to the archive root, or "" for the root itself.
+func cleanName(name string) string {
+    return path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))[1:]
+}
+
+// add records e, letting a later entry for the same path replace an earlier
+// one as extraction would, and implies its parent directories.
+func (a *archiveListing) add(e TreeEntry) {
+    if e.Path == "" {
+        return
+    }
+    if e.Type != File || !a.opts.Modes {
+        e.Mode = 0
+    }
+    a.entries[e.Path] = e
+    for dir := path.Dir(e.Path); dir != "."; dir = path.Dir(dir) {
+        if _, ok := a.entries[dir]; ok {
+            break
+        }
+        a.entries[dir] = TreeEntry{Path: dir, Type: Dir}
+    }
+}
+
+func (a *archiveListing) sorted() []TreeEntry {
+    entries := make([]TreeEntry, 0, len(a.entries))
+    for _, e := range a.entries {
+        entries = append(entries, e)
+    }
+    slices.SortFunc(entries, byPath)
+    return entries
+}
+
+func (a *archiveListing) readTar(r io.Reader) error {
+    tr := tar.NewReader(r)
+    for {
+        hdr, err := tr.Next()
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        e := TreeEntry{Path: cleanName(hdr.Name), Mode: fs.FileMode(hdr.Mode).Perm()}
+        switch hdr.Typeflag {
+        case tar.TypeDir:
+            e.Type = Dir
+        case tar.TypeSymlink:
+            e.Type, e.Target = Symlink, hdr.Linkname
+        case tar.TypeLink:
+            // A hard link has the contents of an entry stored earlier.
+            prev, ok := a.entries[cleanName(hdr.Linkname)]
+            if !ok || prev.Type != File {
+                return fmt.Errorf("hard link %s to missing file %s", hdr.Name, hdr.Linkname)
+            }
+            e.Type, e.Digest, e.Size = File, prev.Digest, prev.Size
+        case tar.TypeReg, tar.TypeCont, tar.TypeGNUSparse:
+            // tar.Reader fills in the holes of a sparse file, and a
+            // contiguous file is a regular file everywhere but where it
+            // was written.
+            e.Type, e.Size = File, hdr.Size
+            if e.Digest, err = a.c.SumReader(tr); err != nil {
+                return err
+            }
+        case tar.TypeXGlobalHeader:
+            // Other PAX and GNU extension headers are consumed by
+            // tar.Reader; a global header carries no tree content.
+            continue
+        default:
+            return fmt.Errorf("%s: unsupported entry type %q", hdr.Name, hdr.Typeflag)
+        }
+        a.add(e)
+    }
+}
+
+func (a *archiveListing) readZip(r io.ReaderAt, size int64) error {
+    zr, err := zip.NewReader(r, size)
+    if err != nil {
+        return err
+    }
+    for _, zf := range zr.File {
+        mode := zf.Mode()
+        e := TreeEntry{Path: cleanName(zf.Name), Mode: mode.Perm()}
+        switch {
+        case mode.IsDir():
+            e.Type = Dir
+        case mode&fs.ModeSymlink != 0:
+            target, err := readZipEntry(zf)
+            if err != nil {
+                return err
+            }
+            e.Type, e.Target = Symlink, string(target)
+        case mode.IsRegular():
+            rc, err := zf.Open()
+            if err != nil {
+                return err
+            }
+            e.Type, e.Size = File, int64(zf.UncompressedSize64)
+            e.Digest, err = a.c.SumReader(rc)
+            rc.Close()
+            if err != nil {
+                return err
+            }
+        default:
+            return fmt.Errorf("%s: unsupported entry type %v", zf.Name, mode.Type())
+        }
+        a.add(e)
+    }
+    return nil
+}
+
+// readZipEntry reads a small entry, such as a symlink target, in full.
+func readZipEntry(zf *zip.File) ([]byte, error) {
+    rc, err := zf.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer rc.Close()
+    b, err := io.ReadAll(io.LimitReader(rc, 4096+1))
+    if err == nil && len(b) > 4096 {
+        err = errors.New("symlink target too long")
+    }
+    return b, err
+}
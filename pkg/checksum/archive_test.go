@@ -0,0 +1,261 @@
+package checksum
+
+import (
+    "archive/tar"
+    "archive/zip"
+    "bytes"
+    "compress/gzip"
+    "fmt"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+// testTree is the tree every test archive holds, listed in a different
+// order by each writer.
+var testTree = []struct {
+    name, body, target string
+    mode               fs.FileMode
+}{
+    {name: "b/", mode: fs.ModeDir | 0o755},
+    {name: "b/c.txt", body: "nested\n", mode: 0o644},
+    {name: "a.txt", body: "top\n", mode: 0o755},
+    {name: "b/link", target: "c.txt", mode: fs.ModeSymlink | 0o777},
+}
+
+func writeTar(t *testing.T, gz bool, reverse bool) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    var zw *gzip.Writer
+    tw := tar.NewWriter(&buf)
+    if gz {
+        zw = gzip.NewWriter(&buf)
+        zw.ModTime = time.Unix(1234567, 0)
+        tw = tar.NewWriter(zw)
+    }
+    for i := range testTree {
+        e := testTree[i]
+        if reverse {
+            e = testTree[len(testTree)-1-i]
+        }
+        hdr := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), ModTime: time.Unix(int64(i)*1000, 0), Uid: i}
+        switch {
+        case e.mode.IsDir():
+            hdr.Typeflag = tar.TypeDir
+        case e.mode&fs.ModeSymlink != 0:
+            hdr.Typeflag, hdr.Linkname = tar.TypeSymlink, e.target
+        default:
+            hdr.Typeflag, hdr.Size = tar.TypeReg, int64(len(e.body))
+        }
+        if err := tw.WriteHeader(hdr); err != nil {
+            t.Fatal(err)
+        }
+        tw.Write([]byte(e.body))
+    }
+    if err := tw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    if zw != nil {
+        zw.Close()
+    }
+    return buf.Bytes()
+}
+
+func writeZip(t *testing.T, dirs bool) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    zw := zip.NewWriter(&buf)
+    for _, e := range testTree {
+        if e.mode.IsDir() && !dirs {
+            continue
+        }
+        hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: time.Now()}
+        hdr.SetMode(e.mode)
+        w, err := zw.CreateHeader(hdr)
+        if err != nil {
+            t.Fatal(err)
+        }
+        w.Write([]byte(e.body + e.target))
+    }
+    if err := zw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return buf.Bytes()
+}
+
+func writeTemp(t *testing.T, name string, data []byte) string {
+    t.Helper()
+    p := filepath.Join(t.TempDir(), name)
+    if err := os.WriteFile(p, data, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    return p
+}
+
+func TestArchiveFormatsAgree(t *testing.T) {
+    c := Config{Algorithm: SHA256}
+    archives := map[string][]byte{
+        "x.tar":          writeTar(t, false, false),
+        "reversed.tar":   writeTar(t, false, true),
+        "x.tar.gz":       writeTar(t, true, false),
+        "reversed.tgz":   writeTar(t, true, true),
+        "x.zip":          writeZip(t, true),
+        "no-dirs.zip":    writeZip(t, false),
+        "misnamed.zip.1": writeTar(t, true, true),
+    }
+    for _, modes := range []bool{false, true} {
+        var want []byte
+        for name, data := range archives {
+            got, err := c.SumArchive(writeTemp(t, name, data), ArchiveOptions{Modes: modes})
+            if err != nil {
+                t.Fatalf("%s: %v", name, err)
+            }
+            if want == nil {
+                want = got
+            } else if !bytes.Equal(got, want) {
+                t.Errorf("modes=%v: %s sums to %x, others to %x", modes, name, got, want)
+            }
+        }
+    }
+}
+
+func TestArchiveModes(t *testing.T) {
+    c := Config{Algorithm: SHA256}
+    p := writeTemp(t, "x.tar", writeTar(t, false, false))
+    with, err := c.SumArchive(p, ArchiveOptions{Modes: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    without, err := c.SumArchive(p, ArchiveOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if bytes.Equal(with, without) {
+        t.Error("file modes do not change the checksum")
+    }
+}
+
+func TestArchiveMatchesTree(t *testing.T) {
+    if err := os.Symlink("x", filepath.Join(t.TempDir(), "probe")); err != nil {
+        t.Skip("symlinks unsupported:", err)
+    }
+    dir := t.TempDir()
+    os.Mkdir(filepath.Join(dir, "b"), 0o755)
+    os.WriteFile(filepath.Join(dir, "a.txt"), []byte("top\n"), 0o755)
+    os.WriteFile(filepath.Join(dir, "b", "c.txt"), []byte("nested\n"), 0o644)
+    os.Symlink("c.txt", filepath.Join(dir, "b", "link"))
+    c := Config{Algorithm: SHA256}
+    want, err := c.SumTree(dir, TreeOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    got, err := c.SumArchive(writeTemp(t, "x.zip", writeZip(t, false)), ArchiveOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(got, want) {
+        t.Errorf("archive sums to %x, extracted tree to %x", got, want)
+    }
+}
+
+func TestArchiveDamaged(t *testing.T) {
+    tgz := writeTar(t, true, false)
+    tarball := writeTar(t, false, false)
+    zipped := writeZip(t, true)
+    corruptZip := bytes.Clone(zipped)
+    // Overwrite part of the central directory at the end of the file.
+    copy(corruptZip[len(corruptZip)-40:], bytes.Repeat([]byte{0xff}, 20))
+    badCRC := bytes.Clone(tgz)
+    badCRC[len(badCRC)-6] ^= 0xff
+    c := Config{Algorithm: SHA256}
+    for name, data := range map[string][]byte{
+        "truncated.tar.gz":  tgz[:len(tgz)/2],
+        "no-trailer.tar.gz": tgz[:len(tgz)-4],
+        "bad-crc.tar.gz":    badCRC,
+        "truncated.tar":     tarball[:700],
+        "corrupt.zip":       corruptZip,
+        "truncated.zip":     zipped[:len(zipped)/2],
+        "not-archive":       []byte("plain text"),
+    } {
+        if _, err := c.SumArchive(writeTemp(t, name, data), ArchiveOptions{}); err == nil {
+            t.Errorf("%s: no error", name)
+        } else if _, ok := err.(*InputError); !ok {
+            t.Errorf("%s: error %T is not an *InputError", name, err)
+        }
+    }
+}
+
+// tarOf writes a tar of the given headers, each followed by body.
+func tarOf(t *testing.T, body string, hdrs ...*tar.Header) []byte {
+    t.Helper()
+    var buf bytes.Buffer
+    tw := tar.NewWriter(&buf)
+    for _, hdr := range hdrs {
+        if err := tw.WriteHeader(hdr); err != nil {
+            t.Fatal(err)
+        }
+        tw.Write([]byte(body[:hdr.Size]))
+    }
+    if err := tw.Close(); err != nil {
+        t.Fatal(err)
+    }
+    return buf.Bytes()
+}
+
+// sparseTar is a tar holding one old-style GNU sparse file, "s", whose 3
+// stored bytes "abc" sit at offset 2 of 8.
+func sparseTar(t *testing.T) []byte {
+    t.Helper()
+    b := tarOf(t, "abc", &tar.Header{Name: "s", Typeflag: tar.TypeGNUSparse, Size: 3, Mode: 0o644, Format: tar.FormatGNU})
+    // tar.Writer cannot write a sparse map, so patch one into the header:
+    // the first map entry, the real size, and the header checksum.
+    copy(b[386:], fmt.Sprintf("%011o\x00%011o\x00", 2, 3))
+    copy(b[483:], fmt.Sprintf("%011o\x00", 8))
+    copy(b[148:], "        ")
+    sum := 0
+    for _, c := range b[:512] {
+        sum += int(c)
+    }
+    copy(b[148:], fmt.Sprintf("%06o\x00 ", sum))
+    return b
+}
+
+func TestArchiveTarTypes(t *testing.T) {
+    c := Config{Algorithm: SHA256}
+    sum := func(name string, data []byte) ([]TreeEntry, []byte) {
+        t.Helper()
+        entries, err := c.Archive(writeTemp(t, name, data), ArchiveOptions{})
+        if err != nil {
+            t.Fatalf("%s: %v", name, err)
+        }
+        return entries, c.TreeSum(entries)
+    }
+
+    reg := &tar.Header{Name: "f", Typeflag: tar.TypeReg, Size: 4, Mode: 0o644}
+    cont := &tar.Header{Name: "f", Typeflag: tar.TypeCont, Size: 4, Mode: 0o644}
+    _, regular := sum("reg.tar", tarOf(t, "body", reg))
+    if _, got := sum("cont.tar", tarOf(t, "body", cont)); !bytes.Equal(got, regular) {
+        t.Errorf("a contiguous file sums to %x, a regular one to %x", got, regular)
+    }
+    glob := &tar.Header{Typeflag: tar.TypeXGlobalHeader, PAXRecords: map[string]string{"comment": "x"}}
+    if _, got := sum("global.tar", tarOf(t, "body", glob, reg)); !bytes.Equal(got, regular) {
+        t.Errorf("a PAX global header changes the sum to %x", got)
+    }
+
+    entries, got := sum("sparse.tar", sparseTar(t))
+    _, want := sum("dense.tar", tarOf(t, "\x00\x00abc\x00\x00\x00", &tar.Header{Name: "s", Typeflag: tar.TypeReg, Size: 8, Mode: 0o644}))
+    if len(entries) != 1 || entries[0].Size != 8 || !bytes.Equal(got, want) {
+        t.Errorf("sparse file: %+v sums to %x, the same file stored densely to %x", entries, got, want)
+    }
+
+    for _, flag := range []byte{tar.TypeFifo, tar.TypeChar, tar.TypeBlock, 'V'} {
+        data := tarOf(t, "", &tar.Header{Name: "odd", Typeflag: flag})
+        _, err := c.Archive(writeTemp(t, "odd.tar", data), ArchiveOptions{})
+        if err == nil || !strings.Contains(err.Error(), "unsupported entry type") {
+            t.Errorf("entry type %q: error %v, want an unsupported entry", flag, err)
+        }
+    }
+}
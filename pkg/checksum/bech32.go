@@ -0,0 +1,160 @@
+package checksum
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// bech32 as specified by BIP-173 (not the bech32m variant of BIP-350).
+
+const (
+    bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+    // Bech32MaxLength is the longest string BIP-173 allows, including the
+    // human-readable part, separator and checksum.
+    Bech32MaxLength = 90
+)
+
+var errBech32Checksum = errors.New("bech32: invalid checksum")
+
+// Bech32Encode encodes data with the human-readable part hrp, such as "bc".
+// The result is lowercase. It fails if hrp is empty, contains characters
+// outside ASCII 33-126 or mixes case, or if the result would exceed
+// Bech32MaxLength characters.
+func Bech32Encode(hrp string, data []byte) (string, error) {
+    if err := checkBech32Case(hrp); err != nil {
+        return "", err
+    }
+    hrp = strings.ToLower(hrp)
+    if hrp == "" {
+        return "", errors.New("bech32: empty human-readable part")
+    }
+    values := convertBits(data, 8, 5, true)
+    if n := len(hrp) + 1 + len(values) + 6; n > Bech32MaxLength {
+        return "", fmt.Errorf("bech32: %d characters is over the %d character limit", n, Bech32MaxLength)
+    }
+    values = append(values, bech32Checksum(hrp, values)...)
+    var b strings.Builder
+    b.WriteString(hrp)
+    b.WriteByte('1')
+    for _, v := range values {
+        b.WriteByte(bech32Charset[v])
+    }
+    return b.String(), nil
+}
+
+// Bech32Decode splits a bech32 string into its lowercased human-readable
+// part and data, after checking its length, case and checksum. Upper-case
+// strings are accepted; mixed-case ones are not.
+func Bech32Decode(s string) (hrp string, data []byte, err error) {
+    if len(s) > Bech32MaxLength {
+        return "", nil, fmt.Errorf("bech32: %d characters is over the %d character limit", len(s), Bech32MaxLength)
+    }
+    if err := checkBech32Case(s); err != nil {
+        return "", nil, err
+    }
+    s = strings.ToLower(s)
+    sep := strings.LastIndexByte(s, '1')
+    switch {
+    case sep < 1:
+        return "", nil, errors.New("bech32: missing human-readable part")
+    case sep+7 > len(s):
+        return "", nil, errors.New("bech32: too short for a checksum")
+    }
+    hrp = s[:sep]
+    values := make([]byte, 0, len(s)-sep-1)
+    for i := sep + 1; i < len(s); i++ {
+        v := strings.IndexByte(bech32Charset, s[i])
+        if v < 0 {
+            return "", nil, fmt.Errorf("bech32: invalid character %q", s[i])
+        }
+        values = append(values, byte(v))
+    }
+    if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != 1 {
+        return "", nil, errBech32Checksum
+    }
+    values = values[:len(values)-6]
+    // The bits after the last whole byte are padding: fewer than five, all
+    // zero, and so all in the final value.
+    if pad := uint(len(values) * 5 % 8); pad >= 5 || pad > 0 && values[len(values)-1]&(1<<pad-1) != 0 {
+        return "", nil, errors.New("bech32: invalid padding")
+    }
+    return hrp, convertBits(values, 5, 8, false), nil
+}
+
+// checkBech32Case rejects characters outside ASCII 33-126 and strings that
+// mix upper and lower case.
+func checkBech32Case(s string) error {
+    var lower, upper bool
+    for i := 0; i < len(s); i++ {
+        c := s[i]
+        switch {
+        case c < 33 || c > 126:
+            return fmt.Errorf("bech32: invalid character %q", c)
+        case 'a' <= c && c <= 'z':
+            lower = true
+        case 'A' <= c && c <= 'Z':
+            upper = true
+        }
+    }
+    if lower && upper {
+        return errors.New("bech32: mixed case")
+    }
+    return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+    gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+    chk := uint32(1)
+    for _, v := range values {
+        top := chk >> 25
+        chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+        for i, g := range gen {
+            if top>>i&1 == 1 {
+                chk ^= g
+            }
+        }
+    }
+    return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+    out := make([]byte, 0, 2*len(hrp)+1)
+    for i := 0; i < len(hrp); i++ {
+        out = append(out, hrp[i]>>5)
+    }
+    out = append(out, 0)
+    for i := 0; i < len(hrp); i++ {
+        out = append(out, hrp[i]&31)
+    }
+    return out
+}
+
+func bech32Checksum(hrp string, values []byte) []byte {
+    mod := bech32Polymod(append(append(bech32HRPExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+    sum := make([]byte, 6)
+    for i := range sum {
+        sum[i] = byte(mod >> (5 * (5 - i)) & 31)
+    }
+    return sum
+}
+
+// convertBits regroups a stream of from-bit values into to-bit values. With
+// pad, leftover bits are zero-filled into a final value; otherwise they are
+// dropped, which callers must have checked are padding.
+func convertBits(data []byte, from, to uint, pad bool) []byte {
+    var acc, bits uint
+    out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+    for _, v := range data {
+        acc = acc<<from | uint(v)
+        bits += from
+        for bits >= to {
+            bits -= to
+            out = append(out, byte(acc>>bits&(1<<to-1)))
+        }
+    }
+    if pad && bits > 0 {
+        out = append(out, byte(acc<<(to-bits)&(1<<to-1)))
+    }
+    return out
+}
@@ -0,0 +1,100 @@
+package checksum
+
+import (
+    "bytes"
+    "math/rand/v2"
+    "strings"
+    "testing"
+)
+
+func TestBech32Vectors(t *testing.T) {
+    // The valid checksums from BIP-173 whose data is whole bytes.
+    for _, s := range []string{
+        "A12UEL5L",
+        "a12uel5l",
+        "an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+        "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+        "11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j",
+        "split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+        "?1ezyfcl",
+    } {
+        hrp, data, err := Bech32Decode(s)
+        if err != nil {
+            t.Errorf("Bech32Decode(%q): %v", s, err)
+            continue
+        }
+        if got, err := Bech32Encode(hrp, data); err != nil || got != strings.ToLower(s) {
+            t.Errorf("Bech32Encode(%q, %x) = %q, %v, want %q", hrp, data, got, err, strings.ToLower(s))
+        }
+    }
+
+    // The invalid strings from BIP-173.
+    for _, s := range []string{
+        "\x201nwldj5",
+        "\x7f1axkwrx",
+        "\x801eym55h",
+        "an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx",
+        "pzry9x0s0muk",
+        "1pzry9x0s0muk",
+        "x1b4n0q5v",
+        "li1dgmt3",
+        "de1lg7wt\xff",
+        "A1G7SGD8",
+        "10a06t8",
+        "1qzzfhee",
+    } {
+        if hrp, data, err := Bech32Decode(s); err == nil {
+            t.Errorf("Bech32Decode(%q) = %q, %x, want an error", s, hrp, data)
+        }
+    }
+}
+
+func TestBech32RoundTrip(t *testing.T) {
+    r := rand.New(rand.NewPCG(1, 2))
+    // "bc1" plus six checksum characters leaves 81 for data: 50 bytes.
+    for n := 0; n <= 50; n++ {
+        data := make([]byte, n)
+        for i := range data {
+            data[i] = byte(r.Uint32())
+        }
+        s, err := Bech32Encode("bc", data)
+        if err != nil {
+            t.Fatalf("Bech32Encode %d bytes: %v", n, err)
+        }
+        if len(s) > Bech32MaxLength {
+            t.Errorf("%d bytes encoded to %d characters", n, len(s))
+        }
+        for _, in := range []string{s, strings.ToUpper(s)} {
+            hrp, got, err := Bech32Decode(in)
+            if err != nil || hrp != "bc" || !bytes.Equal(got, data) {
+                t.Errorf("Bech32Decode(%q) = %q, %x, %v, want bc, %x", in, hrp, got, err, data)
+            }
+        }
+        // Any single changed character breaks the checksum.
+        i := 3 + r.IntN(len(s)-3)
+        c := bech32Charset[(strings.IndexByte(bech32Charset, s[i])+1+r.IntN(31))%32]
+        if _, _, err := Bech32Decode(s[:i] + string(c) + s[i+1:]); err == nil {
+            t.Errorf("Bech32Decode accepted %q with character %d changed", s, i)
+        }
+    }
+    if s, err := Bech32Encode("bc", make([]byte, 51)); err == nil {
+        t.Errorf("Bech32Encode 51 bytes = %q, want an error", s)
+    }
+}
+
+func TestBech32Case(t *testing.T) {
+    s, _ := Bech32Encode("bc", []byte("case"))
+    mixed := strings.ToUpper(s[:1]) + s[1:]
+    if _, _, err := Bech32Decode(mixed); err == nil {
+        t.Errorf("Bech32Decode accepted mixed case %q", mixed)
+    }
+    if _, err := Bech32Encode("Bc", nil); err == nil {
+        t.Error("Bech32Encode accepted a mixed-case prefix")
+    }
+    if s, err := Bech32Encode("BC", []byte("case")); err != nil || s != strings.ToLower(s) {
+        t.Errorf("Bech32Encode(BC) = %q, %v, want lower case", s, err)
+    }
+    if _, err := Bech32Encode("", nil); err == nil {
+        t.Error("Bech32Encode accepted an empty prefix")
+    }
+}
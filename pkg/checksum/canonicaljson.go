@@ -0,0 +1,184 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "slices"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+)
+
+// ErrInvalidJSON is wrapped by the errors CanonicalJSON returns for input
+// that is not a single valid JSON document.
+var ErrInvalidJSON = errors.New("invalid JSON")
+
+// CanonicalJSON re-serializes a JSON document in the canonical form of RFC
+// 8785 (JCS): object members sorted by the UTF-16 code units of their
+// names, no insignificant whitespace, strings with only the mandatory
+// escapes and numbers formatted as ECMAScript prints doubles. Duplicate
+// member names and numbers outside the double range are rejected, as
+// I-JSON requires. Invalid UTF-8 is replaced rather than rejected.
+func CanonicalJSON(data []byte) ([]byte, error) {
+    dec := json.NewDecoder(bytes.NewReader(data))
+    dec.UseNumber()
+    v, err := decodeJSONValue(dec)
+    if err == nil {
+        if _, err = dec.Token(); err == io.EOF {
+            err = nil
+        } else if err == nil {
+            err = errors.New("data after the end of the document")
+        }
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+    }
+    var b bytes.Buffer
+    if err := writeCanonicalJSON(&b, v); err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+    }
+    return b.Bytes(), nil
+}
+
+// decodeJSONValue reads one value token by token, so duplicate member
+// names can be detected.
+func decodeJSONValue(dec *json.Decoder) (any, error) {
+    tok, err := dec.Token()
+    if err == io.EOF {
+        return nil, io.ErrUnexpectedEOF
+    } else if err != nil {
+        return nil, err
+    }
+    switch tok {
+    case json.Delim('{'):
+        obj := make(map[string]any)
+        for dec.More() {
+            tok, err := dec.Token()
+            if err != nil {
+                return nil, err
+            }
+            name := tok.(string)
+            if _, dup := obj[name]; dup {
+                return nil, fmt.Errorf("duplicate member name %q", name)
+            }
+            if obj[name], err = decodeJSONValue(dec); err != nil {
+                return nil, err
+            }
+        }
+        _, err := dec.Token()
+        return obj, err
+    case json.Delim('['):
+        arr := []any{}
+        for dec.More() {
+            v, err := decodeJSONValue(dec)
+            if err != nil {
+                return nil, err
+            }
+            arr = append(arr, v)
+        }
+        _, err := dec.Token()
+        return arr, err
+    }
+    return tok, nil
+}
+
+func writeCanonicalJSON(b *bytes.Buffer, v any) error {
+    switch v := v.(type) {
+    case nil:
+        b.WriteString("null")
+    case bool:
+        b.WriteString(strconv.FormatBool(v))
+    case string:
+        writeCanonicalString(b, v)
+    case json.Number:
+        s, err := canonicalNumber(v)
+        if err != nil {
+            return err
+        }
+        b.WriteString(s)
+    case []any:
+        b.WriteByte('[')
+        for i, e := range v {
+            if i > 0 {
+                b.WriteByte(',')
+            }
+            if err := writeCanonicalJSON(b, e); err != nil {
+                return err
+            }
+        }
+        b.WriteByte(']')
+    case map[string]any:
+        names := make([]string, 0, len(v))
+        for name := range v {
+            names = append(names, name)
+        }
+        slices.SortFunc(names, func(a, b string) int {
+            return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+        })
+        b.WriteByte('{')
+        for i, name := range names {
+            if i > 0 {
+                b.WriteByte(',')
+            }
+            writeCanonicalString(b, name)
+            b.WriteByte(':')
+            if err := writeCanonicalJSON(b, v[name]); err != nil {
+                return err
+            }
+        }
+        b.WriteByte('}')
+    }
+    return nil
+}
+
+func writeCanonicalString(b *bytes.Buffer, s string) {
+    b.WriteByte('"')
+    for _, r := range s {
+        switch r {
+        case '"':
+            b.WriteString(`\"`)
+        case '\\':
+            b.WriteString(`\\`)
+        case '\b':
+            b.WriteString(`\b`)
+        case '\f':
+            b.WriteString(`\f`)
+        case '\n':
+            b.WriteString(`\n`)
+        case '\r':
+            b.WriteString(`\r`)
+        case '\t':
+            b.WriteString(`\t`)
+        default:
+            if r < 0x20 {
+                fmt.Fprintf(b, `\u%04x`, r)
+            } else {
+                b.WriteRune(r)
+            }
+        }
+    }
+    b.WriteByte('"')
+}
+
+// canonicalNumber formats n as ECMAScript's Number.prototype.toString does:
+// the shortest representation that round-trips, in plain decimal from 1e-6
+// up to 1e21 and in exponent form outside that range.
+func canonicalNumber(n json.Number) (string, error) {
+    f, err := strconv.ParseFloat(string(n), 64)
+    if err != nil || math.IsInf(f, 0) {
+        return "", fmt.Errorf("number %s is outside the range of a double", n)
+    }
+    if f == 0 {
+        return "0", nil
+    }
+    if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+        return strconv.FormatFloat(f, 'f', -1, 64), nil
+    }
+    mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+    sign, digits := exp[:1], strings.TrimLeft(exp[1:], "0")
+    return mantissa + "e" + sign + digits, nil
+}
@@ -0,0 +1,68 @@
+package checksum
+
+import (
+    "errors"
+    "strings"
+    "testing"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+    for _, tt := range []struct{ in, want string }{
+        {`{"b":1,"a":2}`, `{"a":2,"b":1}`},
+        {"{ \"a\": 2,\n\t\"b\": 1 }\n", `{"a":2,"b":1}`},
+        {`[1, {"z": [], "y": {}}, "x"]`, `[1,{"y":{},"z":[]},"x"]`},
+        // The example of RFC 8785, section 3.2.2.
+        {
+            `{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001], "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/", "literals": [null, true, false]}`,
+            `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+        },
+        // Members sort by UTF-16 code units, which puts a surrogate pair
+        // before U+FB33 although its UTF-8 sorts after (RFC 8785, 3.2.3).
+        {
+            `{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`,
+            "{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001F600\":5,\"\ufb33\":3}",
+        },
+        {`[0, -0, 1e21, 1e-7, 123456789012345680000, 0.1]`, `[0,0,1e+21,1e-7,123456789012345680000,0.1]`},
+    } {
+        got, err := CanonicalJSON([]byte(tt.in))
+        if err != nil || string(got) != tt.want {
+            t.Errorf("CanonicalJSON(%s) = %s, %v, want %s", tt.in, got, err, tt.want)
+        }
+    }
+}
+
+func TestCanonicalJSONInvalid(t *testing.T) {
+    for _, in := range []string{
+        ``,
+        `{"a":`,
+        `{"a":1,"a":2}`,
+        `{"a":1} {"b":2}`,
+        `[1e400]`,
+        `{'a':1}`,
+        `[1,]`,
+    } {
+        if got, err := CanonicalJSON([]byte(in)); !errors.Is(err, ErrInvalidJSON) {
+            t.Errorf("CanonicalJSON(%q) = %s, %v, want ErrInvalidJSON", in, got, err)
+        }
+    }
+}
+
+func TestCanonicalJSONSum(t *testing.T) {
+    c := Config{Normalize: NormalizeJSON}
+    sum := func(doc string) string {
+        d, err := c.SumReader(strings.NewReader(doc))
+        if err != nil {
+            t.Fatalf("SumReader(%s): %v", doc, err)
+        }
+        return string(d)
+    }
+    if sum(`{"b":1,"a":2}`) != sum(`{ "a": 2, "b": 1 }`) {
+        t.Error("reordered members hash differently")
+    }
+    if sum(`{"b":1,"a":2}`) == sum(`{"b":1,"a":3}`) {
+        t.Error("a changed value hashes the same")
+    }
+    if _, err := c.SumReader(strings.NewReader(`{"b":1,`)); !errors.Is(err, ErrInvalidJSON) {
+        t.Errorf("SumReader of invalid JSON: %v", err)
+    }
+}
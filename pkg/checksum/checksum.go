@@ -0,0 +1,263 @@
+// Package checksum computes the checksums printed by randomtool.
+//
+// A checksum covers a list of parts. Every part is followed by its length as
+// an 8-byte big-endian value, so no two different part lists hash the same
+// byte stream. The length trails the part rather than leading it so a part
+// can be streamed into the hash before its size is known. Legacy mode
+// reproduces the original plain concatenation.
+package checksum
+
+import (
+    "crypto/hmac"
+    "encoding/binary"
+    "encoding/hex"
+    "hash"
+    "io"
+    "os"
+)
+
+// Config describes how a checksum is computed. The zero value is framed
+// SHA-1.
+type Config struct {
+    Algorithm Algorithm
+    // Key, when non-nil, switches to an HMAC over Algorithm.
+    Key []byte
+    // Legacy disables part framing.
+    Legacy bool
+    // Salt, when non-nil, is prepended to the input: as a leading part for
+    // framed checksums and as raw bytes for SumReader.
+    Salt []byte
+    // Normalize is applied to everything SumReader and Writer.ReadPart
+    // read. Bytes passed to Sum or Writer.Write are hashed as they are.
+    Normalize Normalization
+    // Also lists further algorithms computed in the same pass over the
+    // input, keyed alike. Every digest is then the digest of Algorithm
+    // followed by one for each of Also; Split separates them.
+    Also []Algorithm
+}
+
+// NewHash returns the bare hash state for c, without any part framing. With
+// Also, it is a *MultiHasher.
+func (c Config) NewHash() hash.Hash {
+    if len(c.Also) > 0 {
+        hashes := []hash.Hash{c.single(c.Algorithm).NewHash()}
+        for _, a := range c.Also {
+            hashes = append(hashes, c.single(a).NewHash())
+        }
+        return NewMultiHasher(hashes...)
+    }
+    if c.Key != nil {
+        return hmac.New(c.Algorithm.New, c.Key)
+    }
+    return c.Algorithm.New()
+}
+
+// single returns c computing only a.
+func (c Config) single(a Algorithm) Config {
+    c.Algorithm, c.Also = a, nil
+    return c
+}
+
+// Algorithms returns Algorithm followed by those of Also.
+func (c Config) Algorithms() []Algorithm {
+    return append([]Algorithm{c.Algorithm}, c.Also...)
+}
+
+// Split returns a digest computed under c as one digest per algorithm, in
+// the order of Algorithms. Each has c's key and settings.
+func (c Config) Split(digest []byte) [][]byte {
+    var digests [][]byte
+    for _, a := range c.Algorithms() {
+        n := min(a.Size(), len(digest))
+        digests = append(digests, digest[:n:n])
+        digest = digest[n:]
+    }
+    return digests
+}
+
+// Name describes the hash function, such as "sha256" or "hmac-sha256",
+// joining the names with "+" when there are further algorithms in Also.
+func (c Config) Name() string {
+    name := c.Algorithm.String()
+    for _, a := range c.Also {
+        name += "+" + a.String()
+    }
+    if c.Key != nil {
+        return "hmac-" + name
+    }
+    return name
+}
+
+// Size is the length of c's digest in bytes, the sum of all algorithms'
+// with Also.
+func (c Config) Size() int {
+    n := c.Algorithm.Size()
+    for _, a := range c.Also {
+        n += a.Size()
+    }
+    return n
+}
+
+// Sum returns the full digest of parts under c.
+func (c Config) Sum(parts ...[]byte) []byte {
+    w := NewWriter(c)
+    for _, p := range parts {
+        w.Write(p)
+        w.EndPart()
+    }
+    return w.Sum(nil)
+}
+
+// Sum returns the default (framed SHA-1) digest of parts.
+func Sum(parts ...[]byte) []byte {
+    return Config{}.Sum(parts...)
+}
+
+// chunkSize is the read size used when streaming readers into a hash.
+const chunkSize = 64 << 10
+
+// SumReader hashes everything read from r as raw data, without part framing,
+// so the result matches what sha1sum and friends print for the same bytes.
+func (c Config) SumReader(r io.Reader) ([]byte, error) {
+    digest, _, err := c.SumReaderWith(r, ReadOptions{})
+    return digest, err
+}
+
+// SumFile is SumReader over the contents of the named file. Failures are
+// reported as an *InputError.
+func (c Config) SumFile(path string) ([]byte, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, inputError(path, err)
+    }
+    defer f.Close()
+    digest, err := c.SumReader(f)
+    return digest, inputError(path, err)
+}
+
+// Writer accumulates a checksum part by part. Bytes written with Write belong
+// to the current part until EndPart is called.
+type Writer struct {
+    h      hash.Hash
+    alg    Algorithm
+    norm   Normalization
+    legacy bool
+    salt   []byte
+    n      uint64
+    open   bool
+
+    trace func(PartTrace)
+    part  hash.Hash // the current part alone, while tracing
+    index int
+}
+
+// PartTrace describes a part closed by a Writer that is being traced.
+type PartTrace struct {
+    Index int // position of the part, from 0; a salt is not counted
+    Bytes uint64
+    // Digest is the plain, unkeyed digest of the part's bytes alone, as
+    // sha1sum and friends would print it for the same data.
+    Digest []byte
+}
+
+// NewWriter returns a Writer with no parts yet.
+func NewWriter(c Config) *Writer {
+    w := &Writer{h: c.NewHash(), alg: c.Algorithm, norm: c.Normalize, legacy: c.Legacy, salt: c.Salt}
+    w.writeSalt()
+    return w
+}
+
+func (w *Writer) writeSalt() {
+    if w.salt != nil {
+        trace := w.trace
+        w.trace = nil
+        w.Write(w.salt)
+        w.EndPart()
+        w.trace = trace
+    }
+}
+
+// Trace calls fn each time a part is closed from now on. The part's own
+// digest comes from a second hash state fed the same bytes as they are
+// written, so the data is still read only once.
+func (w *Writer) Trace(fn func(PartTrace)) {
+    w.trace = fn
+    w.part = w.alg.New()
+}
+
+// Reset discards all parts written so far, reusing the hash state. The
+// salt, if any, is kept.
+func (w *Writer) Reset() {
+    w.h.Reset()
+    w.n = 0
+    w.open = false
+    w.index = 0
+    if w.part != nil {
+        w.part.Reset()
+    }
+    w.writeSalt()
+}
+
+// Write appends p to the current part. It never returns an error.
+func (w *Writer) Write(p []byte) (int, error) {
+    w.open = true
+    w.n += uint64(len(p))
+    if w.trace != nil {
+        w.part.Write(p)
+    }
+    return w.h.Write(p)
+}
+
+// ReadPart streams r, normalized as the Config asked, into the Writer as one
+// complete part and returns the number of bytes hashed.
+func (w *Writer) ReadPart(r io.Reader) (int64, error) {
+    n, err := io.CopyBuffer(w, struct{ io.Reader }{w.norm.Reader(r)}, make([]byte, chunkSize))
+    if err != nil {
+        return n, err
+    }
+    w.EndPart()
+    return n, nil
+}
+
+// Pending reports whether bytes have been written to a part that has not
+// been closed yet.
+func (w *Writer) Pending() bool {
+    return w.open
+}
+
+// EndPart closes the current part, which may be empty.
+func (w *Writer) EndPart() {
+    if !w.legacy {
+        var n [8]byte
+        binary.BigEndian.PutUint64(n[:], w.n)
+        w.h.Write(n[:])
+    }
+    if w.trace != nil {
+        w.trace(PartTrace{Index: w.index, Bytes: w.n, Digest: w.part.Sum(nil)})
+        w.part.Reset()
+        w.index++
+    }
+    w.n = 0
+    w.open = false
+}
+
+// Sum closes any part still open and appends the full digest to b.
+func (w *Writer) Sum(b []byte) []byte {
+    if w.open {
+        w.EndPart()
+    }
+    return w.h.Sum(b)
+}
+
+// SumHex closes any part still open and returns the first n hex characters
+// of the digest, or all of them when n is 0 or exceeds the digest length.
+func (w *Writer) SumHex(n int) string {
+    return truncate(hex.EncodeToString(w.Sum(nil)), n)
+}
+
+func truncate(s string, n int) string {
+    if n > 0 && n < len(s) {
+        return s[:n]
+    }
+    return s
+}
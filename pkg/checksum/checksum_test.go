@@ -0,0 +1,111 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "strings"
+    "testing"
+)
+
+func parts(s ...string) [][]byte {
+    var p [][]byte
+    for _, x := range s {
+        p = append(p, []byte(x))
+    }
+    return p
+}
+
+func TestSaltSumReader(t *testing.T) {
+    salt := []byte{0x00, 0x11, 0x22, 0x33}
+    got, err := Config{Algorithm: SHA256, Salt: salt}.SumReader(strings.NewReader("data"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    // For raw input the salt is a plain prefix.
+    want, _ := Config{Algorithm: SHA256}.SumReader(strings.NewReader("\x00\x11\x22\x33data"))
+    if !bytes.Equal(got, want) {
+        t.Errorf("salted SumReader = %x, want %x", got, want)
+    }
+}
+
+func TestFraming(t *testing.T) {
+    for _, tt := range []struct {
+        parts          [][]byte
+        framed, legacy string
+    }{
+        {nil, "da39a3ee5e6b4b0d3255bfef95601890afd80709", "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+        {parts(""), "05fe405753166f125559e7c9ac558654f107c7e9", "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+        {parts("", ""), "e129f27c5103bc5cc44bcdf0a15e160d445066ff", "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+        {parts("a", "b", "c"), "dcb7a83334052d8982fbf9b2b21310abee7b9a6f", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+        {parts("ab", "c"), "2a4df762ee2ef665909f0ec16107b3ec89d7ebda", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+        {parts("a", "bc"), "0667d744d4977bbeaa8660ebe8bb0ee39487bf68", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+        {parts("a", "b"), "54ce14f4ab7bb2e76780dd33d6d8c0c906bd0c34", "da23614e02469a0d7c7bd1bdab5c9c474b1904dc"},
+        // One part holding what the framing of "a", "b" would write between them.
+        {parts("a\x00\x00\x00\x00\x00\x00\x00\x01b"), "f3d13077179550f5eb22234b575573b9bb8cf80d", "8c12b96c8884a67cad1675090d65eb85d54fa06b"},
+    } {
+        if got := hex.EncodeToString(Sum(tt.parts...)); got != tt.framed {
+            t.Errorf("Sum(%q) = %s, want %s", tt.parts, got, tt.framed)
+        }
+        if got := hex.EncodeToString(Config{Legacy: true}.Sum(tt.parts...)); got != tt.legacy {
+            t.Errorf("legacy Sum(%q) = %s, want %s", tt.parts, got, tt.legacy)
+        }
+    }
+}
+
+func TestWriter(t *testing.T) {
+    c := Config{Algorithm: SHA256}
+    want := c.Sum(parts("ab", "", "c")...)
+
+    w := NewWriter(c)
+    w.Write([]byte("a"))
+    w.Write([]byte("b"))
+    w.EndPart()
+    w.EndPart()
+    if _, err := w.ReadPart(strings.NewReader("c")); err != nil {
+        t.Fatal(err)
+    }
+    if w.Pending() {
+        t.Error("Pending after ReadPart")
+    }
+    if got := w.Sum(nil); !bytes.Equal(got, want) {
+        t.Errorf("Writer gives %x, Sum %x", got, want)
+    }
+
+    w.Reset()
+    w.Write([]byte("c"))
+    if !w.Pending() {
+        t.Error("not Pending after Write")
+    }
+    if got, want := w.SumHex(12), hex.EncodeToString(c.Sum(parts("c")...))[:12]; got != want {
+        t.Errorf("SumHex(12) after Reset = %s, want %s", got, want)
+    }
+    if got := NewWriter(c).SumHex(1000); len(got) != 64 {
+        t.Errorf("SumHex(1000) = %q, want the full digest", got)
+    }
+}
+
+func TestSalt(t *testing.T) {
+    salt := []byte{0x00, 0x11, 0x22, 0x33}
+    c := Config{Algorithm: SHA256, Salt: salt}
+    want := c.Sum([]byte("user@example.com"))
+    if got := (Config{Algorithm: SHA256, Salt: bytes.Clone(salt)}).Sum([]byte("user@example.com")); !bytes.Equal(got, want) {
+        t.Errorf("same salt gives %x, then %x", want, got)
+    }
+    // The salt is hashed as a leading part of its own.
+    if got := (Config{Algorithm: SHA256}).Sum(salt, []byte("user@example.com")); !bytes.Equal(got, want) {
+        t.Errorf("salted checksum %x differs from the salt as a leading part, %x", want, got)
+    }
+    for _, other := range []Config{
+        {Algorithm: SHA256},
+        {Algorithm: SHA256, Salt: []byte{0x00, 0x11, 0x22, 0x34}},
+    } {
+        if bytes.Equal(other.Sum([]byte("user@example.com")), want) {
+            t.Errorf("salt %x gives the same checksum as %x", other.Salt, salt)
+        }
+    }
+    w := NewWriter(c)
+    w.Write([]byte("user@example.com"))
+    if got := w.Sum(nil); !bytes.Equal(got, want) {
+        t.Errorf("Writer with salt gives %x, Sum %x", got, want)
+    }
+}
@@ -0,0 +1,141 @@
+package checksum
+
+import (
+    "encoding"
+    "encoding/binary"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "hash"
+    "sync"
+)
+
+// Checksummer computes framed checksums of in-memory parts with as little
+// allocation as possible, for callers that compute very many small ones.
+// Unlike Writer, it has no notion of an open part, so Sum does not change
+// its state and more parts may follow. A Checksummer is not safe for
+// concurrent use; GetChecksummer and PutChecksummer are.
+type Checksummer struct {
+    c   Config
+    h   hash.Hash
+    len [8]byte
+    sum []byte
+    hex []byte
+}
+
+// NewChecksummer returns a Checksummer with no parts yet. Normalize is not
+// applied to parts.
+func NewChecksummer(c Config) *Checksummer {
+    s := &Checksummer{c: c, h: c.NewHash()}
+    s.writeSalt()
+    return s
+}
+
+func (s *Checksummer) writeSalt() {
+    if s.c.Salt != nil {
+        s.WritePart(s.c.Salt)
+    }
+}
+
+// WritePart appends p as one complete part.
+func (s *Checksummer) WritePart(p []byte) {
+    s.h.Write(p)
+    if !s.c.Legacy {
+        binary.BigEndian.PutUint64(s.len[:], uint64(len(p)))
+        s.h.Write(s.len[:])
+    }
+}
+
+// Sum appends the full digest of the parts so far to b.
+func (s *Checksummer) Sum(b []byte) []byte {
+    return s.h.Sum(b)
+}
+
+// AppendSumHex appends the first n hex characters of the digest to dst, or
+// all of them when n is 0 or exceeds the digest length. It does not
+// allocate once dst has room.
+func (s *Checksummer) AppendSumHex(dst []byte, n int) []byte {
+    s.sum = s.h.Sum(s.sum[:0])
+    start := len(dst)
+    dst = hex.AppendEncode(dst, s.sum)
+    if n > 0 && n < len(dst)-start {
+        dst = dst[:start+n]
+    }
+    return dst
+}
+
+// SumHexN is AppendSumHex as a string. The hex is built in a buffer kept
+// by s, so the returned string is the only allocation.
+func (s *Checksummer) SumHexN(n int) string {
+    s.hex = s.AppendSumHex(s.hex[:0], n)
+    return string(s.hex)
+}
+
+// Reset discards all parts, reusing the hash state. The salt, if any, is
+// written again.
+func (s *Checksummer) Reset() {
+    s.h.Reset()
+    s.writeSalt()
+}
+
+// Clone returns an independent copy of s, so parts common to several
+// checksums can be hashed once and each continued on its own. It fails
+// only for HMACs over hashes that cannot be cloned, such as blake2b-256.
+func (s *Checksummer) Clone() (*Checksummer, error) {
+    h, err := cloneHash(s.h, s.c)
+    if err != nil {
+        return nil, fmt.Errorf("checksum: cloning %s: %w", s.c.Name(), err)
+    }
+    return &Checksummer{c: s.c, h: h}, nil
+}
+
+func cloneHash(h hash.Hash, c Config) (hash.Hash, error) {
+    if cl, ok := h.(hash.Cloner); ok {
+        return cl.Clone()
+    }
+    // blake2b only marshals its state.
+    m, ok := h.(encoding.BinaryMarshaler)
+    if !ok || c.Key != nil {
+        return nil, errors.ErrUnsupported
+    }
+    state, err := m.MarshalBinary()
+    if err != nil {
+        return nil, err
+    }
+    clone := c.NewHash()
+    if err := clone.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+        return nil, err
+    }
+    return clone, nil
+}
+
+// checksummerPools holds unkeyed Checksummers by algorithm. Keyed ones are
+// not pooled, since the key is part of their hash state, and neither are
+// those computing several algorithms.
+var checksummerPools [len(algorithms)]sync.Pool
+
+func pooled(c Config) bool {
+    return c.Key == nil && len(c.Also) == 0 && int(c.Algorithm) < len(checksummerPools)
+}
+
+// GetChecksummer returns a Checksummer for c with no parts yet, reusing
+// one given to PutChecksummer when it can.
+func GetChecksummer(c Config) *Checksummer {
+    if pooled(c) {
+        if s, ok := checksummerPools[c.Algorithm].Get().(*Checksummer); ok {
+            s.c = c
+            s.Reset()
+            return s
+        }
+    }
+    return NewChecksummer(c)
+}
+
+// PutChecksummer hands s back for reuse by GetChecksummer. s must not be
+// used afterwards.
+func PutChecksummer(s *Checksummer) {
+    if pooled(s.c) {
+        s.c.Salt = nil
+        checksummerPools[s.c.Algorithm].Put(s)
+    }
+}
@@ -0,0 +1,107 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "testing"
+)
+
+func TestChecksummer(t *testing.T) {
+    for _, c := range []Config{
+        {},
+        {Algorithm: SHA256, Salt: []byte("salt")},
+        {Algorithm: BLAKE2b256, Legacy: true},
+        {Algorithm: SHA256, Key: []byte("key")},
+        {Algorithm: SHA1, Also: []Algorithm{XXHash64}},
+    } {
+        want := c.Sum(parts("a", "bc", "")...)
+        s := GetChecksummer(c)
+        for _, p := range parts("a", "bc", "") {
+            s.WritePart(p)
+        }
+        if got := s.Sum(nil); !bytes.Equal(got, want) {
+            t.Errorf("%s: Sum = %x, want %x", c.Name(), got, want)
+        }
+        // Sum leaves the state alone.
+        if got := s.SumHexN(0); got != hex.EncodeToString(want) {
+            t.Errorf("%s: SumHexN(0) = %s, want %x", c.Name(), got, want)
+        }
+        if got := string(s.AppendSumHex([]byte("x:"), 12)); got != "x:"+hex.EncodeToString(want)[:12] {
+            t.Errorf("%s: AppendSumHex = %s", c.Name(), got)
+        }
+
+        clone, err := s.Clone()
+        if err != nil {
+            t.Fatalf("%s: Clone: %v", c.Name(), err)
+        }
+        clone.WritePart([]byte("d"))
+        if got := s.Sum(nil); !bytes.Equal(got, want) {
+            t.Errorf("%s: writing to the clone changed the original", c.Name())
+        }
+        if got, want := clone.Sum(nil), c.Sum(parts("a", "bc", "", "d")...); !bytes.Equal(got, want) {
+            t.Errorf("%s: clone Sum = %x, want %x", c.Name(), got, want)
+        }
+
+        s.Reset()
+        if got, want := s.Sum(nil), c.Sum(); !bytes.Equal(got, want) {
+            t.Errorf("%s: after Reset = %x, want %x", c.Name(), got, want)
+        }
+        PutChecksummer(s)
+    }
+}
+
+func TestChecksummerPool(t *testing.T) {
+    s := GetChecksummer(Config{Algorithm: SHA256, Salt: []byte("salt")})
+    s.WritePart([]byte("left over"))
+    PutChecksummer(s)
+    // Whether or not the pool hands s back, nothing of it may show.
+    s = GetChecksummer(Config{Algorithm: SHA256})
+    if got, want := s.Sum(nil), (Config{Algorithm: SHA256}).Sum(); !bytes.Equal(got, want) {
+        t.Errorf("pooled Checksummer kept state: %x, want %x", got, want)
+    }
+    PutChecksummer(s)
+}
+
+func TestChecksummerAllocs(t *testing.T) {
+    part := []byte("a small part")
+    for _, a := range Algorithms() {
+        c := Config{Algorithm: a}
+        PutChecksummer(GetChecksummer(c))
+        dst := make([]byte, 0, 128)
+        allocs := testing.AllocsPerRun(100, func() {
+            s := GetChecksummer(c)
+            s.WritePart(part)
+            s.WritePart(part)
+            dst = s.AppendSumHex(dst[:0], 16)
+            PutChecksummer(s)
+        })
+        if allocs != 0 {
+            t.Errorf("%s: %v allocations per checksum, want 0", a, allocs)
+        }
+    }
+}
+
+func BenchmarkChecksummer(b *testing.B) {
+    part := []byte("a small part")
+    for _, a := range []Algorithm{SHA1, SHA256, XXHash64} {
+        c := Config{Algorithm: a}
+        b.Run(a.String()+"/pooled", func(b *testing.B) {
+            b.ReportAllocs()
+            dst := make([]byte, 0, 128)
+            for b.Loop() {
+                s := GetChecksummer(c)
+                s.WritePart(part)
+                dst = s.AppendSumHex(dst[:0], 16)
+                PutChecksummer(s)
+            }
+        })
+        b.Run(a.String()+"/writer", func(b *testing.B) {
+            b.ReportAllocs()
+            for b.Loop() {
+                w := NewWriter(c)
+                w.Write(part)
+                w.SumHex(16)
+            }
+        })
+    }
+}
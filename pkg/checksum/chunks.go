@@ -0,0 +1,182 @@
+package checksum
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "math/bits"
+    "slices"
+)
+
+// Chunk is one piece of a stream split by Config.Chunks.
+type Chunk struct {
+    Offset int64
+    Length int64
+    Digest []byte
+}
+
+// ChunkOptions selects how Config.Chunks splits its input. Without CDC,
+// every chunk but the last is Size bytes. With CDC, boundaries are placed
+// where a rolling hash of the last bytes matches a pattern, so an
+// insertion near the start only changes the chunks around it; chunks are
+// then between Min and Max bytes and average about Min plus Size rounded
+// up to a power of two.
+type ChunkOptions struct {
+    Size int64
+    CDC  bool
+    Min  int64
+    Max  int64
+}
+
+// buzhashWindow is the number of bytes the rolling hash covers.
+const buzhashWindow = 48
+
+// buzhashTable maps bytes to random values for the rolling hash. It is
+// generated from a fixed seed because every CDC boundary depends on it:
+// changing it changes the chunks of every file.
+var buzhashTable = func() (t [256]uint64) {
+    x := uint64(0x72616e646f6d746f) // splitmix64
+    for i := range t {
+        x += 0x9e3779b97f4a7c15
+        z := x
+        z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+        z = (z ^ z>>27) * 0x94d049bb133111eb
+        t[i] = z ^ z>>31
+    }
+    return t
+}()
+
+// MaxChunkSize is the largest Size, Min or Max that ChunkOptions allow.
+// CDC chunks are buffered whole, so this bounds the memory they take.
+const MaxChunkSize = 256 << 20
+
+func (o ChunkOptions) check() error {
+    switch {
+    case o.Size < 1:
+        return errors.New("checksum: chunk size must be positive")
+    case o.CDC && (o.Min < 1 || o.Max < o.Min || o.Max < o.Size):
+        return errors.New("checksum: CDC chunks need 0 < min <= max and max >= size")
+    case o.Size > MaxChunkSize || o.Max > MaxChunkSize:
+        return fmt.Errorf("checksum: chunks are at most %d bytes", MaxChunkSize)
+    }
+    return nil
+}
+
+// Chunks splits r into chunks, calling fn with the raw digest of each in
+// order, and returns the digest of the whole stream, which matches
+// SumReader. All digests are computed in a single pass over r. Salt and
+// Normalize are not applied.
+func (c Config) Chunks(r io.Reader, opts ChunkOptions, fn func(Chunk) error) ([]byte, error) {
+    if err := opts.check(); err != nil {
+        return nil, err
+    }
+    if opts.CDC {
+        return c.cdcChunks(r, opts, fn)
+    }
+    // Fixed-size chunks are streamed into the hashes, so only one read
+    // buffer is held however large they are.
+    whole, h := c.NewHash(), c.NewHash()
+    w := io.MultiWriter(whole, h)
+    buf := make([]byte, min(opts.Size, chunkSize))
+    var offset int64
+    for {
+        h.Reset()
+        n, err := io.CopyBuffer(w, struct{ io.Reader }{io.LimitReader(r, opts.Size)}, buf)
+        if err != nil {
+            return nil, err
+        }
+        if n == 0 {
+            return whole.Sum(nil), nil
+        }
+        if err := fn(Chunk{Offset: offset, Length: n, Digest: h.Sum(nil)}); err != nil {
+            return nil, err
+        }
+        offset += n
+    }
+}
+
+// cdcChunks is Chunks with content-defined boundaries. The buffer holds the
+// current chunk and grows as data arrives, up to Max bytes.
+func (c Config) cdcChunks(r io.Reader, opts ChunkOptions, fn func(Chunk) error) ([]byte, error) {
+    whole, h := c.NewHash(), c.NewHash()
+    var buf []byte
+    var offset int64
+    cut := newCDCCutter(opts)
+    eof := false
+    for {
+        end := 0
+        for end == 0 {
+            if end = cut.scan(buf); end > 0 {
+                break
+            }
+            if eof || int64(len(buf)) == opts.Max {
+                end = len(buf)
+                break
+            }
+            want := int(min(opts.Max-int64(len(buf)), chunkSize))
+            buf = slices.Grow(buf, want)
+            n, err := io.ReadFull(r, buf[len(buf):len(buf)+want])
+            buf = buf[:len(buf)+n]
+            if err == io.EOF || err == io.ErrUnexpectedEOF {
+                eof = true
+            } else if err != nil {
+                return nil, err
+            }
+        }
+        if end == 0 {
+            return whole.Sum(nil), nil
+        }
+        whole.Write(buf[:end])
+        h.Reset()
+        h.Write(buf[:end])
+        if err := fn(Chunk{Offset: offset, Length: int64(end), Digest: h.Sum(nil)}); err != nil {
+            return nil, err
+        }
+        offset += int64(end)
+        buf = buf[:copy(buf, buf[end:])]
+        cut.reset()
+    }
+}
+
+// cdcCutter finds the boundary at the end of a chunk with a rolling hash.
+// The hash restarts at every chunk and only bytes from Min-buzhashWindow on
+// are hashed, which is enough to decide every boundary from Min on. Bytes
+// already scanned are not hashed again when more arrive.
+type cdcCutter struct {
+    min   int
+    mask  uint64
+    start int
+    pos   int
+    h     uint64
+}
+
+func newCDCCutter(opts ChunkOptions) *cdcCutter {
+    c := &cdcCutter{
+        min:   int(opts.Min),
+        mask:  uint64(1)<<(bits.Len64(uint64(opts.Size-1))) - 1,
+        start: max(0, int(opts.Min)-buzhashWindow),
+    }
+    c.reset()
+    return c
+}
+
+func (c *cdcCutter) reset() {
+    c.pos, c.h = c.start, 0
+}
+
+// scan hashes the new bytes of buf, which starts at the current chunk, and
+// returns the chunk's length once a boundary is found, or 0.
+func (c *cdcCutter) scan(buf []byte) int {
+    for ; c.pos < len(buf); c.pos++ {
+        i := c.pos
+        c.h = bits.RotateLeft64(c.h, 1) ^ buzhashTable[buf[i]]
+        if i-c.start >= buzhashWindow {
+            c.h ^= bits.RotateLeft64(buzhashTable[buf[i-buzhashWindow]], buzhashWindow)
+        }
+        if i+1 >= c.min && c.h&c.mask == 0 {
+            c.pos++
+            return i + 1
+        }
+    }
+    return 0
+}
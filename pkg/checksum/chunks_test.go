@@ -0,0 +1,135 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "math/rand/v2"
+    "runtime"
+    "testing"
+    "testing/iotest"
+)
+
+func randomData(n int) []byte {
+    b := make([]byte, n)
+    r := rand.NewChaCha8([32]byte{'c', 'd', 'c'})
+    r.Read(b)
+    return b
+}
+
+func chunksOf(t *testing.T, data []byte, opts ChunkOptions) []Chunk {
+    t.Helper()
+    var chunks []Chunk
+    whole, err := Config{Algorithm: SHA256}.Chunks(iotest.HalfReader(bytes.NewReader(data)), opts, func(c Chunk) error {
+        chunks = append(chunks, c)
+        return nil
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    if sum := sha256.Sum256(data); !bytes.Equal(whole, sum[:]) {
+        t.Errorf("whole digest %x, want %x", whole, sum)
+    }
+    var offset int64
+    for i, c := range chunks {
+        if c.Offset != offset {
+            t.Fatalf("chunk %d at %d, want %d", i, c.Offset, offset)
+        }
+        if sum := sha256.Sum256(data[c.Offset : c.Offset+c.Length]); !bytes.Equal(c.Digest, sum[:]) {
+            t.Errorf("chunk %d digest %x, want %x", i, c.Digest, sum)
+        }
+        offset += c.Length
+    }
+    if offset != int64(len(data)) {
+        t.Errorf("chunks cover %d bytes, want %d", offset, len(data))
+    }
+    return chunks
+}
+
+func TestChunksFixed(t *testing.T) {
+    data := randomData(10000)
+    chunks := chunksOf(t, data, ChunkOptions{Size: 4096})
+    if len(chunks) != 3 || chunks[0].Length != 4096 || chunks[1].Length != 4096 || chunks[2].Length != 1808 {
+        t.Errorf("got %d chunks: %+v", len(chunks), chunks)
+    }
+    if chunks := chunksOf(t, data[:8192], ChunkOptions{Size: 4096}); len(chunks) != 2 {
+        t.Errorf("8192 bytes gave %d chunks, want 2", len(chunks))
+    }
+    if chunks := chunksOf(t, nil, ChunkOptions{Size: 4096}); len(chunks) != 0 {
+        t.Errorf("no data gave %d chunks", len(chunks))
+    }
+}
+
+func TestChunksCDC(t *testing.T) {
+    opts := ChunkOptions{Size: 16 << 10, CDC: true, Min: 4 << 10, Max: 64 << 10}
+    data := randomData(4 << 20)
+    chunks := chunksOf(t, data, opts)
+    for i, c := range chunks[:len(chunks)-1] {
+        if c.Length < opts.Min || c.Length > opts.Max {
+            t.Errorf("chunk %d is %d bytes, outside %d-%d", i, c.Length, opts.Min, opts.Max)
+        }
+    }
+    if avg := int64(len(data)) / int64(len(chunks)); avg < opts.Size || avg > 2*opts.Size {
+        t.Errorf("average chunk %d bytes for -size %d", avg, opts.Size)
+    }
+
+    // Prepending bytes only changes the chunks up to the first boundary
+    // after the insertion; the rest are found again at shifted offsets.
+    shifted := chunksOf(t, append(bytes.Repeat([]byte("x"), 100), data...), opts)
+    seen := make(map[string]bool)
+    for _, c := range chunks {
+        seen[string(c.Digest)] = true
+    }
+    changed := 0
+    for _, c := range shifted {
+        if !seen[string(c.Digest)] {
+            changed++
+        }
+    }
+    if changed > 2 {
+        t.Errorf("prepending 100 bytes changed %d of %d chunks", changed, len(shifted))
+    }
+
+    // Fixed-size chunks all move, so none of them match.
+    fixed := chunksOf(t, data, ChunkOptions{Size: opts.Size})
+    seen = make(map[string]bool)
+    for _, c := range fixed {
+        seen[string(c.Digest)] = true
+    }
+    for i, c := range chunksOf(t, append(bytes.Repeat([]byte("x"), 100), data...), ChunkOptions{Size: opts.Size}) {
+        if seen[string(c.Digest)] {
+            t.Errorf("fixed chunk %d did not change", i)
+        }
+    }
+}
+
+func TestChunksLarge(t *testing.T) {
+    // Neither mode may allocate a whole chunk before the data arrives.
+    data := randomData(1000)
+    for _, opts := range []ChunkOptions{{Size: MaxChunkSize}, {Size: MaxChunkSize / 4, CDC: true, Min: 1, Max: MaxChunkSize}} {
+        var before, after runtime.MemStats
+        runtime.ReadMemStats(&before)
+        chunks := chunksOf(t, data, opts)
+        runtime.ReadMemStats(&after)
+        if len(chunks) != 1 {
+            t.Errorf("%+v: %d chunks of 1000 bytes", opts, len(chunks))
+        }
+        if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+            t.Errorf("%+v: allocated %d bytes for 1000 of input", opts, alloc)
+        }
+    }
+}
+
+func TestChunkOptions(t *testing.T) {
+    for _, opts := range []ChunkOptions{
+        {Size: 0},
+        {Size: 16, CDC: true, Min: 0, Max: 64},
+        {Size: 16, CDC: true, Min: 32, Max: 16},
+        {Size: 64, CDC: true, Min: 4, Max: 32},
+        {Size: MaxChunkSize + 1},
+        {Size: 16, CDC: true, Min: 4, Max: MaxChunkSize + 1},
+    } {
+        if _, err := (Config{}).Chunks(bytes.NewReader(nil), opts, func(Chunk) error { return nil }); err == nil {
+            t.Errorf("Chunks accepted %+v", opts)
+        }
+    }
+}
@@ -0,0 +1,39 @@
+package checksum
+
+import "crypto/subtle"
+
+// Equal reports whether two hex checksums are the same, ignoring case. The
+// comparison takes time that depends only on the lengths of the inputs, so
+// it is safe for checking values an attacker may control. Checksums of
+// different lengths are never equal; use EqualPrefix to accept a truncated
+// expected value.
+//
+// Equal folds case, so it suits hex and base32 but not base64; compare
+// decoded bytes with crypto/subtle for case-sensitive encodings. Only ASCII
+// letters are folded; any other byte, including one that is not valid
+// UTF-8, must match exactly.
+func Equal(expected, actual string) bool {
+    return subtle.ConstantTimeCompare(lowerASCII(expected), lowerASCII(actual)) == 1
+}
+
+// lowerASCII returns s with A to Z lowered. Unlike strings.ToLower, it
+// keeps the length of s, so invalid UTF-8 and letters such as the Kelvin
+// sign, which lowers to k, cannot make different checksums compare equal.
+func lowerASCII(s string) []byte {
+    b := []byte(s)
+    for i, c := range b {
+        if 'A' <= c && c <= 'Z' {
+            b[i] = c + 'a' - 'A'
+        }
+    }
+    return b
+}
+
+// EqualPrefix reports whether expected, ignoring case, is a non-empty
+// leading part of actual, in the same constant time as Equal.
+func EqualPrefix(expected, actual string) bool {
+    if expected == "" || len(expected) > len(actual) {
+        return false
+    }
+    return Equal(expected, actual[:len(expected)])
+}
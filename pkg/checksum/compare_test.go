@@ -0,0 +1,29 @@
+package checksum
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+    for _, tt := range []struct {
+        expected, actual string
+        equal, prefix    bool
+    }{
+        {"dcb7a8333405", "dcb7a8333405", true, true},
+        {"DCB7A8333405", "dcb7a8333405", true, true},
+        {"dcb7a8333405", "DCB7a8333405", true, true},
+        {"dcb7", "dcb7a8333405", false, true},
+        {"dcb7a8333405", "dcb7", false, false},
+        {"dcb7a8333406", "dcb7a8333405", false, false},
+        {"", "dcb7a8333405", false, false},
+        {"", "", true, false},
+        // The Kelvin sign lowers to k but is three bytes long.
+        {"\u212a", "k", false, false},
+        {"\xff", "\xff", true, true},
+    } {
+        if got := Equal(tt.expected, tt.actual); got != tt.equal {
+            t.Errorf("Equal(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.equal)
+        }
+        if got := EqualPrefix(tt.expected, tt.actual); got != tt.prefix {
+            t.Errorf("EqualPrefix(%q, %q) = %v, want %v", tt.expected, tt.actual, got, tt.prefix)
+        }
+    }
+}
@@ -0,0 +1,82 @@
+package checksum
+
+import (
+    "encoding/base32"
+    "encoding/base64"
+    "encoding/hex"
+    "fmt"
+    "strings"
+)
+
+// Encoding is a textual (or raw) representation of digest bytes.
+type Encoding int
+
+const (
+    Hex Encoding = iota
+    Base64
+    Base64URL
+    Base32
+    Raw
+)
+
+var encodingNames = [...]string{
+    Hex:       "hex",
+    Base64:    "base64",
+    Base64URL: "base64url",
+    Base32:    "base32",
+    Raw:       "raw",
+}
+
+// EncodingNames lists the names accepted by ParseEncoding.
+func EncodingNames() []string {
+    return encodingNames[:]
+}
+
+// ParseEncoding returns the encoding with the given name, such as "base64".
+func ParseEncoding(name string) (Encoding, error) {
+    for i, n := range encodingNames {
+        if n == name {
+            return Encoding(i), nil
+        }
+    }
+    return 0, fmt.Errorf("unknown encoding %q (supported: %s)", name, strings.Join(EncodingNames(), ", "))
+}
+
+func (e Encoding) String() string {
+    if e < 0 || int(e) >= len(encodingNames) {
+        return fmt.Sprintf("Encoding(%d)", int(e))
+    }
+    return encodingNames[e]
+}
+
+// Encode returns b in the encoding. Base64URL omits padding; Raw returns the
+// bytes unchanged.
+func (e Encoding) Encode(b []byte) string {
+    switch e {
+    case Base64:
+        return base64.StdEncoding.EncodeToString(b)
+    case Base64URL:
+        return base64.RawURLEncoding.EncodeToString(b)
+    case Base32:
+        return base32.StdEncoding.EncodeToString(b)
+    case Raw:
+        return string(b)
+    }
+    return hex.EncodeToString(b)
+}
+
+// Decode is the inverse of Encode. Hex and base32 input may be in either
+// case.
+func (e Encoding) Decode(s string) ([]byte, error) {
+    switch e {
+    case Base64:
+        return base64.StdEncoding.DecodeString(s)
+    case Base64URL:
+        return base64.RawURLEncoding.DecodeString(s)
+    case Base32:
+        return base32.StdEncoding.DecodeString(strings.ToUpper(s))
+    case Raw:
+        return []byte(s), nil
+    }
+    return hex.DecodeString(s)
+}
@@ -0,0 +1,54 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "strings"
+    "testing"
+)
+
+func TestEncodings(t *testing.T) {
+    digest, _ := hex.DecodeString("dcb7a83334052d8982fbf9b2b21310abee7b9a6f")
+    for _, tt := range []struct {
+        enc      Encoding
+        in, want string
+    }{
+        {Hex, "dcb7a83334052d8982fbf9b2b21310abee7b9a6f", "dcb7a83334052d8982fbf9b2b21310abee7b9a6f"},
+        {Base64, "dcb7a83334052d8982fbf9b2b21310abee7b9a6f", "3LeoMzQFLYmC+/myshMQq+57mm8="},
+        {Base64URL, "dcb7a83334052d8982fbf9b2b21310abee7b9a6f", "3LeoMzQFLYmC-_myshMQq-57mm8"},
+        {Base32, "dcb7a83334052d8982fbf9b2b21310abee7b9a6f", "3S32QMZUAUWYTAX37GZLEEYQVPXHXGTP"},
+        {Base64, "dcb7a8333405", "3LeoMzQF"},
+        {Base32, "dcb7a8333405", "3S32QMZUAU======"},
+        {Base64, "fbff", "+/8="},
+        {Base64URL, "fbff", "-_8"},
+        {Base32, "fbff", "7P7Q===="},
+        {Raw, "00ff0a", "\x00\xff\n"},
+    } {
+        b, _ := hex.DecodeString(tt.in)
+        if got := tt.enc.Encode(b); got != tt.want {
+            t.Errorf("%s.Encode(%s) = %q, want %q", tt.enc, tt.in, got, tt.want)
+        }
+        if got, err := tt.enc.Decode(tt.want); err != nil || !bytes.Equal(got, b) {
+            t.Errorf("%s.Decode(%q) = %x, %v, want %s", tt.enc, tt.want, got, err, tt.in)
+        }
+        if e, err := ParseEncoding(tt.enc.String()); err != nil || e != tt.enc {
+            t.Errorf("ParseEncoding(%q) = %v, %v", tt.enc, e, err)
+        }
+    }
+
+    // Hex and base32 decode in either case; base64 does not fold.
+    for _, enc := range []Encoding{Hex, Base32} {
+        if got, err := enc.Decode(strings.ToUpper(enc.Encode(digest))); err != nil || !bytes.Equal(got, digest) {
+            t.Errorf("%s does not decode upper case: %x, %v", enc, got, err)
+        }
+        if got, err := enc.Decode(strings.ToLower(enc.Encode(digest))); err != nil || !bytes.Equal(got, digest) {
+            t.Errorf("%s does not decode lower case: %x, %v", enc, got, err)
+        }
+    }
+    if got, err := Base64.Decode(strings.ToLower(Base64.Encode(digest))); err == nil && bytes.Equal(got, digest) {
+        t.Error("base64 folded case")
+    }
+    if _, err := ParseEncoding("base58"); err == nil {
+        t.Error("ParseEncoding accepted base58")
+    }
+}
@@ -0,0 +1,52 @@
+package checksum
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// ErrVerifyMismatch reports that a checksum or signature was computed
+// successfully but did not match the one expected.
+var ErrVerifyMismatch = errors.New("checksum mismatch")
+
+// LimitError reports an input larger than a limit the caller set. Such
+// input is rejected, never silently truncated.
+type LimitError struct {
+    What  string // what was limited, such as "input" or "manifest line"
+    Limit int64  // the limit in bytes
+}
+
+func (e *LimitError) Error() string {
+    return fmt.Sprintf("%s exceeds the limit of %d bytes", e.What, e.Limit)
+}
+
+// InputError reports an input that could not be read, or whose contents
+// were not in the form expected. Path names the file, directory, archive,
+// URL or other input.
+type InputError struct {
+    Path string
+    Err  error
+}
+
+// Error prefixes Err with Path unless Err already names it, as the errors
+// of the os and net/http packages do.
+func (e *InputError) Error() string {
+    msg := e.Err.Error()
+    if strings.Contains(msg, e.Path) {
+        return msg
+    }
+    return e.Path + ": " + msg
+}
+
+func (e *InputError) Unwrap() error { return e.Err }
+
+// inputError wraps a non-nil err as an InputError for path, unless it
+// already is one.
+func inputError(path string, err error) error {
+    var ie *InputError
+    if err == nil || errors.As(err, &ie) {
+        return err
+    }
+    return &InputError{Path: path, Err: err}
+}
@@ -0,0 +1,135 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "slices"
+    "strings"
+    "testing"
+)
+
+// splitAt cuts data into up to three parts at offsets taken modulo its
+// length plus one.
+func splitAt(data []byte, cut1, cut2 uint) [][]byte {
+    i := int(cut1 % uint(len(data)+1))
+    j := int(cut2 % uint(len(data)+1))
+    if i > j {
+        i, j = j, i
+    }
+    return [][]byte{data[:i], data[i:j], data[j:]}
+}
+
+func FuzzChecksumParts(f *testing.F) {
+    f.Add([]byte("abc"), uint(1), uint(2))
+    f.Add([]byte(""), uint(0), uint(0))
+    f.Add([]byte("\x00\x00\x00\x00\x00\x00\x00\x01"), uint(4), uint(8))
+    f.Fuzz(func(t *testing.T, data []byte, cut1, cut2 uint) {
+        p := splitAt(data, cut1, cut2)
+        for _, c := range []Config{{}, {Algorithm: XXHash64, Salt: []byte("s")}, {Algorithm: SHA256, Legacy: true}} {
+            want := c.Sum(p...)
+            w := NewWriter(c)
+            s := NewChecksummer(c)
+            for _, part := range p {
+                if _, err := w.ReadPart(bytes.NewReader(part)); err != nil {
+                    t.Fatal(err)
+                }
+                s.WritePart(part)
+            }
+            if got := w.Sum(nil); !bytes.Equal(got, want) {
+                t.Fatalf("%s: Writer %x, Sum %x", c.Name(), got, want)
+            }
+            if got := s.Sum(nil); !bytes.Equal(got, want) {
+                t.Fatalf("%s: Checksummer %x, Sum %x", c.Name(), got, want)
+            }
+        }
+
+        // Legacy checksums only see the concatenation; framed ones also
+        // see where the parts end, so a different split must differ.
+        legacy := Config{Legacy: true}
+        if !bytes.Equal(legacy.Sum(p...), legacy.Sum(data)) {
+            t.Fatal("legacy checksum depends on the split")
+        }
+        other := splitAt(data, cut1+1, cut2)
+        if !slices.EqualFunc(p, other, bytes.Equal) && bytes.Equal(Sum(p...), Sum(other...)) {
+            t.Fatalf("parts %q and %q have the same checksum", p, other)
+        }
+    })
+}
+
+func FuzzManifestParse(f *testing.F) {
+    f.Add("dcb7a83334052d8982fbf9b2b21310abee7b9a6f  a.txt", []byte{0xdc}, "a.txt")
+    f.Add("\\dcb7a833  new\\nline", []byte{}, "back\\slash")
+    f.Add("dcb7a833 *binary", []byte{1, 2}, "\r")
+    f.Add("  ", []byte{0}, " leading space")
+    f.Fuzz(func(t *testing.T, line string, sum []byte, name string) {
+        if e, err := ParseManifestLine(line); err == nil {
+            if e.Sum == "" || strings.Contains(e.Sum, " ") || e.Name == "" {
+                t.Fatalf("ParseManifestLine(%q) = %+v", line, e)
+            }
+            back, err := ParseManifestLine(FormatManifestLine(e.Sum, e.Name))
+            if err != nil || back != e {
+                t.Fatalf("%+v formats to %q, which parses as %+v, %v", e, FormatManifestLine(e.Sum, e.Name), back, err)
+            }
+        }
+
+        if len(sum) == 0 || name == "" {
+            return
+        }
+        e := ManifestEntry{Sum: hex.EncodeToString(sum), Name: name}
+        line = FormatManifestLine(e.Sum, e.Name)
+        if strings.ContainsAny(line, "\n\r") {
+            t.Fatalf("FormatManifestLine(%+v) = %q, which spans lines", e, line)
+        }
+        if back, err := ParseManifestLine(line); err != nil || back != e {
+            t.Fatalf("%+v formats to %q, which parses as %+v, %v", e, line, back, err)
+        }
+    })
+}
+
+func FuzzVerifyInput(f *testing.F) {
+    f.Add("dcb7a83334052d8982fbf9b2b21310abee7b9a6f", []byte{0xdc, 0xb7})
+    f.Add("DCB7", []byte{0xdc, 0xb7})
+    f.Add("3LeoMzQF", []byte("x"))
+    f.Add("bc1qpzry9x8gf2tvdw0s3jn54khce6mua7lxmw7nu", []byte{})
+    f.Add("zQmYtUc4iTCbbfVSDNKvtQqrfyezPPnFvE33wFmutw9PBBk", []byte{0x12, 0x20})
+    f.Add("K", []byte("k"))
+    f.Fuzz(func(t *testing.T, expected string, digest []byte) {
+        actual := hex.EncodeToString(digest)
+        if !Equal(actual, strings.ToUpper(actual)) || !Equal(actual, actual) {
+            t.Fatalf("%s does not equal itself", actual)
+        }
+        // The length check keeps out letters such as the Kelvin sign,
+        // which lower to ASCII.
+        if eq := Equal(expected, actual); eq != Equal(actual, expected) || eq != (len(expected) == len(actual) && strings.ToLower(expected) == actual) {
+            t.Fatalf("Equal(%q, %q) = %v", expected, actual, eq)
+        }
+        if p := EqualPrefix(expected, actual); p != (expected != "" && len(expected) <= len(actual) && Equal(expected, actual[:len(expected)])) {
+            t.Fatalf("EqualPrefix(%q, %q) = %v", expected, actual, p)
+        }
+
+        for _, enc := range []Encoding{Hex, Base64, Base64URL, Base32} {
+            if b, err := enc.Decode(expected); err == nil {
+                if back, err := enc.Decode(enc.Encode(b)); err != nil || !bytes.Equal(back, b) {
+                    t.Fatalf("%s: %q decodes to %x, which does not round trip: %x, %v", enc, expected, b, back, err)
+                }
+            }
+        }
+        if hrp, data, err := Bech32Decode(expected); err == nil {
+            if s, err := Bech32Encode(hrp, data); err != nil || s != strings.ToLower(expected) {
+                t.Fatalf("bech32 %q decodes to %q, %x, which encodes as %q, %v", expected, hrp, data, s, err)
+            }
+        }
+        if a, d, err := DecodeMultihash(expected); err == nil {
+            if len(d) != a.Size() {
+                t.Fatalf("multihash %q has a %d byte %s digest", expected, len(d), a)
+            }
+            mh, err := Multihash(a, d)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if a2, d2, err := ParseMultihash(mh); err != nil || a2 != a || !bytes.Equal(d2, d) {
+                t.Fatalf("multihash %x does not round trip: %s, %x, %v", mh, a2, d2, err)
+            }
+        }
+    })
+}
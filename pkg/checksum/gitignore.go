@@ -0,0 +1,375 @@
+package checksum
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path"
+    "path/filepath"
+    "strings"
+)
+
+// gitFilter decides which paths of a tree walk git would ignore. Paths are
+// relative to the work tree, slash-separated and empty at its top.
+type gitFilter struct {
+    // prefix is the walk's root relative to the work tree.
+    prefix string
+    // rules holds the patterns of each directory's .gitignore, keyed by
+    // the directory. The top of the work tree also has .git/info/exclude.
+    rules map[string][]ignoreRule
+    // tracked holds every path in the index and every directory above
+    // one, or is nil to allow untracked files.
+    tracked map[string]bool
+}
+
+// ignoreRule is one line of an ignore file, as gitignore(5) describes.
+type ignoreRule struct {
+    segs     []string
+    negate   bool
+    dirOnly  bool
+    anchored bool
+}
+
+// newGitFilter finds the work tree containing root and loads the ignore
+// files from its top down to root; the walk loads the ones below.
+func newGitFilter(root string, trackedOnly bool) (*gitFilter, error) {
+    abs, err := filepath.Abs(root)
+    if err != nil {
+        return nil, err
+    }
+    top, gitDir, err := findGitDir(abs)
+    if err != nil {
+        return nil, err
+    }
+    prefix, err := filepath.Rel(top, abs)
+    if err != nil {
+        return nil, err
+    }
+    g := &gitFilter{prefix: filepath.ToSlash(prefix), rules: make(map[string][]ignoreRule)}
+    if g.prefix == "." {
+        g.prefix = ""
+    }
+    if err := g.load("", filepath.Join(gitDir, "info", "exclude")); err != nil {
+        return nil, err
+    }
+    for _, dir := range dirsFromTop(g.prefix) {
+        if err := g.load(dir, filepath.Join(top, filepath.FromSlash(dir), ".gitignore")); err != nil {
+            return nil, err
+        }
+    }
+    if trackedOnly {
+        if g.tracked, err = readGitIndex(gitDir); err != nil {
+            return nil, err
+        }
+    }
+    return g, nil
+}
+
+// findGitDir returns the top of the work tree containing dir and its git
+// directory, following a .git file as linked work trees and submodules
+// use.
+func findGitDir(dir string) (top, gitDir string, err error) {
+    for top = dir; ; top = filepath.Dir(top) {
+        p := filepath.Join(top, ".git")
+        info, err := os.Stat(p)
+        if err == nil && info.IsDir() {
+            return top, p, nil
+        }
+        if err == nil {
+            data, err := os.ReadFile(p)
+            if err != nil {
+                return "", "", err
+            }
+            target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+            if !ok {
+                return "", "", fmt.Errorf("%s: not a gitdir file", p)
+            }
+            if !filepath.IsAbs(target) {
+                target = filepath.Join(top, target)
+            }
+            return top, target, nil
+        }
+        if !errors.Is(err, fs.ErrNotExist) {
+            return "", "", err
+        }
+        if filepath.Dir(top) == top {
+            return "", "", errors.New("not inside a git work tree")
+        }
+    }
+}
+
+// load adds the patterns of the ignore file at name, if there is one, to
+// the directory dir.
+func (g *gitFilter) load(dir, name string) error {
+    f, err := os.Open(name)
+    if errors.Is(err, fs.ErrNotExist) {
+        return nil
+    } else if err != nil {
+        return err
+    }
+    defer f.Close()
+    sc := bufio.NewScanner(f)
+    for sc.Scan() {
+        if rule, ok := parseIgnoreRule(sc.Text()); ok {
+            g.rules[dir] = append(g.rules[dir], rule)
+        }
+    }
+    if err := sc.Err(); err != nil {
+        return fmt.Errorf("%s: %w", name, err)
+    }
+    return nil
+}
+
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+    line = strings.TrimSuffix(line, "\r")
+    if line == "" || line[0] == '#' {
+        return ignoreRule{}, false
+    }
+    // Trailing spaces are dropped unless escaped with a backslash.
+    for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+        line = line[:len(line)-1]
+    }
+    var r ignoreRule
+    if line, r.negate = strings.CutPrefix(line, "!"); r.negate && line == "" {
+        return ignoreRule{}, false
+    }
+    line, r.dirOnly = strings.CutSuffix(line, "/")
+    r.anchored = strings.Contains(line, "/")
+    line = strings.TrimPrefix(line, "/")
+    if line == "" {
+        return ignoreRule{}, false
+    }
+    r.segs = strings.Split(bracketNegation(line), "/")
+    return r, true
+}
+
+// bracketNegation rewrites git's [!...] as the [^...] that path.Match
+// expects.
+func bracketNegation(p string) string {
+    b := []byte(p)
+    for i := 0; i < len(b); i++ {
+        switch {
+        case b[i] == '\\':
+            i++
+        case b[i] == '[' && i+1 < len(b) && b[i+1] == '!':
+            b[i+1] = '^'
+        }
+    }
+    return string(b)
+}
+
+// matches reports whether r matches the path rel, relative to the
+// directory holding the rule. An unanchored rule matches the last element
+// at any depth.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+    if r.dirOnly && !isDir {
+        return false
+    }
+    if !r.anchored {
+        ok, _ := path.Match(r.segs[0], path.Base(rel))
+        return ok
+    }
+    return matchSegments(r.segs, strings.Split(rel, "/"))
+}
+
+// matchSegments matches path elements against pattern elements, where a
+// "**" element matches any number of path elements, or at least one at the
+// end of the pattern.
+func matchSegments(pat, name []string) bool {
+    for len(pat) > 0 {
+        if pat[0] == "**" {
+            if len(pat) == 1 {
+                return len(name) > 0
+            }
+            for i := range len(name) + 1 {
+                if matchSegments(pat[1:], name[i:]) {
+                    return true
+                }
+            }
+            return false
+        }
+        if len(name) == 0 {
+            return false
+        }
+        if ok, _ := path.Match(pat[0], name[0]); !ok {
+            return false
+        }
+        pat, name = pat[1:], name[1:]
+    }
+    return len(name) == 0
+}
+
+// skip reports whether the walk should leave out rel, relative to its
+// root. With an index, that is anything not in it; as in git, ignore rules
+// do not apply to tracked files. Otherwise the last matching rule wins,
+// and the rules of deeper directories come after those of their parents.
+// Directories are skipped whole, so a negated rule cannot bring back a
+// file below an ignored directory, as in git.
+func (g *gitFilter) skip(rel string, isDir bool) bool {
+    full := path.Join(g.prefix, rel)
+    if path.Base(full) == ".git" {
+        return true
+    }
+    if g.tracked != nil {
+        return !g.tracked[full]
+    }
+    ignored := false
+    for _, dir := range dirsFromTop(path.Dir(full)) {
+        sub := full
+        if dir != "" {
+            sub = full[len(dir)+1:]
+        }
+        for _, r := range g.rules[dir] {
+            if r.matches(sub, isDir) {
+                ignored = !r.negate
+            }
+        }
+    }
+    return ignored
+}
+
+// dirsFromTop returns the top of the work tree, "", followed by each
+// directory down to and including dir.
+func dirsFromTop(dir string) []string {
+    dirs := []string{""}
+    if dir == "" || dir == "." {
+        return dirs
+    }
+    for i, c := range dir {
+        if c == '/' {
+            dirs = append(dirs, dir[:i])
+        }
+    }
+    return append(dirs, dir)
+}
+
+// readGitIndex returns the paths in the index of gitDir, in any of index
+// versions 2 to 4, together with every directory above them. A missing
+// index is an empty one.
+func readGitIndex(gitDir string) (map[string]bool, error) {
+    name := filepath.Join(gitDir, "index")
+    data, err := os.ReadFile(name)
+    if errors.Is(err, fs.ErrNotExist) {
+        return map[string]bool{}, nil
+    } else if err != nil {
+        return nil, err
+    }
+    hashSize := 20
+    if sha256Repo(gitDir) {
+        hashSize = 32
+    }
+    paths, err := parseGitIndex(data, hashSize)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", name, err)
+    }
+    tracked := make(map[string]bool)
+    for _, p := range paths {
+        for ; p != "." && !tracked[p]; p = path.Dir(p) {
+            tracked[p] = true
+        }
+    }
+    return tracked, nil
+}
+
+// sha256Repo reports whether the repository's config sets
+// extensions.objectFormat to sha256, which lengthens index entries.
+func sha256Repo(gitDir string) bool {
+    data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+    if err != nil {
+        return false
+    }
+    for line := range strings.Lines(string(data)) {
+        if strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(line), " ", ""), "objectformat=sha256") {
+            return true
+        }
+    }
+    return false
+}
+
+var errBadIndex = errors.New("malformed git index")
+
+func parseGitIndex(data []byte, hashSize int) ([]string, error) {
+    if len(data) < 12 || string(data[:4]) != "DIRC" {
+        return nil, errBadIndex
+    }
+    version := binary.BigEndian.Uint32(data[4:])
+    if version < 2 || version > 4 {
+        return nil, fmt.Errorf("unsupported git index version %d", version)
+    }
+    count := binary.BigEndian.Uint32(data[8:])
+    // Each entry has 40 bytes of stat data, the object name and 16 bits
+    // of flags, then its path.
+    fixed := 40 + hashSize + 2
+    paths := make([]string, 0, min(count, uint32(len(data)/fixed)))
+    rest := data[12:]
+    var prev []byte
+    for range count {
+        if len(rest) < fixed {
+            return nil, errBadIndex
+        }
+        flags := binary.BigEndian.Uint16(rest[fixed-2:])
+        n := fixed
+        if flags&0x4000 != 0 {
+            if version < 3 {
+                return nil, errBadIndex
+            }
+            n += 2
+        }
+        var name []byte
+        if version == 4 {
+            // The path drops a varint count of bytes from the end of the
+            // previous one and appends a NUL-terminated suffix.
+            strip, used := indexVarint(rest[min(n, len(rest)):])
+            if used == 0 || strip > uint64(len(prev)) {
+                return nil, errBadIndex
+            }
+            n += used
+            end := bytes.IndexByte(rest[min(n, len(rest)):], 0)
+            if end < 0 {
+                return nil, errBadIndex
+            }
+            name = append(prev[:len(prev)-int(strip):len(prev)-int(strip)], rest[n:n+end]...)
+            n += end + 1
+        } else {
+            end := bytes.IndexByte(rest[min(n, len(rest)):], 0)
+            if end < 0 {
+                return nil, errBadIndex
+            }
+            name = rest[n : n+end]
+            // Entries are padded with one to eight NULs to a multiple of
+            // eight bytes.
+            n = (n + end + 8) &^ 7
+            if n > len(rest) {
+                return nil, errBadIndex
+            }
+        }
+        paths = append(paths, string(name))
+        prev = name
+        rest = rest[n:]
+    }
+    return paths, nil
+}
+
+// indexVarint decodes git's offset varint, in which each continuation
+// also adds one, returning the value and the bytes used, or 0 bytes if b
+// ends first.
+func indexVarint(b []byte) (uint64, int) {
+    var v uint64
+    for i, c := range b {
+        if i > 0 {
+            v++
+        }
+        v = v<<7 | uint64(c&0x7f)
+        if c&0x80 == 0 {
+            return v, i + 1
+        }
+        if i == 9 {
+            break
+        }
+    }
+    return 0, 0
+}
@@ -0,0 +1,138 @@
+package checksum
+
+import (
+    "os"
+    "os/exec"
+    "path/filepath"
+    "slices"
+    "testing"
+)
+
+// gitFixture is a work tree, without git's object store, exercising the
+// parts of gitignore(5) the filter implements.
+var gitFixture = [][2]string{
+    {".git/info/exclude", "secret\n"},
+    {".git/config", "[core]\n"},
+    {".gitignore", "# build output\n*.log\n!keep.log\nbuild/\n/root-only\ndocs/**/*.tmp\nspace\\ \n[!a-m]*.bak\n"},
+    {"a.log", ""},
+    {"keep.log", ""},
+    {"build/out", ""},
+    {"root-only", ""},
+    {"secret", ""},
+    {"space ", ""},
+    {"z.bak", ""},
+    {"c.bak", ""},
+    {"src/main.go", ""},
+    {"src/build", "a file, so build/ does not match it\n"},
+    {"src/root-only", ""},
+    {"src/secret", ""},
+    {"docs/x.tmp", ""},
+    {"docs/a/b/y.tmp", ""},
+    {"docs/a/readme", ""},
+    {"sub/.gitignore", "!a.log\n*.txt\n"},
+    {"sub/a.log", ""},
+    {"sub/n.txt", ""},
+    {"sub/deeper/n.txt", ""},
+    {"n.txt", ""},
+}
+
+func gitFiles(t *testing.T, root string, opts TreeOptions) []string {
+    t.Helper()
+    opts.Git, opts.NoDigest = true, true
+    entries, err := Config{}.Tree(root, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    var files []string
+    for _, e := range entries {
+        if e.Type == File {
+            files = append(files, e.Path)
+        }
+    }
+    return files
+}
+
+func TestGitIgnore(t *testing.T) {
+    dir := buildTree(t, gitFixture)
+    want := []string{
+        ".gitignore",
+        "c.bak",
+        "docs/a/readme",
+        "keep.log",
+        "n.txt",
+        "src/build",
+        "src/main.go",
+        "src/root-only",
+        "sub/.gitignore",
+        "sub/a.log",
+    }
+    if got := gitFiles(t, dir, TreeOptions{}); !slices.Equal(got, want) {
+        t.Errorf("got files\n%q\nwant\n%q", got, want)
+    }
+
+    // Rules still apply, relative to their own directories, when the walk
+    // starts below the top of the work tree.
+    if got := gitFiles(t, filepath.Join(dir, "sub"), TreeOptions{}); !slices.Equal(got, []string{".gitignore", "a.log"}) {
+        t.Errorf("walking sub: %q", got)
+    }
+    if got := gitFiles(t, filepath.Join(dir, "src"), TreeOptions{}); !slices.Equal(got, []string{"build", "main.go", "root-only"}) {
+        t.Errorf("walking src: %q", got)
+    }
+}
+
+func TestGitWorktreeFile(t *testing.T) {
+    // A linked work tree has a .git file pointing at its git directory.
+    gitDir := buildTree(t, [][2]string{{"info/exclude", "*.o\n"}})
+    dir := buildTree(t, [][2]string{{".git", "gitdir: " + gitDir + "\n"}, {"a.o", ""}, {"a.c", ""}})
+    if got := gitFiles(t, dir, TreeOptions{}); !slices.Equal(got, []string{"a.c"}) {
+        t.Errorf("got %q", got)
+    }
+}
+
+func TestGitTrackedOnly(t *testing.T) {
+    if _, err := exec.LookPath("git"); err != nil {
+        t.Skip("git is not installed")
+    }
+    for _, version := range []string{"2", "3", "4"} {
+        dir := buildTree(t, [][2]string{
+            {".gitignore", "*.log\n"},
+            {"tracked.txt", ""},
+            {"forced.log", ""},
+            {"untracked.txt", ""},
+            {"dir/tracked-too.txt", ""},
+            {"dir/untracked.txt", ""},
+        })
+        for _, args := range [][]string{
+            {"init", "-q"},
+            {"add", ".gitignore", "tracked.txt", "dir/tracked-too.txt"},
+            {"add", "-f", "forced.log"},
+            {"update-index", "--index-version", version},
+        } {
+            cmd := exec.Command("git", args...)
+            cmd.Dir = dir
+            cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_SYSTEM=/dev/null")
+            if out, err := cmd.CombinedOutput(); err != nil {
+                t.Fatalf("git %v: %v\n%s", args, err, out)
+            }
+        }
+        want := []string{".gitignore", "dir/tracked-too.txt", "forced.log", "tracked.txt"}
+        if got := gitFiles(t, dir, TreeOptions{TrackedOnly: true}); !slices.Equal(got, want) {
+            t.Errorf("index version %s: got %q, want %q", version, got, want)
+        }
+        want = []string{".gitignore", "dir/tracked-too.txt", "dir/untracked.txt", "tracked.txt", "untracked.txt"}
+        if got := gitFiles(t, dir, TreeOptions{}); !slices.Equal(got, want) {
+            t.Errorf("with untracked files: got %q, want %q", got, want)
+        }
+    }
+}
+
+func TestParseGitIndex(t *testing.T) {
+    for _, data := range []string{"", "DIRC", "DIRC\x00\x00\x00\x05\x00\x00\x00\x00", "DIRC\x00\x00\x00\x02\x00\x00\x00\x01"} {
+        if paths, err := parseGitIndex([]byte(data), 20); err == nil {
+            t.Errorf("parseGitIndex(%q) = %q, want an error", data, paths)
+        }
+    }
+    if paths, err := parseGitIndex([]byte("DIRC\x00\x00\x00\x02\x00\x00\x00\x00"), 20); err != nil || len(paths) != 0 {
+        t.Errorf("empty index: %q, %v", paths, err)
+    }
+}
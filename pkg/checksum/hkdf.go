@@ -0,0 +1,25 @@
+package checksum
+
+import (
+    "crypto/hkdf"
+    "fmt"
+)
+
+// MaxDerivedKey is the longest key HKDF can expand to with a: 255 blocks
+// of the digest size (RFC 5869, section 2.3).
+func MaxDerivedKey(a Algorithm) int {
+    return 255 * a.Size()
+}
+
+// DeriveKey runs HKDF extract and expand over a, turning secret into length
+// bytes of key material bound to info. A nil salt is the RFC's default of
+// one digest of zeros.
+func DeriveKey(a Algorithm, secret, salt []byte, info string, length int) ([]byte, error) {
+    if !a.Cryptographic() {
+        return nil, fmt.Errorf("checksum: HKDF needs a cryptographic algorithm, not %s", a)
+    }
+    if length < 1 || length > MaxDerivedKey(a) {
+        return nil, fmt.Errorf("checksum: HKDF-%s keys are 1 to %d bytes, not %d", a, MaxDerivedKey(a), length)
+    }
+    return hkdf.Key(a.New, secret, salt, info, length)
+}
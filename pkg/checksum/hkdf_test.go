@@ -0,0 +1,71 @@
+package checksum
+
+import (
+    "encoding/hex"
+    "testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+    // Test cases 1-4 of RFC 5869, appendix A.
+    for _, tt := range []struct {
+        alg                Algorithm
+        secret, salt, info string
+        want               string
+    }{
+        {
+            SHA256,
+            "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+            "000102030405060708090a0b0c",
+            "f0f1f2f3f4f5f6f7f8f9",
+            "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+        },
+        {
+            SHA256,
+            "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f",
+            "606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeaf",
+            "b0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+            "b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71cc30c58179ec3e87c14c01d5c1f3434f1d87",
+        },
+        {
+            SHA256,
+            "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+            "",
+            "",
+            "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+        },
+        {
+            SHA1,
+            "0b0b0b0b0b0b0b0b0b0b0b",
+            "000102030405060708090a0b0c",
+            "f0f1f2f3f4f5f6f7f8f9",
+            "085a01ea1b10f36933068b56efa5ad81a4f14b822f5b091568a9cdd4f155fda2c22e422478d305f3f896",
+        },
+    } {
+        secret, _ := hex.DecodeString(tt.secret)
+        salt, _ := hex.DecodeString(tt.salt)
+        info, _ := hex.DecodeString(tt.info)
+        want, _ := hex.DecodeString(tt.want)
+        got, err := DeriveKey(tt.alg, secret, salt, string(info), len(want))
+        if err != nil || hex.EncodeToString(got) != tt.want {
+            t.Errorf("DeriveKey(%s, %s...) = %x, %v, want %s", tt.alg, tt.secret[:8], got, err, tt.want)
+        }
+    }
+}
+
+func TestDeriveKeyLimits(t *testing.T) {
+    secret := []byte("master secret")
+    if MaxDerivedKey(SHA256) != 255*32 {
+        t.Errorf("MaxDerivedKey(sha256) = %d, want %d", MaxDerivedKey(SHA256), 255*32)
+    }
+    if got, err := DeriveKey(SHA256, secret, nil, "", MaxDerivedKey(SHA256)); err != nil || len(got) != MaxDerivedKey(SHA256) {
+        t.Errorf("DeriveKey at the limit = %d bytes, %v", len(got), err)
+    }
+    for _, n := range []int{0, -1, MaxDerivedKey(SHA256) + 1} {
+        if _, err := DeriveKey(SHA256, secret, nil, "", n); err == nil {
+            t.Errorf("DeriveKey accepted length %d", n)
+        }
+    }
+    if _, err := DeriveKey(CRC32C, secret, nil, "", 4); err == nil {
+        t.Error("DeriveKey accepted crc32c")
+    }
+}
@@ -0,0 +1,79 @@
+package checksum
+
+import (
+    "errors"
+    "strings"
+)
+
+// ErrMalformedLine reports a manifest line that is not in the form
+// sha1sum and friends print.
+var ErrMalformedLine = errors.New("improperly formatted checksum line")
+
+// ManifestEntry is one line of a manifest: a checksum and the name of the
+// file it belongs to.
+type ManifestEntry struct {
+    Sum  string
+    Name string
+}
+
+// FormatManifestLine formats one entry the way sha1sum prints it, without
+// a line ending. Names containing a backslash or line break are escaped
+// and the line gets a leading backslash, matching GNU coreutils.
+func FormatManifestLine(sum, name string) string {
+    if !strings.ContainsAny(name, "\\\n\r") {
+        return sum + "  " + name
+    }
+    r := strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+    return "\\" + sum + "  " + r.Replace(name)
+}
+
+// ParseManifestLine splits a "<sum>  <name>" or "<sum> *<name>" line, as
+// FormatManifestLine or sha1sum writes them. The caller strips line
+// endings and skips blank and comment lines. The sum is only checked for
+// backslashes, which no checksum encoding uses; a leading one would be
+// taken for the escape marker when the line is written again.
+func ParseManifestLine(line string) (ManifestEntry, error) {
+    escaped := strings.HasPrefix(line, "\\")
+    if escaped {
+        line = line[1:]
+    }
+    i := strings.IndexByte(line, ' ')
+    if i <= 0 || i+2 > len(line) || (line[i+1] != ' ' && line[i+1] != '*') {
+        return ManifestEntry{}, ErrMalformedLine
+    }
+    sum, name := line[:i], line[i+2:]
+    if name == "" || strings.ContainsRune(sum, '\\') {
+        return ManifestEntry{}, ErrMalformedLine
+    }
+    if escaped {
+        var err error
+        if name, err = unescapeName(name); err != nil {
+            return ManifestEntry{}, err
+        }
+    }
+    return ManifestEntry{Sum: sum, Name: name}, nil
+}
+
+func unescapeName(s string) (string, error) {
+    var b strings.Builder
+    for i := 0; i < len(s); i++ {
+        if s[i] != '\\' {
+            b.WriteByte(s[i])
+            continue
+        }
+        if i++; i == len(s) {
+            return "", ErrMalformedLine
+        }
+        switch s[i] {
+        case '\\':
+            b.WriteByte('\\')
+        case 'n':
+            b.WriteByte('\n')
+        case 'r':
+            b.WriteByte('\r')
+        default:
+            return "", ErrMalformedLine
+        }
+    }
+    return b.String(), nil
+}
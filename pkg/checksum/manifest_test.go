@@ -0,0 +1,44 @@
+package checksum
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestManifestLine(t *testing.T) {
+    for _, tt := range []struct {
+        sum, name, line string
+    }{
+        {"dcb7a833", "a.txt", "dcb7a833  a.txt"},
+        {"dcb7a833", " two  spaces ", "dcb7a833   two  spaces "},
+        {"dcb7a833", "new\nline", "\\dcb7a833  new\\nline"},
+        {"dcb7a833", `back\slash`, `\dcb7a833  back\\slash`},
+        {"dcb7a833", "cr\r", `\dcb7a833  cr\r`},
+    } {
+        if got := FormatManifestLine(tt.sum, tt.name); got != tt.line {
+            t.Errorf("FormatManifestLine(%q, %q) = %q, want %q", tt.sum, tt.name, got, tt.line)
+        }
+        if e, err := ParseManifestLine(tt.line); err != nil || e != (ManifestEntry{Sum: tt.sum, Name: tt.name}) {
+            t.Errorf("ParseManifestLine(%q) = %+v, %v", tt.line, e, err)
+        }
+    }
+    if e, err := ParseManifestLine("dcb7a833 *binary"); err != nil || e.Name != "binary" {
+        t.Errorf("binary mode line: %+v, %v", e, err)
+    }
+
+    for _, line := range []string{
+        "",
+        "dcb7a833",
+        "dcb7a833 ",
+        "dcb7a833  ",
+        "dcb7a833 x",
+        " dcb7a833  x",
+        `\dcb7a833  trailing\`,
+        `\dcb7a833  bad\escape`,
+        `\\  0`,
+    } {
+        if e, err := ParseManifestLine(line); !errors.Is(err, ErrMalformedLine) {
+            t.Errorf("ParseManifestLine(%q) = %+v, %v, want ErrMalformedLine", line, e, err)
+        }
+    }
+}
@@ -0,0 +1,16 @@
+//go:build !unix || aix
+
+package checksum
+
+import (
+    "errors"
+    "os"
+)
+
+// mmap is unavailable here, so ReadMmap always falls back to buffered
+// reads.
+func mmap(f *os.File, size int) ([]byte, error) {
+    return nil, errors.New("mmap is not supported on this platform")
+}
+
+func munmap(data []byte) {}
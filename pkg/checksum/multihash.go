@@ -0,0 +1,178 @@
+package checksum
+
+import (
+    "encoding/base32"
+    "encoding/binary"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+)
+
+// Multihash codes from the multicodec table
+// (https://github.com/multiformats/multicodec/blob/master/table.csv).
+var multihashCodes = map[Algorithm]uint64{
+    SHA1:       0x11,
+    SHA256:     0x12,
+    SHA512:     0x13,
+    BLAKE2b256: 0xb220,
+}
+
+// MultihashCode returns the multihash function code for a, if it has one.
+func (a Algorithm) MultihashCode() (uint64, bool) {
+    code, ok := multihashCodes[a]
+    return code, ok
+}
+
+// Multihash wraps a full digest of algorithm a as
+// <varint code><varint length><digest>.
+func Multihash(a Algorithm, digest []byte) ([]byte, error) {
+    code, ok := a.MultihashCode()
+    if !ok {
+        return nil, fmt.Errorf("%s has no multihash code", a)
+    }
+    b := binary.AppendUvarint(nil, code)
+    b = binary.AppendUvarint(b, uint64(len(digest)))
+    return append(b, digest...), nil
+}
+
+// ParseMultihash splits a multihash into its algorithm and digest. Truncated
+// digests are rejected: the encoded length must be the algorithm's full
+// digest size.
+func ParseMultihash(b []byte) (Algorithm, []byte, error) {
+    code, n := binary.Uvarint(b)
+    if n <= 0 {
+        return 0, nil, errors.New("multihash: bad function code")
+    }
+    b = b[n:]
+    size, n := binary.Uvarint(b)
+    if n <= 0 {
+        return 0, nil, errors.New("multihash: bad digest length")
+    }
+    b = b[n:]
+    if uint64(len(b)) != size {
+        return 0, nil, fmt.Errorf("multihash: digest is %d bytes, header says %d", len(b), size)
+    }
+    for a, c := range multihashCodes {
+        if c != code {
+            continue
+        }
+        if int(size) != a.Size() {
+            return 0, nil, fmt.Errorf("multihash: truncated %s digest (%d of %d bytes)", a, size, a.Size())
+        }
+        return a, b, nil
+    }
+    return 0, nil, fmt.Errorf("multihash: unsupported function code 0x%x", code)
+}
+
+// DecodeMultihash parses a multihash given as bare hex or as a multibase
+// string. None of the supported function codes starts with a hex digit that
+// doubles as a multibase prefix, so the two forms cannot be confused.
+func DecodeMultihash(s string) (Algorithm, []byte, error) {
+    var b []byte
+    var err error
+    switch {
+    case s == "":
+        return 0, nil, errors.New("multihash: empty string")
+    case strings.ContainsRune("fFbBz", rune(s[0])):
+        b, err = MultibaseDecode(s)
+    default:
+        b, err = hex.DecodeString(s)
+    }
+    if err != nil {
+        return 0, nil, err
+    }
+    return ParseMultihash(b)
+}
+
+// Multibase names accepted by MultibaseEncode, with their prefix characters.
+var multibasePrefixes = map[string]byte{
+    "base16":    'f',
+    "base32":    'b',
+    "base58btc": 'z',
+}
+
+// MultibaseNames lists the bases MultibaseEncode supports.
+func MultibaseNames() []string {
+    return []string{"base16", "base32", "base58btc"}
+}
+
+var base32Lower = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// MultibaseEncode encodes b in the named base, prefixed with the base's
+// multibase character.
+func MultibaseEncode(base string, b []byte) (string, error) {
+    prefix, ok := multibasePrefixes[base]
+    if !ok {
+        return "", fmt.Errorf("unknown multibase %q (supported: %s)", base, strings.Join(MultibaseNames(), ", "))
+    }
+    var body string
+    switch base {
+    case "base16":
+        body = hex.EncodeToString(b)
+    case "base32":
+        body = base32Lower.EncodeToString(b)
+    case "base58btc":
+        body = base58Encode(b)
+    }
+    return string(prefix) + body, nil
+}
+
+// MultibaseDecode decodes a multibase string, selecting the base from its
+// prefix character. Upper-case base16 ('F') and base32 ('B') are accepted.
+func MultibaseDecode(s string) ([]byte, error) {
+    if s == "" {
+        return nil, errors.New("multibase: empty string")
+    }
+    body := s[1:]
+    switch s[0] {
+    case 'f', 'F':
+        return hex.DecodeString(body)
+    case 'b', 'B':
+        return base32Lower.DecodeString(strings.ToLower(body))
+    case 'z':
+        return base58Decode(body)
+    }
+    return nil, fmt.Errorf("multibase: unsupported prefix %q", s[0])
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58Encode(b []byte) string {
+    zeros := 0
+    for zeros < len(b) && b[zeros] == 0 {
+        zeros++
+    }
+    n := new(big.Int).SetBytes(b)
+    radix, mod := big.NewInt(58), new(big.Int)
+    var out []byte
+    for n.Sign() > 0 {
+        n.DivMod(n, radix, mod)
+        out = append(out, base58Alphabet[mod.Int64()])
+    }
+    for range zeros {
+        out = append(out, base58Alphabet[0])
+    }
+    for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+        out[i], out[j] = out[j], out[i]
+    }
+    return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+    n, radix := new(big.Int), big.NewInt(58)
+    zeros := 0
+    for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+        zeros++
+    }
+    for i := 0; i < len(s); i++ {
+        d := strings.IndexByte(base58Alphabet, s[i])
+        if d < 0 {
+            return nil, fmt.Errorf("base58: invalid character %q", s[i])
+        }
+        n.Mul(n, radix)
+        n.Add(n, big.NewInt(int64(d)))
+    }
+    return append(make([]byte, zeros), n.Bytes()...), nil
+}
@@ -0,0 +1,114 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "strings"
+    "testing"
+)
+
+// Multihashes of "foo". The sha1 and sha2-256 values are those of the
+// multiformats test fixtures.
+var multihashVectors = []struct {
+    alg                    Algorithm
+    multihash, b58, base32 string
+}{
+    {SHA1, "11140beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33", "z5dqx43zNtUUbPj97vJhpHyUUPyrmXG", "bcekax3whwxvd6d63zfoq3vd7hrn4e5o2rizq"},
+    {SHA256, "12202c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", "zQmRJzsvyCQyizr73Gmms8ZRtvNxmgqumxc2KUp71dfEmoj", "bciqcyjvunnup7rup7gnukpa5gbatie2cfvygja57ud4yuxuimjtoplq"},
+    {BLAKE2b256, "a0e40220b8fe9f7f6255a6fa08f668ab632a8d081ad87983c77cd274e48ce450f0b349fd", "", ""},
+}
+
+func TestMultihash(t *testing.T) {
+    for _, v := range multihashVectors {
+        digest, _ := Config{Algorithm: v.alg}.SumReader(strings.NewReader("foo"))
+        mh, err := Multihash(v.alg, digest)
+        if err != nil || hex.EncodeToString(mh) != v.multihash {
+            t.Errorf("Multihash(%s) = %x, %v, want %s", v.alg, mh, err, v.multihash)
+        }
+        a, d, err := ParseMultihash(mh)
+        if err != nil || a != v.alg || !bytes.Equal(d, digest) {
+            t.Errorf("ParseMultihash(%x) = %s, %x, %v", mh, a, d, err)
+        }
+        for base, want := range map[string]string{"base58btc": v.b58, "base32": v.base32, "base16": "f" + v.multihash} {
+            if want == "" {
+                continue
+            }
+            if got, err := MultibaseEncode(base, mh); err != nil || got != want {
+                t.Errorf("MultibaseEncode(%s, %s) = %q, %v, want %q", base, v.alg, got, err, want)
+            }
+            for _, s := range []string{want, strings.ToUpper(want[:1]) + strings.ToUpper(want[1:])} {
+                if base == "base58btc" && s != want {
+                    continue
+                }
+                if got, err := MultibaseDecode(s); err != nil || !bytes.Equal(got, mh) {
+                    t.Errorf("MultibaseDecode(%q) = %x, %v", s, got, err)
+                }
+            }
+        }
+    }
+}
+
+func TestDecodeMultihash(t *testing.T) {
+    for _, v := range multihashVectors {
+        for _, s := range []string{v.multihash, "f" + v.multihash, v.b58, v.base32} {
+            if s == "" {
+                continue
+            }
+            if a, d, err := DecodeMultihash(s); err != nil || a != v.alg || hex.EncodeToString(d) != v.multihash[len(v.multihash)-2*v.alg.Size():] {
+                t.Errorf("DecodeMultihash(%q) = %s, %x, %v", s, a, d, err)
+            }
+        }
+    }
+    for _, s := range []string{"", "z", "1114", "m" + multihashVectors[0].multihash} {
+        if _, _, err := DecodeMultihash(s); err == nil {
+            t.Errorf("DecodeMultihash(%q) succeeded", s)
+        }
+    }
+}
+
+func TestParseMultihashErrors(t *testing.T) {
+    for _, h := range []string{
+        "",
+        "11",
+        "1114" + "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a",     // shorter than its length
+        "1114" + "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a3300", // longer than its length
+        "1110" + "0beec7b5ea3f0fdbc95d0dd47f3c5bc2",           // truncated sha1
+        "1514" + "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33",   // sha3-384 is not supported
+        "ffffffffffffffffffffff",
+    } {
+        b, _ := hex.DecodeString(h)
+        if a, _, err := ParseMultihash(b); err == nil {
+            t.Errorf("ParseMultihash(%s) = %s, want an error", h, a)
+        }
+    }
+    if _, err := Multihash(CRC32C, make([]byte, 4)); err == nil {
+        t.Error("crc32c has a multihash")
+    }
+}
+
+func TestBase58(t *testing.T) {
+    for in, want := range map[string]string{
+        "":                         "",
+        "Hello World!":             "2NEpo7TZRRrLZSi2U",
+        "\x00\x00\x28\x7f\xb4\xcd": "11233QC4",
+        "\x00":                     "1",
+    } {
+        if got := base58Encode([]byte(in)); got != want {
+            t.Errorf("base58Encode(%q) = %q, want %q", in, got, want)
+        }
+        if got, err := base58Decode(want); err != nil || string(got) != in {
+            t.Errorf("base58Decode(%q) = %q, %v", want, got, err)
+        }
+    }
+    for _, s := range []string{"0", "O", "I", "l", "2NEpo7TZ+"} {
+        if _, err := base58Decode(s); err == nil {
+            t.Errorf("base58Decode(%q) succeeded", s)
+        }
+    }
+    if _, err := MultibaseDecode("m"); err == nil {
+        t.Error("MultibaseDecode accepted base64's prefix")
+    }
+    if _, err := MultibaseEncode("base64", nil); err == nil {
+        t.Error("MultibaseEncode accepted base64")
+    }
+}
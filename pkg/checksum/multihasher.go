@@ -0,0 +1,81 @@
+package checksum
+
+import (
+    "errors"
+    "hash"
+)
+
+// MultiHasher is a hash.Hash that feeds every write to several hashes, so
+// a single pass over the input yields all of their digests. Sum appends
+// the digests one after another, in order; Sums returns them apart.
+type MultiHasher struct {
+    hashes []hash.Hash
+}
+
+// NewMultiHasher returns a MultiHasher writing to hashes.
+func NewMultiHasher(hashes ...hash.Hash) *MultiHasher {
+    return &MultiHasher{hashes: hashes}
+}
+
+// Write writes p to every hash. It never returns an error.
+func (m *MultiHasher) Write(p []byte) (int, error) {
+    for _, h := range m.hashes {
+        h.Write(p)
+    }
+    return len(p), nil
+}
+
+func (m *MultiHasher) Sum(b []byte) []byte {
+    for _, h := range m.hashes {
+        b = h.Sum(b)
+    }
+    return b
+}
+
+// Sums returns the digest of each hash, in order.
+func (m *MultiHasher) Sums() [][]byte {
+    sums := make([][]byte, len(m.hashes))
+    for i, h := range m.hashes {
+        sums[i] = h.Sum(nil)
+    }
+    return sums
+}
+
+func (m *MultiHasher) Reset() {
+    for _, h := range m.hashes {
+        h.Reset()
+    }
+}
+
+// Size is the total length of the digests.
+func (m *MultiHasher) Size() int {
+    n := 0
+    for _, h := range m.hashes {
+        n += h.Size()
+    }
+    return n
+}
+
+// BlockSize is the block size of the first hash.
+func (m *MultiHasher) BlockSize() int {
+    if len(m.hashes) == 0 {
+        return 1
+    }
+    return m.hashes[0].BlockSize()
+}
+
+// Clone implements hash.Cloner when every hash does.
+func (m *MultiHasher) Clone() (hash.Cloner, error) {
+    clone := &MultiHasher{hashes: make([]hash.Hash, len(m.hashes))}
+    for i, h := range m.hashes {
+        c, ok := h.(hash.Cloner)
+        if !ok {
+            return nil, errors.ErrUnsupported
+        }
+        var err error
+        if clone.hashes[i], err = c.Clone(); err != nil {
+            return nil, err
+        }
+    }
+    return clone, nil
+}
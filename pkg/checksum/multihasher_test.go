@@ -0,0 +1,80 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/sha1"
+    "crypto/sha256"
+    "hash"
+    "io"
+    "strings"
+    "testing"
+)
+
+func TestMultiHasher(t *testing.T) {
+    m := NewMultiHasher(sha1.New(), sha256.New())
+    io.WriteString(m, "abc")
+    s1, s256 := sha1.Sum([]byte("abc")), sha256.Sum256([]byte("abc"))
+    sums := m.Sums()
+    if len(sums) != 2 || !bytes.Equal(sums[0], s1[:]) || !bytes.Equal(sums[1], s256[:]) {
+        t.Errorf("Sums() = %x", sums)
+    }
+    if got := m.Sum([]byte("x")); !bytes.Equal(got, append(append([]byte("x"), s1[:]...), s256[:]...)) {
+        t.Errorf("Sum() = %x", got)
+    }
+    if m.Size() != 52 || m.BlockSize() != 64 {
+        t.Errorf("Size, BlockSize = %d, %d", m.Size(), m.BlockSize())
+    }
+
+    c, err := m.Clone()
+    if err != nil {
+        t.Fatal(err)
+    }
+    clone := c.(hash.Hash)
+    io.WriteString(clone, "d")
+    if !bytes.Equal(m.Sums()[0], s1[:]) {
+        t.Error("writing to the clone changed the original")
+    }
+    if s := sha1.Sum([]byte("abcd")); !bytes.Equal(clone.(*MultiHasher).Sums()[0], s[:]) {
+        t.Error("the clone lost its state")
+    }
+
+    m.Reset()
+    if s := sha256.Sum256(nil); !bytes.Equal(m.Sums()[1], s[:]) {
+        t.Error("Reset kept state")
+    }
+}
+
+func TestAlsoSinglePass(t *testing.T) {
+    data := strings.Repeat("single pass ", 50000)
+    c := Config{Algorithm: SHA1, Also: []Algorithm{SHA256, SHA512, XXHash64}}
+    // The counting reader hides Seek, so the input cannot be read twice.
+    r := &CountingReader{R: strings.NewReader(data)}
+    digest, err := c.SumReader(r)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if r.N != int64(len(data)) {
+        t.Errorf("read %d bytes of %d", r.N, len(data))
+    }
+    if len(digest) != c.Size() {
+        t.Fatalf("digest is %d bytes, want %d", len(digest), c.Size())
+    }
+    for i, a := range c.Algorithms() {
+        want, _ := Config{Algorithm: a}.SumReader(strings.NewReader(data))
+        if got := c.Split(digest)[i]; !bytes.Equal(got, want) {
+            t.Errorf("%s: got %x, want %x", a, got, want)
+        }
+    }
+    if c.Name() != "sha1+sha256+sha512+xxhash64" {
+        t.Errorf("Name() = %q", c.Name())
+    }
+
+    // Framed parts and keys apply to each algorithm alike.
+    keyed := Config{Algorithm: SHA256, Also: []Algorithm{SHA1}, Key: []byte("k")}
+    sums := keyed.Split(keyed.Sum([]byte("a"), []byte("b")))
+    for i, a := range keyed.Algorithms() {
+        if want := (Config{Algorithm: a, Key: []byte("k")}).Sum([]byte("a"), []byte("b")); !bytes.Equal(sums[i], want) {
+            t.Errorf("keyed %s: got %x, want %x", a, sums[i], want)
+        }
+    }
+}
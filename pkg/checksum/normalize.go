@@ -0,0 +1,195 @@
+package checksum
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "strings"
+
+    "golang.org/x/text/transform"
+    "golang.org/x/text/unicode/norm"
+)
+
+// Normalization is a set of text transformations applied to input before
+// it is hashed, so that files differing only in line endings, trailing
+// whitespace or Unicode composition hash the same.
+type Normalization uint
+
+const (
+    // NormalizeEOL turns CRLF and lone CR line endings into LF.
+    NormalizeEOL Normalization = 1 << iota
+    // NormalizeTrailingSpace drops spaces, tabs, vertical tabs, form feeds
+    // and carriage returns at the end of each line and of the input.
+    NormalizeTrailingSpace
+    // NormalizeNFC converts UTF-8 text to Unicode normalization form C.
+    NormalizeNFC
+    // NormalizeJSON replaces a JSON document with its CanonicalJSON form.
+    // The whole document is read before anything is returned.
+    NormalizeJSON
+)
+
+var normalizationNames = []struct {
+    n    Normalization
+    name string
+}{
+    {NormalizeEOL, "eol"},
+    {NormalizeTrailingSpace, "trailing-ws"},
+    {NormalizeNFC, "nfc"},
+    {NormalizeJSON, "json"},
+}
+
+// NormalizationNames lists the names accepted by ParseNormalization.
+func NormalizationNames() []string {
+    names := make([]string, len(normalizationNames))
+    for i, n := range normalizationNames {
+        names[i] = n.name
+    }
+    return names
+}
+
+// ParseNormalization parses a comma-separated list of normalization names,
+// such as "eol,nfc". The empty string is no normalization.
+func ParseNormalization(list string) (Normalization, error) {
+    var n Normalization
+    if list == "" {
+        return 0, nil
+    }
+names:
+    for _, name := range strings.Split(list, ",") {
+        for _, known := range normalizationNames {
+            if known.name == name {
+                n |= known.n
+                continue names
+            }
+        }
+        return 0, fmt.Errorf("unknown normalization %q (supported: %s)", name, strings.Join(NormalizationNames(), ", "))
+    }
+    return n, nil
+}
+
+func (n Normalization) String() string {
+    var names []string
+    for _, known := range normalizationNames {
+        if n&known.n != 0 {
+            names = append(names, known.name)
+        }
+    }
+    return strings.Join(names, ",")
+}
+
+// Reader returns r with the normalizations applied as it is read: JSON
+// first, then NFC, line endings and trailing whitespace. It returns r
+// itself when n is empty.
+func (n Normalization) Reader(r io.Reader) io.Reader {
+    if n&NormalizeJSON != 0 {
+        r = &canonicalJSONReader{r: r}
+    }
+    var ts []transform.Transformer
+    if n&NormalizeNFC != 0 {
+        ts = append(ts, norm.NFC)
+    }
+    if n&NormalizeEOL != 0 {
+        ts = append(ts, eolTransformer{})
+    }
+    if n&NormalizeTrailingSpace != 0 {
+        ts = append(ts, &trailingSpaceTransformer{})
+    }
+    if ts == nil {
+        return r
+    }
+    return transform.NewReader(r, transform.Chain(ts...))
+}
+
+// canonicalJSONReader reads all of r on the first Read and then returns
+// its canonical form, or the error that prevented it.
+type canonicalJSONReader struct {
+    r   io.Reader
+    out *bytes.Reader
+    err error
+}
+
+func (c *canonicalJSONReader) Read(p []byte) (int, error) {
+    if c.out == nil && c.err == nil {
+        var data []byte
+        if data, c.err = io.ReadAll(c.r); c.err == nil {
+            data, c.err = CanonicalJSON(data)
+        }
+        c.out = bytes.NewReader(data)
+    }
+    if c.err != nil {
+        return 0, c.err
+    }
+    return c.out.Read(p)
+}
+
+// eolTransformer rewrites CRLF and CR as LF. A CR at the end of src is held
+// back until the next byte shows whether it starts a CRLF.
+type eolTransformer struct{ transform.NopResetter }
+
+func (eolTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+    for nSrc < len(src) {
+        i := bytes.IndexByte(src[nSrc:], '\r')
+        if i < 0 {
+            i = len(src) - nSrc
+        }
+        n := copy(dst[nDst:], src[nSrc:nSrc+i])
+        nDst += n
+        nSrc += n
+        switch {
+        case n < i || nSrc < len(src) && nDst == len(dst):
+            return nDst, nSrc, transform.ErrShortDst
+        case nSrc == len(src):
+            return nDst, nSrc, nil
+        case nSrc+1 == len(src) && !atEOF:
+            return nDst, nSrc, transform.ErrShortSrc
+        }
+        dst[nDst] = '\n'
+        nDst++
+        nSrc++
+        if nSrc < len(src) && src[nSrc] == '\n' {
+            nSrc++
+        }
+    }
+    return nDst, nSrc, nil
+}
+
+// trailingSpaceTransformer drops whitespace before a newline or the end of
+// input. Whitespace is consumed into pending and only written out once a
+// later byte on the same line shows it is not trailing, so runs of any
+// length are handled.
+type trailingSpaceTransformer struct {
+    pending []byte
+}
+
+func (t *trailingSpaceTransformer) Reset() {
+    t.pending = t.pending[:0]
+}
+
+func (t *trailingSpaceTransformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+    for ; nSrc < len(src); nSrc++ {
+        c := src[nSrc]
+        switch {
+        case c == ' ' || c == '\t' || c == '\v' || c == '\f' || c == '\r':
+            t.pending = append(t.pending, c)
+            continue
+        case c == '\n':
+            t.pending = t.pending[:0]
+        case len(t.pending) > 0:
+            n := copy(dst[nDst:], t.pending)
+            nDst += n
+            t.pending = t.pending[:copy(t.pending, t.pending[n:])]
+            if len(t.pending) > 0 {
+                return nDst, nSrc, transform.ErrShortDst
+            }
+        }
+        if nDst == len(dst) {
+            return nDst, nSrc, transform.ErrShortDst
+        }
+        dst[nDst] = c
+        nDst++
+    }
+    if atEOF {
+        t.pending = t.pending[:0]
+    }
+    return nDst, nSrc, nil
+}
@@ -0,0 +1,92 @@
+package checksum
+
+import (
+    "bytes"
+    "encoding/hex"
+    "io"
+    "strings"
+    "testing"
+    "testing/iotest"
+)
+
+func normalized(t *testing.T, n Normalization, r io.Reader) string {
+    t.Helper()
+    out, err := io.ReadAll(n.Reader(r))
+    if err != nil {
+        t.Fatalf("%s: %v", n, err)
+    }
+    return string(out)
+}
+
+func TestNormalize(t *testing.T) {
+    for _, tt := range []struct {
+        n        Normalization
+        in, want string
+    }{
+        {NormalizeEOL, "a\r\nb\rc\nd", "a\nb\nc\nd"},
+        {NormalizeEOL, "\r\r\n\n\r", "\n\n\n\n"},
+        {NormalizeEOL, "no endings", "no endings"},
+        {NormalizeTrailingSpace, "a \t\nb\v\f \n  c  ", "a\nb\n  c"},
+        {NormalizeTrailingSpace, "in side \nx", "in side\nx"},
+        {NormalizeTrailingSpace, "crlf \r\n", "crlf\n"},
+        {NormalizeTrailingSpace, "   ", ""},
+        {NormalizeNFC, "cafe\u0301", "caf\u00e9"},
+        {NormalizeNFC, "caf\u00e9", "caf\u00e9"},
+        {NormalizeEOL | NormalizeTrailingSpace, "a \r\nb\t\rc", "a\nb\nc"},
+        {NormalizeEOL | NormalizeTrailingSpace | NormalizeNFC, "e\u0301 \r\n", "\u00e9\n"},
+        {0, "a \r\n", "a \r\n"},
+    } {
+        // One byte at a time puts every CR, and every run of spaces, on a
+        // read boundary.
+        for _, r := range []io.Reader{strings.NewReader(tt.in), iotest.OneByteReader(strings.NewReader(tt.in))} {
+            if got := normalized(t, tt.n, r); got != tt.want {
+                t.Errorf("%s %q = %q, want %q", tt.n, tt.in, got, tt.want)
+            }
+        }
+    }
+}
+
+func TestNormalizeLarge(t *testing.T) {
+    // Long enough to cross the transformer's internal buffers many times,
+    // with line lengths that put CRs and whitespace runs at every offset.
+    var in, want strings.Builder
+    for i := 0; in.Len() < 1<<20; i++ {
+        line := strings.Repeat("x", i%97)
+        in.WriteString(line + strings.Repeat(" ", i%13) + "\r\n")
+        want.WriteString(line + "\n")
+        in.WriteString(line + "\r")
+        want.WriteString(line + "\n")
+    }
+    got := normalized(t, NormalizeEOL|NormalizeTrailingSpace, strings.NewReader(in.String()))
+    if got != want.String() {
+        t.Errorf("normalized %d bytes to %d, want %d", in.Len(), len(got), want.Len())
+    }
+}
+
+func TestNormalizeSum(t *testing.T) {
+    c := Config{Normalize: NormalizeEOL | NormalizeTrailingSpace}
+    w := NewWriter(c)
+    if _, err := w.ReadPart(strings.NewReader("a \r\nb\rc\t\n")); err != nil {
+        t.Fatal(err)
+    }
+    // sha1("a\nb\nc\n" followed by its 8-byte length).
+    if got := hex.EncodeToString(w.Sum(nil)); got != "5f9d8d5b0977acbbbd17f577e6c8e0c284a63b57" {
+        t.Errorf("normalized sum = %s", got)
+    }
+    if !bytes.Equal(w.Sum(nil), Sum([]byte("a\nb\nc\n"))) {
+        t.Error("normalized sum differs from the sum of the normalized text")
+    }
+}
+
+func TestParseNormalization(t *testing.T) {
+    n, err := ParseNormalization("eol,nfc")
+    if err != nil || n != NormalizeEOL|NormalizeNFC || n.String() != "eol,nfc" {
+        t.Errorf("ParseNormalization(eol,nfc) = %v, %v", n, err)
+    }
+    if n, err := ParseNormalization(""); err != nil || n != 0 {
+        t.Errorf("ParseNormalization(\"\") = %v, %v", n, err)
+    }
+    if _, err := ParseNormalization("eol,crlf"); err == nil {
+        t.Error("ParseNormalization accepted crlf")
+    }
+}
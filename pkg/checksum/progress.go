@@ -0,0 +1,132 @@
+package checksum
+
+import (
+    "fmt"
+    "io"
+    "sync"
+    "time"
+)
+
+// ProgressWriter passes writes through to an underlying writer and reports
+// how many bytes have gone by on a status writer. Tee a reader into it to
+// watch a hash being computed. It is safe for concurrent use, so one
+// ProgressWriter can cover several inputs hashed in parallel.
+//
+// On a terminal the report is a single line rewritten in place at most
+// every TTYInterval; otherwise a plain line is appended every LogInterval.
+// Reports are only made from Write, so a stalled input shows no update.
+type ProgressWriter struct {
+    w      io.Writer
+    status io.Writer
+    total  int64
+    tty    bool
+    now    func() time.Time
+    start  time.Time
+
+    mu    sync.Mutex
+    n     int64
+    last  time.Time
+    shown bool
+}
+
+// Report intervals for terminal and log output.
+const (
+    TTYInterval = 200 * time.Millisecond
+    LogInterval = 5 * time.Second
+)
+
+// NewProgressWriter returns a ProgressWriter forwarding to w, which may be
+// nil to only count. total is the expected number of bytes, or negative
+// when unknown, in which case no percentage or ETA is shown. tty selects
+// in-place updates for an interactive status writer.
+func NewProgressWriter(w, status io.Writer, total int64, tty bool) *ProgressWriter {
+    return newProgressWriter(w, status, total, tty, time.Now)
+}
+
+// newProgressWriter is NewProgressWriter with the clock supplied by now.
+func newProgressWriter(w, status io.Writer, total int64, tty bool, now func() time.Time) *ProgressWriter {
+    start := now()
+    return &ProgressWriter{w: w, status: status, total: total, tty: tty, now: now, start: start, last: start}
+}
+
+func (p *ProgressWriter) Write(b []byte) (int, error) {
+    n := len(b)
+    var err error
+    if p.w != nil {
+        n, err = p.w.Write(b)
+    }
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    p.n += int64(n)
+    interval := LogInterval
+    if p.tty {
+        interval = TTYInterval
+    }
+    if now := p.now(); now.Sub(p.last) >= interval {
+        p.last = now
+        p.report(now)
+    }
+    return n, err
+}
+
+// Done ends the report. On a terminal the progress line is erased so
+// nothing is left to interleave with later output; otherwise a final line
+// with the totals is written.
+func (p *ProgressWriter) Done() {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+    if p.tty {
+        if p.shown {
+            io.WriteString(p.status, "\r\033[K")
+            p.shown = false
+        }
+        return
+    }
+    now := p.now()
+    fmt.Fprintf(p.status, "%s in %s\n", p.line(now), now.Sub(p.start).Round(time.Millisecond))
+}
+
+func (p *ProgressWriter) report(now time.Time) {
+    if p.tty {
+        fmt.Fprintf(p.status, "\r%s\033[K", p.line(now))
+        p.shown = true
+        return
+    }
+    fmt.Fprintln(p.status, p.line(now))
+}
+
+// line describes progress as, for example,
+// "1.2 GiB / 50.0 GiB (2.4%), 350.1 MiB/s, ETA 2m23s".
+func (p *ProgressWriter) line(now time.Time) string {
+    elapsed := now.Sub(p.start).Seconds()
+    rate := 0.0
+    if elapsed > 0 {
+        rate = float64(p.n) / elapsed
+    }
+    if p.total < 0 {
+        return fmt.Sprintf("%s, %s/s", formatBytes(float64(p.n)), formatBytes(rate))
+    }
+    pct := 100.0
+    if p.total > 0 {
+        pct = 100 * float64(p.n) / float64(p.total)
+    }
+    s := fmt.Sprintf("%s / %s (%.1f%%), %s/s", formatBytes(float64(p.n)), formatBytes(float64(p.total)), pct, formatBytes(rate))
+    if rate > 0 && p.n < p.total {
+        eta := time.Duration(float64(p.total-p.n) / rate * float64(time.Second))
+        s += ", ETA " + eta.Round(time.Second).String()
+    }
+    return s
+}
+
+func formatBytes(n float64) string {
+    const units = "KMGTPE"
+    if n < 1024 {
+        return fmt.Sprintf("%.0f B", n)
+    }
+    i := -1
+    for n >= 1024 && i < len(units)-1 {
+        n /= 1024
+        i++
+    }
+    return fmt.Sprintf("%.1f %ciB", n, units[i])
+}
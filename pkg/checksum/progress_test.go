@@ -0,0 +1,104 @@
+package checksum
+
+import (
+    "bytes"
+    "testing"
+    "time"
+)
+
+// fakeClock is a time source that moves only when told to.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestProgressLog(t *testing.T) {
+    clock := &fakeClock{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+    var out, status bytes.Buffer
+    p := newProgressWriter(&out, &status, 100<<20, false, clock.now)
+
+    steps := []struct {
+        after time.Duration
+        mib   int
+        want  string // what Write adds to status
+    }{
+        {time.Second, 10, ""},
+        {4 * time.Second, 10, "20.0 MiB / 100.0 MiB (20.0%), 4.0 MiB/s, ETA 20s\n"},
+        {time.Second, 10, ""},
+        {4 * time.Second, 20, "50.0 MiB / 100.0 MiB (50.0%), 5.0 MiB/s, ETA 10s\n"},
+        {2 * time.Second, 50, ""},
+    }
+    for i, s := range steps {
+        clock.advance(s.after)
+        status.Reset()
+        if n, err := p.Write(make([]byte, s.mib<<20)); n != s.mib<<20 || err != nil {
+            t.Fatalf("Write = %d, %v", n, err)
+        }
+        if got := status.String(); got != s.want {
+            t.Errorf("step %d: status %q, want %q", i, got, s.want)
+        }
+    }
+    if out.Len() != 100<<20 {
+        t.Errorf("passed %d bytes through, want %d", out.Len(), 100<<20)
+    }
+
+    clock.advance(8 * time.Second)
+    status.Reset()
+    p.Done()
+    if got, want := status.String(), "100.0 MiB / 100.0 MiB (100.0%), 5.0 MiB/s in 20s\n"; got != want {
+        t.Errorf("Done: status %q, want %q", got, want)
+    }
+}
+
+func TestProgressTTY(t *testing.T) {
+    clock := &fakeClock{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+    var status bytes.Buffer
+    p := newProgressWriter(nil, &status, -1, true, clock.now)
+
+    // Nothing is shown until TTYInterval has passed, and nothing is left
+    // to erase.
+    p.Write(make([]byte, 512))
+    p.Done()
+    if status.Len() != 0 {
+        t.Errorf("status %q before the first interval", status.String())
+    }
+
+    clock.advance(TTYInterval)
+    p.Write(make([]byte, 512))
+    clock.advance(TTYInterval / 2)
+    p.Write(make([]byte, 1024))
+    clock.advance(TTYInterval / 2)
+    p.Write(make([]byte, 1024))
+    if got, want := status.String(), "\r1.0 KiB, 5.0 KiB/s\033[K\r3.0 KiB, 7.5 KiB/s\033[K"; got != want {
+        t.Errorf("status %q, want %q", got, want)
+    }
+
+    status.Reset()
+    p.Done()
+    if got, want := status.String(), "\r\033[K"; got != want {
+        t.Errorf("Done: status %q, want the line erased with %q", got, want)
+    }
+}
+
+func TestProgressLine(t *testing.T) {
+    start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+    for _, tc := range []struct {
+        total, n int64
+        elapsed  time.Duration
+        want     string
+    }{
+        {-1, 0, 0, "0 B, 0 B/s"},
+        {-1, 1023, time.Second, "1023 B, 1023 B/s"},
+        {0, 0, time.Second, "0 B / 0 B (100.0%), 0 B/s"},
+        {1 << 30, 0, time.Second, "0 B / 1.0 GiB (0.0%), 0 B/s"},
+        {50 << 30, 1288490189, 3 * time.Second, "1.2 GiB / 50.0 GiB (2.4%), 409.6 MiB/s, ETA 2m2s"},
+        // Inputs can outgrow the size they had when the total was taken.
+        {1 << 20, 2 << 20, time.Second, "2.0 MiB / 1.0 MiB (200.0%), 2.0 MiB/s"},
+    } {
+        p := newProgressWriter(nil, nil, tc.total, false, func() time.Time { return start })
+        p.n = tc.n
+        if got := p.line(start.Add(tc.elapsed)); got != tc.want {
+            t.Errorf("%d of %d bytes after %s: %q, want %q", tc.n, tc.total, tc.elapsed, got, tc.want)
+        }
+    }
+}
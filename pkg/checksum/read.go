@@ -0,0 +1,202 @@
+package checksum
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "strings"
+)
+
+// ReadMode selects how SumReaderWith reads its input.
+type ReadMode int
+
+const (
+    // ReadBuffered reads one buffer at a time and hashes it before the
+    // next read, as SumReader does.
+    ReadBuffered ReadMode = iota
+    // ReadMmap maps a regular file into memory and hashes it in place. It
+    // falls back to ReadBuffered for anything that cannot be mapped, such
+    // as pipes, empty files, some network filesystems and platforms
+    // without mmap. A mapped file that is truncated while it is hashed
+    // crashes the process.
+    ReadMmap
+    // ReadAhead reads the next buffer in a second goroutine while the
+    // current one is hashed, which hides read latency on slow storage.
+    ReadAhead
+)
+
+var readModeNames = [...]string{
+    ReadBuffered: "buffered",
+    ReadMmap:     "mmap",
+    ReadAhead:    "readahead",
+}
+
+// ReadModeNames lists the names accepted by ParseReadMode.
+func ReadModeNames() []string {
+    return readModeNames[:]
+}
+
+// ParseReadMode returns the read mode with the given name, such as "mmap".
+func ParseReadMode(name string) (ReadMode, error) {
+    for i, n := range readModeNames {
+        if n == name {
+            return ReadMode(i), nil
+        }
+    }
+    return 0, fmt.Errorf("unknown reader %q (supported: %s)", name, strings.Join(ReadModeNames(), ", "))
+}
+
+func (m ReadMode) String() string {
+    if m < 0 || int(m) >= len(readModeNames) {
+        return fmt.Sprintf("ReadMode(%d)", int(m))
+    }
+    return readModeNames[m]
+}
+
+// ReadOptions controls SumReaderWith. The zero value matches SumReader.
+type ReadOptions struct {
+    Mode ReadMode
+    // Buffer is the size of each read, or of each piece of a mapped file
+    // handed to the hash; 0 means 64 KiB. ReadAhead holds two buffers.
+    Buffer int
+    // Progress, when non-nil, receives a copy of the raw input as it is
+    // hashed.
+    Progress io.Writer
+}
+
+// SumReaderWith is SumReader with a choice of read strategy. It also
+// returns the number of bytes taken from r, before any normalization.
+func (c Config) SumReaderWith(r io.Reader, opts ReadOptions) ([]byte, int64, error) {
+    size := opts.Buffer
+    if size <= 0 {
+        size = chunkSize
+    }
+    h := c.NewHash()
+    h.Write(c.Salt)
+    if f, ok := r.(*os.File); ok && opts.Mode == ReadMmap && c.Normalize == 0 {
+        if n, ok := sumMapped(h, f, size, opts.Progress); ok {
+            return h.Sum(nil), n, nil
+        }
+    }
+    cr := &CountingReader{R: r}
+    var src io.Reader = cr
+    if opts.Progress != nil {
+        src = io.TeeReader(src, opts.Progress)
+    }
+    src = c.Normalize.Reader(src)
+    var err error
+    if opts.Mode == ReadAhead {
+        err = readAhead(h, src, size)
+    } else {
+        // Hide any WriterTo so reads really happen in size pieces.
+        _, err = io.CopyBuffer(h, struct{ io.Reader }{src}, make([]byte, size))
+    }
+    if err != nil {
+        return nil, cr.N, err
+    }
+    return h.Sum(nil), cr.N, nil
+}
+
+// sumMapped hashes the rest of f from a memory mapping, leaving f at its
+// end. It reports false, having read nothing, if f cannot be mapped.
+func sumMapped(h io.Writer, f *os.File, size int, progress io.Writer) (int64, bool) {
+    info, err := f.Stat()
+    if err != nil || !info.Mode().IsRegular() || info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+        return 0, false
+    }
+    off, err := f.Seek(0, io.SeekCurrent)
+    if err != nil || off >= info.Size() {
+        return 0, false
+    }
+    data, err := mmap(f, int(info.Size()))
+    if err != nil {
+        return 0, false
+    }
+    defer munmap(data)
+    rest := data[off:]
+    for p := rest; len(p) > 0; {
+        n := min(size, len(p))
+        h.Write(p[:n])
+        if progress != nil {
+            progress.Write(p[:n])
+        }
+        p = p[n:]
+    }
+    f.Seek(0, io.SeekEnd)
+    return int64(len(rest)), true
+}
+
+// readAhead copies r to w through two buffers of size bytes, so that the
+// next read runs while the previous buffer is being written.
+func readAhead(w io.Writer, r io.Reader, size int) error {
+    type block struct {
+        b   []byte
+        err error
+    }
+    free := make(chan []byte, 2)
+    full := make(chan block, 2)
+    free <- make([]byte, size)
+    free <- make([]byte, size)
+    go func() {
+        defer close(full)
+        for b := range free {
+            n, err := io.ReadFull(r, b)
+            full <- block{b[:n], err}
+            if err != nil {
+                return
+            }
+        }
+    }()
+    defer close(free)
+    for blk := range full {
+        w.Write(blk.b)
+        switch blk.err {
+        case nil:
+            free <- blk.b[:cap(blk.b)]
+        case io.EOF, io.ErrUnexpectedEOF:
+            return nil
+        default:
+            return blk.err
+        }
+    }
+    return nil
+}
+
+// A CountingReader reads from R and adds the number of bytes read to N.
+type CountingReader struct {
+    R io.Reader
+    N int64
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+    n, err := c.R.Read(p)
+    c.N += int64(n)
+    return n, err
+}
+
+// LimitReader returns a reader of r that fails with err, typically a
+// *LimitError, once more than n bytes have been read, rather than stopping
+// at n as io.LimitReader does.
+func LimitReader(r io.Reader, n int64, err error) io.Reader {
+    return &limitReader{r: r, left: n, err: err}
+}
+
+type limitReader struct {
+    r    io.Reader
+    left int64
+    err  error
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+    if int64(len(p)) > l.left+1 {
+        p = p[:l.left+1]
+    }
+    n, err := l.r.Read(p)
+    if int64(n) > l.left {
+        n = int(l.left)
+        l.left = 0
+        return n, l.err
+    }
+    l.left -= int64(n)
+    return n, err
+}
@@ -0,0 +1,168 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/sha1"
+    "errors"
+    "io"
+    "io/fs"
+    "os"
+    "path/filepath"
+    "runtime"
+    "strings"
+    "testing"
+    "testing/iotest"
+)
+
+// TestSumFileSparse hashes a sparse file of several hundred megabytes and
+// checks that memory use does not grow with it.
+func TestSumFileSparse(t *testing.T) {
+    if testing.Short() {
+        t.Skip("hashes 300MB")
+    }
+    const size = 300 << 20
+    path := filepath.Join(t.TempDir(), "sparse")
+    f, err := os.Create(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := f.Truncate(size); err != nil {
+        t.Fatal(err)
+    }
+    f.Close()
+
+    h := sha1.New()
+    zeros := make([]byte, 1<<20)
+    for range size / len(zeros) {
+        h.Write(zeros)
+    }
+    want := h.Sum(nil)
+
+    var before, after runtime.MemStats
+    runtime.GC()
+    runtime.ReadMemStats(&before)
+    got, err := Config{}.SumFile(path)
+    runtime.ReadMemStats(&after)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.Equal(got, want) {
+        t.Errorf("SumFile = %x, want %x", got, want)
+    }
+    if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+        t.Errorf("SumFile of %d bytes allocated %d bytes", size, alloc)
+    }
+}
+
+func TestSumFileMissing(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "missing")
+    _, err := Config{}.SumFile(path)
+    var ie *InputError
+    if !errors.As(err, &ie) || ie.Path != path || !errors.Is(err, fs.ErrNotExist) {
+        t.Errorf("SumFile of a missing file: %v", err)
+    }
+}
+
+func TestReadModes(t *testing.T) {
+    data := bytes.Repeat([]byte("read modes agree\n"), 10000)
+    dir := t.TempDir()
+    path := filepath.Join(dir, "data")
+    if err := os.WriteFile(path, data, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    empty := filepath.Join(dir, "empty")
+    if err := os.WriteFile(empty, nil, 0o644); err != nil {
+        t.Fatal(err)
+    }
+    c := Config{Algorithm: SHA256}
+    want, _ := c.SumReader(bytes.NewReader(data))
+    tail, _ := c.SumReader(bytes.NewReader(data[1000:]))
+    none, _ := c.SumReader(bytes.NewReader(nil))
+
+    for _, mode := range []ReadMode{ReadBuffered, ReadMmap, ReadAhead} {
+        for _, buffer := range []int{0, 1, 4096, 1 << 20} {
+            opts := ReadOptions{Mode: mode, Buffer: buffer}
+            check := func(what string, r io.Reader, wantSum []byte, wantN int64) {
+                t.Helper()
+                got, n, err := c.SumReaderWith(r, opts)
+                if err != nil || !bytes.Equal(got, wantSum) || n != wantN {
+                    t.Errorf("%s, buffer %d, %s: %x, %d bytes, %v; want %x, %d bytes", mode, buffer, what, got, n, err, wantSum, wantN)
+                }
+            }
+            f, err := os.Open(path)
+            if err != nil {
+                t.Fatal(err)
+            }
+            check("file", f, want, int64(len(data)))
+            // The file is left at its end, so the rest of it is empty.
+            check("file at its end", f, none, 0)
+            f.Seek(1000, io.SeekStart)
+            check("file from an offset", f, tail, int64(len(data)-1000))
+            f.Close()
+
+            f, _ = os.Open(empty)
+            check("empty file", f, none, 0)
+            f.Close()
+            check("reader", iotest.HalfReader(bytes.NewReader(data)), want, int64(len(data)))
+
+            var progress bytes.Buffer
+            f, _ = os.Open(path)
+            opts.Progress = &progress
+            check("file with progress", f, want, int64(len(data)))
+            f.Close()
+            if !bytes.Equal(progress.Bytes(), data) {
+                t.Errorf("%s: progress saw %d bytes, want %d", mode, progress.Len(), len(data))
+            }
+        }
+
+        errRead := errors.New("read failed")
+        r := io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errRead))
+        if _, _, err := c.SumReaderWith(r, ReadOptions{Mode: mode}); !errors.Is(err, errRead) {
+            t.Errorf("%s: read error %v, want %v", mode, err, errRead)
+        }
+    }
+
+    // Normalization needs the bytes read through it, so mmap falls back.
+    f, _ := os.Open(path)
+    defer f.Close()
+    n := Config{Algorithm: SHA256, Normalize: NormalizeEOL}
+    got, _, err := n.SumReaderWith(f, ReadOptions{Mode: ReadMmap})
+    if wantN, _ := n.SumReader(bytes.NewReader(data)); err != nil || !bytes.Equal(got, wantN) {
+        t.Errorf("normalized mmap: %x, %v", got, err)
+    }
+}
+
+func TestParseReadMode(t *testing.T) {
+    for _, name := range ReadModeNames() {
+        if m, err := ParseReadMode(name); err != nil || m.String() != name {
+            t.Errorf("ParseReadMode(%q) = %v, %v", name, m, err)
+        }
+    }
+    if _, err := ParseReadMode("direct"); err == nil {
+        t.Error("ParseReadMode accepted direct")
+    }
+}
+
+func BenchmarkReadModes(b *testing.B) {
+    path := filepath.Join(b.TempDir(), "data")
+    data := make([]byte, 16<<20)
+    if err := os.WriteFile(path, data, 0o644); err != nil {
+        b.Fatal(err)
+    }
+    c := Config{Algorithm: XXHash64}
+    for _, mode := range []ReadMode{ReadBuffered, ReadMmap, ReadAhead} {
+        b.Run(mode.String(), func(b *testing.B) {
+            b.SetBytes(int64(len(data)))
+            for b.Loop() {
+                f, err := os.Open(path)
+                if err != nil {
+                    b.Fatal(err)
+                }
+                if _, _, err := c.SumReaderWith(f, ReadOptions{Mode: mode}); err != nil {
+                    b.Fatal(err)
+                }
+                f.Close()
+            }
+        })
+    }
+}
@@ -0,0 +1,39 @@
+package checksum
+
+import (
+    "crypto/ed25519"
+    "errors"
+)
+
+// signContext starts every signed message, so randomtool signatures cannot
+// be mistaken for ed25519 signatures made for any other purpose.
+const signContext = "randomtool signature v1\x00"
+
+var errNotCryptographic = errors.New("checksum: signatures need a cryptographic algorithm")
+
+// SignedMessage is the message Sign signs for a digest computed with a:
+// a fixed context string, the algorithm name and a NUL, then the full
+// digest. Naming the algorithm keeps a signature over a SHA-1 digest from
+// being replayed as one over a SHA-256 digest with the same bytes.
+func SignedMessage(a Algorithm, digest []byte) []byte {
+    msg := make([]byte, 0, len(signContext)+len(a.String())+1+len(digest))
+    msg = append(msg, signContext...)
+    msg = append(msg, a.String()...)
+    msg = append(msg, 0)
+    return append(msg, digest...)
+}
+
+// Sign returns the ed25519 signature of the untruncated digest computed
+// with a.
+func Sign(priv ed25519.PrivateKey, a Algorithm, digest []byte) ([]byte, error) {
+    if !a.Cryptographic() {
+        return nil, errNotCryptographic
+    }
+    return ed25519.Sign(priv, SignedMessage(a, digest)), nil
+}
+
+// VerifySignature reports whether sig is a valid Sign signature of digest
+// under pub.
+func VerifySignature(pub ed25519.PublicKey, a Algorithm, digest, sig []byte) bool {
+    return a.Cryptographic() && len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, SignedMessage(a, digest), sig)
+}
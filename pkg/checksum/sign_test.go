@@ -0,0 +1,58 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/ed25519"
+    "testing"
+)
+
+func TestSign(t *testing.T) {
+    priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+    pub := priv.Public().(ed25519.PublicKey)
+    digest := Config{Algorithm: SHA256}.Sum(parts("a", "b")...)
+    sig, err := Sign(priv, SHA256, digest)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !VerifySignature(pub, SHA256, digest, sig) {
+        t.Fatal("signature does not verify")
+    }
+
+    for i := range len(digest) * 8 {
+        d := bytes.Clone(digest)
+        d[i/8] ^= 1 << (i % 8)
+        if VerifySignature(pub, SHA256, d, sig) {
+            t.Fatalf("verifies with bit %d of the digest flipped", i)
+        }
+    }
+    for i := range len(sig) * 8 {
+        s := bytes.Clone(sig)
+        s[i/8] ^= 1 << (i % 8)
+        if VerifySignature(pub, SHA256, digest, s) {
+            t.Fatalf("verifies with bit %d of the signature flipped", i)
+        }
+    }
+
+    // BLAKE2b-256 digests are as long as SHA-256 ones, but the algorithm
+    // name is signed too.
+    if VerifySignature(pub, BLAKE2b256, digest, sig) {
+        t.Error("a sha256 signature verifies as blake2b-256")
+    }
+    other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{8}, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+    if VerifySignature(other, SHA256, digest, sig) || VerifySignature(pub[:31], SHA256, digest, sig) {
+        t.Error("verifies under the wrong key")
+    }
+    if _, err := Sign(priv, XXHash64, make([]byte, 8)); err == nil {
+        t.Error("signed an xxhash64 digest")
+    }
+    if VerifySignature(pub, XXHash64, digest[:8], ed25519.Sign(priv, SignedMessage(XXHash64, digest[:8]))) {
+        t.Error("verified an xxhash64 signature")
+    }
+}
+
+func TestSignedMessage(t *testing.T) {
+    got := SignedMessage(SHA1, []byte{0xde, 0xad})
+    if want := "randomtool signature v1\x00sha1\x00\xde\xad"; string(got) != want {
+        t.Errorf("SignedMessage = %q, want %q", got, want)
+    }
+}
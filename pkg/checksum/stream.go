@@ -0,0 +1,52 @@
+package checksum
+
+import (
+    "crypto/hkdf"
+    "crypto/sha256"
+    "io"
+    "strconv"
+
+    "golang.org/x/crypto/chacha20"
+)
+
+// streamInfo labels the HKDF expansion behind NewStream. Changing it, or
+// anything else in NewStream, changes every stream ever produced.
+const streamInfo = "randomtool stream v1"
+
+// NewStream returns an endless deterministic pseudo-random byte stream
+// seeded by digest. The seed is expanded with HKDF-SHA256 into a ChaCha20
+// key, using an all-zero nonce, and the stream is the raw keystream. The
+// same digest yields the same bytes on every platform. ChaCha20's 32-bit
+// block counter limits a stream to 256 GiB; reading past that panics.
+func NewStream(digest []byte) io.Reader {
+    key, err := hkdf.Key(sha256.New, digest, nil, streamInfo, chacha20.KeySize)
+    if err != nil {
+        panic("checksum: " + err.Error())
+    }
+    c, err := chacha20.NewUnauthenticatedCipher(key, make([]byte, chacha20.NonceSize))
+    if err != nil {
+        panic("checksum: " + err.Error())
+    }
+    return &stream{c: c}
+}
+
+type stream struct {
+    c *chacha20.Cipher
+}
+
+func (s *stream) Read(p []byte) (int, error) {
+    clear(p)
+    s.c.XORKeyStream(p, p)
+    return len(p), nil
+}
+
+// SubSeed derives the seed of stream index from seed, so one seed can
+// drive any number of independent streams. Like NewStream, it must never
+// change.
+func SubSeed(seed []byte, index uint64) []byte {
+    key, err := hkdf.Key(sha256.New, seed, nil, streamInfo+" sub-seed "+strconv.FormatUint(index, 10), sha256.Size)
+    if err != nil {
+        panic("checksum: " + err.Error())
+    }
+    return key
+}
@@ -0,0 +1,71 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "io"
+    "testing"
+)
+
+// The stream of the framed SHA-1 checksum of "a", "b", "c", and of its
+// seventh SubSeed, as an independent HKDF and ChaCha20 implementation
+// computes them. These must never change.
+const (
+    streamSeed     = "dcb7a83334052d8982fbf9b2b21310abee7b9a6f"
+    streamPrefix   = "dfaed995866cf7a6eb0645b6c2fc7d43f0273d27c148f31f11471d5824eff474a215119114716db0806611a84a5e408537fb99a456a2a6f016be6522a6755ef2d2775e3ff336a9f245e0d40fd0a44673"
+    streamMiBHash  = "7f05f915f645f12451fa69ac9ea69f3ac4db6eca1caecea67fe488283649b071"
+    subSeed0       = "da8bd9bb025c15c28d157a2cc23e52cd534ee882d6c5a31ac6bba7e3b2a140ef"
+    subSeed7       = "a804e19d6fa5f3a9c3d3009749004fdd6fa47ea62408f45bbbc07999f6470edd"
+    subSeed7Prefix = "cb01e53207773d1c541dc5f137108cce"
+)
+
+func readHex(t *testing.T, r io.Reader, n int) string {
+    t.Helper()
+    b := make([]byte, n)
+    if _, err := io.ReadFull(r, b); err != nil {
+        t.Fatal(err)
+    }
+    return hex.EncodeToString(b)
+}
+
+func TestStream(t *testing.T) {
+    seed, _ := hex.DecodeString(streamSeed)
+    if got := readHex(t, NewStream(seed), len(streamPrefix)/2); got != streamPrefix {
+        t.Errorf("NewStream = %s, want %s", got, streamPrefix)
+    }
+
+    // Reads of any size, crossing ChaCha20 block boundaries, give the same
+    // bytes.
+    var chunked bytes.Buffer
+    s := NewStream(seed)
+    for _, n := range []int{1, 3, 60, 0, 7, 64, 129, 1000} {
+        chunked.WriteString(readHex(t, s, n))
+    }
+    whole := readHex(t, NewStream(seed), 1264)
+    if chunked.String() != whole {
+        t.Error("chunked reads differ from a single read")
+    }
+
+    h := sha256.New()
+    if _, err := io.CopyN(h, NewStream(seed), 1<<20); err != nil {
+        t.Fatal(err)
+    }
+    if got := hex.EncodeToString(h.Sum(nil)); got != streamMiBHash {
+        t.Errorf("sha256 of the first MiB = %s, want %s", got, streamMiBHash)
+    }
+}
+
+func TestSubSeed(t *testing.T) {
+    seed, _ := hex.DecodeString(streamSeed)
+    if got := hex.EncodeToString(SubSeed(seed, 0)); got != subSeed0 {
+        t.Errorf("SubSeed(0) = %s, want %s", got, subSeed0)
+    }
+    sub := SubSeed(seed, 7)
+    if got := hex.EncodeToString(sub); got != subSeed7 {
+        t.Errorf("SubSeed(7) = %s, want %s", got, subSeed7)
+    }
+    if got := readHex(t, NewStream(sub), 16); got != subSeed7Prefix {
+        t.Errorf("stream of SubSeed(7) = %s, want %s", got, subSeed7Prefix)
+    }
+}
@@ -0,0 +1,207 @@
+package checksum
+
+import (
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "path"
+    "path/filepath"
+    "slices"
+    "strings"
+)
+
+// EntryType is the kind of a directory tree entry.
+type EntryType byte
+
+const (
+    File    EntryType = 'f'
+    Dir     EntryType = 'd'
+    Symlink EntryType = 'l'
+)
+
+func (t EntryType) String() string {
+    switch t {
+    case File:
+        return "file"
+    case Dir:
+        return "dir"
+    case Symlink:
+        return "symlink"
+    }
+    return fmt.Sprintf("EntryType(%q)", byte(t))
+}
+
+// TreeEntry is one path below the root of a tree walk.
+type TreeEntry struct {
+    // Path is slash-separated and relative to the root.
+    Path string
+    Type EntryType
+    // Digest is the raw content digest of a file (as SumFile computes it)
+    // and nil for other types.
+    Digest []byte
+    // Size is the length of a file in bytes and 0 for other types.
+    Size int64
+    // Target is the link target of a symlink, which is never followed.
+    Target string
+    // Mode holds permission bits to include in the tree hash. Directory
+    // walks leave it zero; archive listings set it for files when asked to.
+    Mode fs.FileMode
+}
+
+// TreeOptions controls which entries a tree walk visits.
+type TreeOptions struct {
+    // Exclude holds filepath.Match patterns. An entry is skipped, along
+    // with everything below it, if a pattern matches its name or its
+    // slash-separated path relative to the root.
+    Exclude []string
+    // NoDigest leaves TreeEntry.Digest nil, for callers that only need the
+    // listing or hash files on their own terms.
+    NoDigest bool
+    // Git skips .git and whatever the .gitignore files of the git work
+    // tree holding the root ignore, along with .git/info/exclude. The
+    // files are parsed here; git itself is not run.
+    Git bool
+    // TrackedOnly, with Git, keeps only the files in the git index, which
+    // as in git includes tracked files that an ignore file matches.
+    TrackedOnly bool
+}
+
+func (o TreeOptions) excluded(rel string) bool {
+    for _, pattern := range o.Exclude {
+        if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+            return true
+        }
+        if ok, _ := path.Match(pattern, rel); ok {
+            return true
+        }
+    }
+    return false
+}
+
+// Tree walks the directory at root and returns its entries sorted by path.
+// The root itself is not an entry.
+func (c Config) Tree(root string, opts TreeOptions) ([]TreeEntry, error) {
+    for _, pattern := range opts.Exclude {
+        if _, err := path.Match(pattern, ""); err != nil {
+            return nil, fmt.Errorf("bad exclude pattern %q: %w", pattern, err)
+        }
+    }
+    info, err := os.Stat(root)
+    if err != nil {
+        return nil, inputError(root, err)
+    }
+    if !info.IsDir() {
+        return nil, inputError(root, errors.New("not a directory"))
+    }
+    var git *gitFilter
+    if opts.Git {
+        if git, err = newGitFilter(root, opts.TrackedOnly); err != nil {
+            return nil, inputError(root, err)
+        }
+    }
+
+    var entries []TreeEntry
+    err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+        if err != nil {
+            return err
+        }
+        if p == root {
+            return nil
+        }
+        rel, err := filepath.Rel(root, p)
+        if err != nil {
+            return err
+        }
+        rel = filepath.ToSlash(rel)
+        if opts.excluded(rel) || git != nil && git.skip(rel, d.IsDir()) {
+            if d.IsDir() {
+                return filepath.SkipDir
+            }
+            return nil
+        }
+        if git != nil && d.IsDir() {
+            if err := git.load(path.Join(git.prefix, rel), filepath.Join(p, ".gitignore")); err != nil {
+                return err
+            }
+        }
+        entry := TreeEntry{Path: rel}
+        switch {
+        case d.Type()&fs.ModeSymlink != 0:
+            entry.Type = Symlink
+            if entry.Target, err = os.Readlink(p); err != nil {
+                return err
+            }
+        case d.IsDir():
+            entry.Type = Dir
+        case d.Type().IsRegular():
+            entry.Type = File
+            info, err := d.Info()
+            if err != nil {
+                return err
+            }
+            entry.Size = info.Size()
+            if !opts.NoDigest {
+                if entry.Digest, err = c.SumFile(p); err != nil {
+                    return err
+                }
+            }
+        default:
+            return fmt.Errorf("%s: unsupported file type %v", p, d.Type())
+        }
+        entries = append(entries, entry)
+        return nil
+    })
+    if err != nil {
+        return nil, inputError(root, err)
+    }
+    slices.SortFunc(entries, byPath)
+    return entries, nil
+}
+
+func byPath(a, b TreeEntry) int {
+    return strings.Compare(a.Path, b.Path)
+}
+
+// TreeSum folds entries into a single digest. It is the second level of a
+// two-level tree hash whose first level is the per-file content digest:
+// each entry contributes three framed parts to one Writer, in path order:
+//
+//	type   one byte: 'f', 'd' or 'l', followed by the permission bits
+//	       as 4 big-endian bytes when Mode is non-zero
+//	path   the slash-separated relative path
+//	value  the content digest for files, the link target for symlinks,
+//	       empty for directories
+//
+// The legacy setting of c is ignored; tree hashes are always framed.
+func (c Config) TreeSum(entries []TreeEntry) []byte {
+    c.Legacy = false
+    w := NewWriter(c)
+    for _, e := range slices.SortedFunc(slices.Values(entries), byPath) {
+        w.Write([]byte{byte(e.Type)})
+        if e.Mode != 0 {
+            w.Write(binary.BigEndian.AppendUint32(nil, uint32(e.Mode.Perm())))
+        }
+        w.EndPart()
+        w.Write([]byte(e.Path))
+        w.EndPart()
+        switch e.Type {
+        case File:
+            w.Write(e.Digest)
+        case Symlink:
+            w.Write([]byte(e.Target))
+        }
+        w.EndPart()
+    }
+    return w.Sum(nil)
+}
+
+// SumTree returns the tree hash of the directory at root.
+func (c Config) SumTree(root string, opts TreeOptions) ([]byte, error) {
+    entries, err := c.Tree(root, opts)
+    if err != nil {
+        return nil, err
+    }
+    return c.TreeSum(entries), nil
+}
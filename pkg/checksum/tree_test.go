@@ -0,0 +1,153 @@
+package checksum
+
+import (
+    "bytes"
+    "crypto/sha1"
+    "errors"
+    "os"
+    "path/filepath"
+    "slices"
+    "testing"
+)
+
+// buildTree writes files, mapping slash-separated paths to contents, below
+// a new directory in the order given.
+func buildTree(t *testing.T, files [][2]string) string {
+    t.Helper()
+    dir := t.TempDir()
+    for _, f := range files {
+        p := filepath.Join(dir, filepath.FromSlash(f[0]))
+        if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.WriteFile(p, []byte(f[1]), 0o644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    return dir
+}
+
+var treeFiles = [][2]string{
+    {"a.txt", "top\n"},
+    {"b/c.txt", "nested\n"},
+    {"b/d/e.txt", "deep\n"},
+    {"z", ""},
+}
+
+func sumTree(t *testing.T, dir string, opts TreeOptions) []byte {
+    t.Helper()
+    sum, err := Config{}.SumTree(dir, opts)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return sum
+}
+
+func TestTreeSum(t *testing.T) {
+    dir := buildTree(t, treeFiles)
+    // The documented layout: type, path and value parts for every entry in
+    // path order.
+    digest := func(s string) string {
+        d := sha1.Sum([]byte(s))
+        return string(d[:])
+    }
+    want := Sum(parts(
+        "f", "a.txt", digest("top\n"),
+        "d", "b", "",
+        "f", "b/c.txt", digest("nested\n"),
+        "d", "b/d", "",
+        "f", "b/d/e.txt", digest("deep\n"),
+        "f", "z", digest(""),
+    )...)
+    if got := sumTree(t, dir, TreeOptions{}); !bytes.Equal(got, want) {
+        t.Errorf("SumTree = %x, want %x", got, want)
+    }
+    if got := (Config{Legacy: true}).TreeSum(mustTree(t, dir)); !bytes.Equal(got, want) {
+        t.Errorf("legacy TreeSum = %x, want the framed %x", got, want)
+    }
+}
+
+func mustTree(t *testing.T, dir string) []TreeEntry {
+    t.Helper()
+    entries, err := Config{}.Tree(dir, TreeOptions{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return entries
+}
+
+func TestTreeOrder(t *testing.T) {
+    want := sumTree(t, buildTree(t, treeFiles), TreeOptions{})
+    reversed := slices.Clone(treeFiles)
+    slices.Reverse(reversed)
+    if got := sumTree(t, buildTree(t, reversed), TreeOptions{}); !bytes.Equal(got, want) {
+        t.Errorf("tree built in reverse sums to %x, want %x", got, want)
+    }
+    entries := mustTree(t, buildTree(t, treeFiles))
+    slices.Reverse(entries)
+    if got := (Config{}).TreeSum(entries); !bytes.Equal(got, want) {
+        t.Errorf("TreeSum of reversed entries = %x, want %x", got, want)
+    }
+}
+
+func TestTreeChanges(t *testing.T) {
+    want := sumTree(t, buildTree(t, treeFiles), TreeOptions{})
+    for name, files := range map[string][][2]string{
+        "byte changed": {treeFiles[0], treeFiles[1], {"b/d/e.txt", "deeP\n"}, treeFiles[3]},
+        "renamed":      {treeFiles[0], treeFiles[1], {"b/d/f.txt", "deep\n"}, treeFiles[3]},
+        "moved":        {treeFiles[0], treeFiles[1], {"b/e.txt", "deep\n"}, treeFiles[3]},
+        "added":        append(slices.Clone(treeFiles), [2]string{"b/d/empty", ""}),
+        "removed":      treeFiles[:3],
+    } {
+        if got := sumTree(t, buildTree(t, files), TreeOptions{}); bytes.Equal(got, want) {
+            t.Errorf("%s: tree hash unchanged", name)
+        }
+    }
+}
+
+func TestTreeSymlink(t *testing.T) {
+    dir := buildTree(t, treeFiles)
+    if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+        t.Skip("symlinks unsupported:", err)
+    }
+    before := sumTree(t, dir, TreeOptions{})
+    // The link is not followed: it is hashed by its target path alone.
+    entries := mustTree(t, dir)
+    i := slices.IndexFunc(entries, func(e TreeEntry) bool { return e.Path == "link" })
+    if i < 0 || entries[i].Type != Symlink || entries[i].Target != "a.txt" || entries[i].Digest != nil {
+        t.Fatalf("link entry: %+v", entries)
+    }
+    os.Remove(filepath.Join(dir, "link"))
+    os.Symlink("z", filepath.Join(dir, "link"))
+    if after := sumTree(t, dir, TreeOptions{}); bytes.Equal(after, before) {
+        t.Error("retargeting a symlink does not change the tree hash")
+    }
+}
+
+func TestTreeExclude(t *testing.T) {
+    want := sumTree(t, buildTree(t, treeFiles), TreeOptions{})
+    files := append(slices.Clone(treeFiles),
+        [2]string{"node_modules/x/y.js", "junk"},
+        [2]string{"b/.git/HEAD", "ref"},
+        [2]string{"b/d/skip.tmp", "tmp"},
+    )
+    dir := buildTree(t, files)
+    opts := TreeOptions{Exclude: []string{"node_modules", ".git", "b/d/*.tmp"}}
+    if got := sumTree(t, dir, opts); !bytes.Equal(got, want) {
+        t.Errorf("excluded entries change the tree hash: %x, want %x", got, want)
+    }
+    if _, err := (Config{}).SumTree(dir, TreeOptions{Exclude: []string{"["}}); err == nil {
+        t.Error("bad exclude pattern accepted")
+    }
+}
+
+func TestTreeNotDirectory(t *testing.T) {
+    dir := buildTree(t, treeFiles)
+    for _, root := range []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "missing")} {
+        _, err := Config{}.SumTree(root, TreeOptions{})
+        var ie *InputError
+        if !errors.As(err, &ie) || ie.Path != root {
+            t.Errorf("SumTree(%s): %v", root, err)
+        }
+    }
+}
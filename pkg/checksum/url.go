@@ -0,0 +1,64 @@
+package checksum
+
+import (
+    "context"
+    "errors"
+    "io"
+    "net/http"
+)
+
+// ErrTooLarge is returned when a download exceeds URLOptions.MaxBytes.
+var ErrTooLarge = errors.New("response body exceeds size limit")
+
+// URLOptions controls how OpenURL downloads a resource.
+type URLOptions struct {
+    // Client performs the request; nil means http.DefaultClient. Timeouts
+    // and redirect limits are configured on the client.
+    Client *http.Client
+    // MaxBytes fails the download once the body exceeds this many bytes.
+    // Zero means no limit.
+    MaxBytes int64
+    // Progress, when non-nil, receives a copy of the body as it is read,
+    // typically a ProgressWriter.
+    Progress io.Writer
+}
+
+// OpenURL issues a GET for url and returns the response body for streaming
+// into a hash. Any status outside 2xx is an error. Errors are reported as an
+// *InputError; ErrTooLarge may also come from reading the body.
+func OpenURL(ctx context.Context, url string, opts URLOptions) (io.ReadCloser, error) {
+    client := opts.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, inputError(url, err)
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return nil, inputError(url, err)
+    }
+    if resp.StatusCode < 200 || resp.StatusCode > 299 {
+        resp.Body.Close()
+        return nil, inputError(url, errors.New(resp.Status))
+    }
+    body := resp.Body
+    if opts.MaxBytes > 0 {
+        if resp.ContentLength > opts.MaxBytes {
+            resp.Body.Close()
+            return nil, inputError(url, ErrTooLarge)
+        }
+        body = struct {
+            io.Reader
+            io.Closer
+        }{LimitReader(body, opts.MaxBytes, ErrTooLarge), body}
+    }
+    if opts.Progress != nil {
+        body = struct {
+            io.Reader
+            io.Closer
+        }{io.TeeReader(body, opts.Progress), body}
+    }
+    return body, nil
+}
@@ -0,0 +1,97 @@
+package checksum
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+)
+
+func newURLServer(t *testing.T) *httptest.Server {
+    t.Helper()
+    mux := http.NewServeMux()
+    mux.HandleFunc("/abc", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "abc") })
+    mux.Handle("/redirect", http.RedirectHandler("/abc", http.StatusFound))
+    mux.HandleFunc("/missing", http.NotFound)
+    // Without a Content-Length, so only reading the body can find it too
+    // large.
+    mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+        io.WriteString(w, "ab")
+        w.(http.Flusher).Flush()
+        io.WriteString(w, "cd")
+    })
+    mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case <-r.Context().Done():
+        case <-time.After(5 * time.Second):
+        }
+    })
+    srv := httptest.NewServer(mux)
+    t.Cleanup(srv.Close)
+    return srv
+}
+
+func sumURL(url string, opts URLOptions) ([]byte, error) {
+    body, err := OpenURL(context.Background(), url, opts)
+    if err != nil {
+        return nil, err
+    }
+    defer body.Close()
+    return Config{}.SumReader(body)
+}
+
+func TestOpenURL(t *testing.T) {
+    srv := newURLServer(t)
+    abc, _ := Config{}.SumReader(strings.NewReader("abc"))
+    var progress bytes.Buffer
+    for _, path := range []string{"/abc", "/redirect"} {
+        progress.Reset()
+        got, err := sumURL(srv.URL+path, URLOptions{Client: srv.Client(), MaxBytes: 3, Progress: &progress})
+        if err != nil || !bytes.Equal(got, abc) {
+            t.Errorf("%s: %x, %v; want %x", path, got, err, abc)
+        }
+        if progress.String() != "abc" {
+            t.Errorf("%s: progress saw %q", path, progress.String())
+        }
+    }
+    if _, err := sumURL(srv.URL+"/chunked", URLOptions{Client: srv.Client(), MaxBytes: 4}); err != nil {
+        t.Errorf("body of exactly MaxBytes: %v", err)
+    }
+}
+
+func TestOpenURLErrors(t *testing.T) {
+    srv := newURLServer(t)
+    noRedirects := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+        return errors.New("redirect refused")
+    }}
+    for _, tt := range []struct {
+        path string
+        opts URLOptions
+        is   error
+    }{
+        {"/missing", URLOptions{}, nil},
+        {"/abc", URLOptions{MaxBytes: 2}, ErrTooLarge},
+        {"/chunked", URLOptions{MaxBytes: 3}, ErrTooLarge},
+        {"/redirect", URLOptions{Client: noRedirects}, nil},
+        {"/slow", URLOptions{Client: &http.Client{Timeout: 50 * time.Millisecond}}, nil},
+    } {
+        _, err := sumURL(srv.URL+tt.path, tt.opts)
+        var ie *InputError
+        switch {
+        case err == nil:
+            t.Errorf("%s: no error", tt.path)
+        case tt.is != nil && !errors.Is(err, tt.is):
+            t.Errorf("%s: %v, want %v", tt.path, err, tt.is)
+        case tt.path != "/chunked" && !errors.As(err, &ie):
+            t.Errorf("%s: error %T is not an *InputError", tt.path, err)
+        }
+    }
+    if _, err := OpenURL(context.Background(), "://bad", URLOptions{}); err == nil {
+        t.Error("malformed URL accepted")
+    }
+}
@@ -0,0 +1,123 @@
+package checksum
+
+import (
+    "encoding/binary"
+    "hash"
+    "math/bits"
+)
+
+// A pure Go XXH64 (seed 0), following the reference description at
+// https://github.com/Cyan4973/xxHash/blob/dev/doc/xxhash_spec.md.
+
+const (
+    xxPrime1 uint64 = 11400714785074694791
+    xxPrime2 uint64 = 14029467366897019727
+    xxPrime3 uint64 = 1609587929392839161
+    xxPrime4 uint64 = 9650029242287828579
+    xxPrime5 uint64 = 2870177450012600261
+)
+
+type xxhash64 struct {
+    v     [4]uint64
+    total uint64
+    buf   [32]byte
+    n     int
+}
+
+func newXXHash64() hash.Hash64 {
+    x := new(xxhash64)
+    x.Reset()
+    return x
+}
+
+func (x *xxhash64) Reset() {
+    // Variables, not constants, so the sums wrap instead of overflowing.
+    p1, p2 := xxPrime1, xxPrime2
+    x.v = [4]uint64{p1 + p2, p2, 0, -p1}
+    x.total = 0
+    x.n = 0
+}
+
+func (x *xxhash64) Size() int      { return 8 }
+func (x *xxhash64) BlockSize() int { return 32 }
+
+func xxRound(acc, input uint64) uint64 {
+    return bits.RotateLeft64(acc+input*xxPrime2, 31) * xxPrime1
+}
+
+func xxMerge(acc, v uint64) uint64 {
+    return (acc^xxRound(0, v))*xxPrime1 + xxPrime4
+}
+
+func (x *xxhash64) stripe(b []byte) {
+    for i := range x.v {
+        x.v[i] = xxRound(x.v[i], binary.LittleEndian.Uint64(b[8*i:]))
+    }
+}
+
+func (x *xxhash64) Write(p []byte) (int, error) {
+    n := len(p)
+    x.total += uint64(n)
+    if x.n > 0 {
+        c := copy(x.buf[x.n:], p)
+        x.n += c
+        p = p[c:]
+        if x.n < len(x.buf) {
+            return n, nil
+        }
+        x.stripe(x.buf[:])
+        x.n = 0
+    }
+    for ; len(p) >= 32; p = p[32:] {
+        x.stripe(p)
+    }
+    x.n = copy(x.buf[:], p)
+    return n, nil
+}
+
+func (x *xxhash64) Sum64() uint64 {
+    var h uint64
+    if x.total >= 32 {
+        h = bits.RotateLeft64(x.v[0], 1) + bits.RotateLeft64(x.v[1], 7) +
+            bits.RotateLeft64(x.v[2], 12) + bits.RotateLeft64(x.v[3], 18)
+        for _, v := range x.v {
+            h = xxMerge(h, v)
+        }
+    } else {
+        h = xxPrime5
+    }
+    h += x.total
+
+    p := x.buf[:x.n]
+    for ; len(p) >= 8; p = p[8:] {
+        h ^= xxRound(0, binary.LittleEndian.Uint64(p))
+        h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+    }
+    if len(p) >= 4 {
+        h ^= uint64(binary.LittleEndian.Uint32(p)) * xxPrime1
+        h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+        p = p[4:]
+    }
+    for _, b := range p {
+        h ^= uint64(b) * xxPrime5
+        h = bits.RotateLeft64(h, 11) * xxPrime1
+    }
+
+    h ^= h >> 33
+    h *= xxPrime2
+    h ^= h >> 29
+    h *= xxPrime3
+    h ^= h >> 32
+    return h
+}
+
+// Sum appends the digest in big-endian order, the canonical XXH64 form.
+func (x *xxhash64) Sum(b []byte) []byte {
+    return binary.BigEndian.AppendUint64(b, x.Sum64())
+}
+
+// Clone implements hash.Cloner.
+func (x *xxhash64) Clone() (hash.Cloner, error) {
+    c := *x
+    return &c, nil
+}
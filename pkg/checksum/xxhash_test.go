@@ -0,0 +1,59 @@
+package checksum
+
+import (
+    "encoding/hex"
+    "fmt"
+    "testing"
+)
+
+func TestXXHash64(t *testing.T) {
+    // Lengths around the 4, 8 and 32 byte steps of the algorithm, over
+    // the bytes 0, 1, 2, ... modulo 251.
+    for _, tt := range []struct {
+        n    int
+        want string
+    }{
+        {0, "ef46db3751d8e999"},
+        {1, "e934a84adb052768"},
+        {4, "ffced8604453cc1e"},
+        {8, "884a173614b81b8d"},
+        {31, "c346d2b59b4d8ee1"},
+        {32, "cbf59c5116ff32b4"},
+        {33, "0c535d1acafb8ead"},
+        {63, "e26aa9e2a95f8e4f"},
+        {64, "f7c67301db6713f0"},
+        {100, "6ac1e58032166597"},
+        {1000, "f306f04aa88b54d3"},
+    } {
+        data := make([]byte, tt.n)
+        for i := range data {
+            data[i] = byte(i % 251)
+        }
+        h := newXXHash64()
+        h.Write(data)
+        if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+            t.Errorf("xxhash64 of %d bytes = %s, want %s", tt.n, got, tt.want)
+        }
+        if got := fmt.Sprintf("%016x", h.Sum64()); got != tt.want {
+            t.Errorf("Sum64 of %d bytes = %s, want %s", tt.n, got, tt.want)
+        }
+
+        // Writes of every size split the buffered 32-byte stripes
+        // differently and must not change the digest.
+        for step := 1; step <= 40 && step < tt.n; step += 3 {
+            h.Reset()
+            for i := 0; i < len(data); i += step {
+                h.Write(data[i:min(i+step, len(data))])
+            }
+            if got := hex.EncodeToString(h.Sum(nil)); got != tt.want {
+                t.Errorf("xxhash64 of %d bytes in writes of %d = %s, want %s", tt.n, step, got, tt.want)
+            }
+        }
+    }
+
+    h := newXXHash64()
+    h.Write([]byte("Nobody inspects the spammish repetition"))
+    if got := hex.EncodeToString(h.Sum(nil)); got != "fbcea83c8a378bf1" {
+        t.Errorf("xxhash64 = %s, want fbcea83c8a378bf1", got)
+    }
+}